@@ -20,20 +20,26 @@ func main() {
 
 	log.Printf("Starting %s", workerConfig.Name)
 	log.Printf("Refresh frequency: %v", workerConfig.Frequency)
+	log.Printf("Job frequency: %v", workerConfig.JobFrequency)
 	log.Printf("Database: %s:%d/%s", workerConfig.DBConfig.Host, workerConfig.DBConfig.Port, workerConfig.DBConfig.Database)
 
-	// Create worker
+	// Create workers
 	worker, err := workers.NewNmapWorker(workerConfig)
 	if err != nil {
 		log.Fatalf("Failed to create worker: %v", err)
 	}
+	ingestionWorker, err := workers.NewIngestionWorker(workerConfig)
+	if err != nil {
+		log.Fatalf("Failed to create ingestion worker: %v", err)
+	}
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start worker
+	// Start workers
 	worker.Start(ctx)
+	ingestionWorker.Start(ctx)
 
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
@@ -43,5 +49,6 @@ func main() {
 	log.Println("Shutdown signal received")
 
 	worker.Stop()
-	log.Println("Worker stopped")
+	ingestionWorker.Stop()
+	log.Println("Workers stopped")
 }
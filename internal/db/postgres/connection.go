@@ -27,6 +27,16 @@ func NewPostgresDB(dsn string) (*gorm.DB, error) {
 		&models.Service{},
 		&models.ScanResult{},
 		&models.NmapScriptResult{},
+		&models.NmapOSGuess{},
+		&models.NucleiFinding{},
+		&models.HttpxResult{},
+		&models.FfufResult{},
+		&models.WPScanScan{},
+		&models.WPScanComponent{},
+		&models.WPScanFinding{},
+		&models.WPScanVulnerability{},
+		&models.IngestionJob{},
+		&models.SavedSearch{},
 		&widgets.WidgetDashboardScan{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to migrate schema: %w", err)
@@ -65,5 +75,10 @@ func NewPostgresDB(dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to create dashboard scan_start index: %w", err)
 	}
 
+	// Changes of the existing rows, applied once
+	if err := runMigrations(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
@@ -39,10 +39,14 @@ func (d *DashboardRepositoryImpl) ReadyCheck() utils.Checker {
 }
 
 // GetDashboardScans retrieves paginated scan-host combinations from materialized view
-func (d *DashboardRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (uint64, []widgets.WidgetDashboardScan, error) {
+func (d *DashboardRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[widgets.WidgetDashboardScan], error) {
 	return postgres.Search[widgets.WidgetDashboardScan](ctx, d.db, params)
 }
 
+func (d *DashboardRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[widgets.WidgetDashboardScan](ctx, d.db, params)
+}
+
 func (d *DashboardRepositoryImpl) RefreshMaterializedView(ctx context.Context) error {
 	return d.db.WithContext(ctx).
 		Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY dashboard_scans").
@@ -62,6 +66,14 @@ func (d *DashboardRepositoryImpl) CreateDashboardScans(ctx context.Context, rows
 	return nil
 }
 
+// DeleteDashboardScans removes the rows of a scan
+func (d *DashboardRepositoryImpl) DeleteDashboardScans(ctx context.Context, scanID string) error {
+	if err := d.db.WithContext(ctx).Where("scan_id = ?", scanID).Delete(&widgets.WidgetDashboardScan{}).Error; err != nil {
+		return fmt.Errorf("failed to delete dashboard scans: %w", err)
+	}
+	return nil
+}
+
 // TruncateDashboard clears the dashboard table
 func (d *DashboardRepositoryImpl) TruncateDashboard(ctx context.Context) error {
 	// Truncate all rows, restart identity (auto-increment) and cascade if needed
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// FfufRepositoryImpl implements FfufRepository interface for ffuf results persistence
+type FfufRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFfufRepository(db *gorm.DB) repositories.FfufRepository {
+	return &FfufRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (f *FfufRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+func (f *FfufRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.FfufResult], error) {
+	return postgres.Search[models.FfufResult](ctx, f.db, params)
+}
+
+func (f *FfufRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.FfufResult](ctx, f.db, params)
+}
+
+// InsertResults inserts ffuf results
+func (f *FfufRepositoryImpl) InsertResults(ctx context.Context, results []models.FfufResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	if err := f.db.WithContext(ctx).CreateInBatches(results, 100).Error; err != nil {
+		return fmt.Errorf("failed to insert ffuf results: %w", err)
+	}
+	return nil
+}
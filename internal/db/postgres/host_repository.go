@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// HostRepositoryImpl implements HostRepository interface for hosts, on nmap data
+type HostRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewHostRepository(db *gorm.DB) repositories.HostRepository {
+	return &HostRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (h *HostRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+// Rows of the scan results of a host, with their service
+const nmapHostScanResultRows = "nmap_scan_results LEFT JOIN nmap_services ON nmap_services.service_id = nmap_scan_results.service_id"
+
+// Relations of a host, searched with dotted parameters (e.g. "scan_results.port"), in any of its scans
+var nmapHostRelations = []postgres.Relation[models.NmapHost]{
+	{Name: "scan_results", Model: &models.ScanResult{}, Table: "nmap_scan_results", From: nmapHostScanResultRows, Where: "nmap_scan_results.host_id = nmap_hosts.host_id"},
+	{Name: "service", Model: &models.Service{}, Table: "nmap_services", From: nmapHostScanResultRows, Where: "nmap_scan_results.host_id = nmap_hosts.host_id"},
+	// Port and host scripts
+	{Name: "scripts", Model: &models.NmapScriptResult{}, Table: "nmap_nse_scripts", From: "nmap_nse_scripts", Where: "nmap_nse_scripts.host_id = nmap_hosts.host_id"},
+	{Name: "os_guesses", Model: &models.NmapOSGuess{}, Table: "nmap_os_guesses", From: "nmap_os_guesses", Where: "nmap_os_guesses.host_id = nmap_hosts.host_id"},
+}
+
+func (h *HostRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+	return postgres.SearchRelated(ctx, h.db, params, nmapHostRelations)
+}
+
+func (h *HostRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.AggregateRelated(ctx, h.db, params, nmapHostRelations)
+}
+
+// GetHost fetches a host, with the OS guesses and host scripts of its latest scan
+func (h *HostRepositoryImpl) GetHost(ctx context.Context, hostID string) (*models.NmapHost, error) {
+	var host models.NmapHost
+	err := h.db.WithContext(ctx).
+		Where("host_id = ?", hostID).
+		Preload("OSGuesses", func(db *gorm.DB) *gorm.DB {
+			return db.
+				Where("scan_id = (SELECT latest.scan_id FROM nmap_os_guesses latest WHERE latest.host_id = ? ORDER BY latest.seen_at DESC LIMIT 1)", hostID).
+				Order("accuracy DESC")
+		}).
+		Preload("HostScripts", func(db *gorm.DB) *gorm.DB {
+			return db.
+				Where("scan_result_id IS NULL").
+				Where("scan_id = (SELECT latest.scan_id FROM nmap_nse_scripts latest JOIN nmap_scans ON nmap_scans.scan_id = latest.scan_id "+
+					"WHERE latest.host_id = ? AND latest.scan_result_id IS NULL ORDER BY nmap_scans.scan_start DESC LIMIT 1)", hostID).
+				Order("script_id")
+		}).
+		First(&host).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "host", ID: hostID}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host: %w", err)
+	}
+	return &host, nil
+}
+
+// GetHostScans fetches the scans of a host, even those where it had no port (only OS guesses or host scripts)
+func (h *HostRepositoryImpl) GetHostScans(ctx context.Context, hostID string) ([]models.NmapScan, error) {
+	var scans []models.NmapScan
+	if err := h.db.WithContext(ctx).
+		Where("scan_id IN (SELECT scan_id FROM nmap_scan_results WHERE host_id = @host "+
+			"UNION SELECT scan_id FROM nmap_os_guesses WHERE host_id = @host "+
+			"UNION SELECT scan_id FROM nmap_nse_scripts WHERE host_id = @host)", map[string]any{"host": hostID}).
+		Order("scan_start").
+		Order("scan_id").
+		Find(&scans).Error; err != nil {
+		return nil, fmt.Errorf("failed to get host scans: %w", err)
+	}
+	return scans, nil
+}
+
+// GetHostHistory fetches the scan results of a host, in the order of their scans
+func (h *HostRepositoryImpl) GetHostHistory(ctx context.Context, hostID string) ([]models.HostScanResult, error) {
+	var results []models.HostScanResult
+	if err := h.db.WithContext(ctx).
+		Select("nmap_scan_results.*, nmap_scans.scan_start, nmap_scans.scanner").
+		Joins("JOIN nmap_scans ON nmap_scans.scan_id = nmap_scan_results.scan_id").
+		Where("nmap_scan_results.host_id = ?", hostID).
+		Order("nmap_scans.scan_start").
+		Order("nmap_scans.scan_id").
+		Order("nmap_scan_results.port").
+		Preload("Service").
+		Preload("Scripts").
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get host history: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// HttpxRepositoryImpl implements HttpxRepository interface for httpx results persistence
+type HttpxRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewHttpxRepository(db *gorm.DB) repositories.HttpxRepository {
+	return &HttpxRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (h *HttpxRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+func (h *HttpxRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+	return postgres.Search[models.HttpxResult](ctx, h.db, params)
+}
+
+func (h *HttpxRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.HttpxResult](ctx, h.db, params)
+}
+
+// InsertResults inserts httpx results
+func (h *HttpxRepositoryImpl) InsertResults(ctx context.Context, results []models.HttpxResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	if err := h.db.WithContext(ctx).CreateInBatches(results, 100).Error; err != nil {
+		return fmt.Errorf("failed to insert httpx results: %w", err)
+	}
+	return nil
+}
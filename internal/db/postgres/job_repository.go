@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepositoryImpl implements JobRepository interface for ingestion jobs
+type JobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) repositories.JobRepository {
+	return &JobRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (j *JobRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+func (j *JobRepositoryImpl) CreateJob(ctx context.Context, job *models.IngestionJob) error {
+	if err := j.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to insert job: %w", err)
+	}
+	return nil
+}
+
+func (j *JobRepositoryImpl) GetJob(ctx context.Context, jobID string) (*models.IngestionJob, error) {
+	var job models.IngestionJob
+	err := j.db.WithContext(ctx).
+		Where("job_id = ?", jobID).
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "job", ID: jobID}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return &job, nil
+}
+
+// ClaimJob locks the oldest queued (or stale) job, skipping the ones other workers are claiming
+func (j *JobRepositoryImpl) ClaimJob(ctx context.Context) (*models.IngestionJob, error) {
+	var job models.IngestionJob
+	err := j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := claimableJobs(tx, now).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order("created_at").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		// A stale job is imported again from the start, its imported files being found as re-uploads
+		job.Status = string(models.JOB_STATUS_RUNNING)
+		job.StartedAt = &now
+		job.HeartbeatAt = &now
+		job.Attempts++
+		job.HostsProcessed = 0
+		job.PortsProcessed = 0
+		return tx.Model(&job).Updates(map[string]any{
+			"status":          job.Status,
+			"started_at":      now,
+			"heartbeat_at":    now,
+			"attempts":        job.Attempts,
+			"hosts_processed": 0,
+			"ports_processed": 0,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "job", ID: string(models.JOB_STATUS_QUEUED)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return &job, nil
+}
+
+// Queued jobs, and running ones whose worker stopped: no heartbeat (or start, before heartbeats) for JOB_STALE_TIMEOUT
+func claimableJobs(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("status = ? OR (status = ? AND COALESCE(heartbeat_at, started_at) < ?)",
+		models.JOB_STATUS_QUEUED, models.JOB_STATUS_RUNNING, now.Add(-models.JOB_STALE_TIMEOUT))
+}
+
+func (j *JobRepositoryImpl) HeartbeatJob(ctx context.Context, jobID string) error {
+	if err := j.db.WithContext(ctx).
+		Model(&models.IngestionJob{}).
+		Where("job_id = ? AND status = ?", jobID, models.JOB_STATUS_RUNNING).
+		Update("heartbeat_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to save job heartbeat: %w", err)
+	}
+	return nil
+}
+
+func (j *JobRepositoryImpl) UpdateJobProgress(ctx context.Context, jobID string, hosts, ports int64) error {
+	if err := j.db.WithContext(ctx).
+		Model(&models.IngestionJob{}).
+		Where("job_id = ?", jobID).
+		Updates(map[string]any{
+			"hosts_processed": hosts,
+			"ports_processed": ports,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+	return nil
+}
+
+func (j *JobRepositoryImpl) FinishJob(ctx context.Context, job *models.IngestionJob) error {
+	if err := j.db.WithContext(ctx).
+		Model(job).
+		Select("status", "files", "error", "hosts_processed", "ports_processed", "finished_at").
+		Updates(job).Error; err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+	return nil
+}
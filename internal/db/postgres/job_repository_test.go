@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/stretchr/testify/assert"
+	pg "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestClaimableJobs(t *testing.T) {
+	db, err := gorm.Open(pg.New(pg.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	statement := claimableJobs(db, now).Find(&[]models.IngestionJob{}).Statement
+
+	// Running jobs are claimed again once their worker stopped saving heartbeats
+	assert.Equal(t,
+		`SELECT * FROM "ingestion_jobs" WHERE status = $1 OR (status = $2 AND COALESCE(heartbeat_at, started_at) < $3)`,
+		statement.SQL.String())
+	assert.Equal(t, []any{models.JOB_STATUS_QUEUED, models.JOB_STATUS_RUNNING, now.Add(-models.JOB_STALE_TIMEOUT)}, statement.Vars)
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Key of the advisory lock taken while migrating, as the API and the worker start together
+const migrationLockKey = 0x5368697279
+
+// Number of rows of a table migrated at once
+const migrationBatchSize = 1000
+
+// schemaMigration records a migration once applied
+type schemaMigration struct {
+	Name      string    `gorm:"type:varchar(255);primaryKey"`
+	AppliedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migration changes the existing rows once, after AutoMigrate changed the schema
+type migration struct {
+	// Never renamed, or the migration is run again
+	name string
+	run  func(tx *gorm.DB) error
+}
+
+// Applied in order, appended to only
+var migrations = []migration{
+	{name: "backfill_host_ips", run: backfillHostIPs},
+	{name: "link_scan_hosts", run: linkScanHosts},
+}
+
+// runMigrations applies the migrations not applied yet, each one in its own transaction
+func runMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to migrate schema migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			// Released with the transaction: the other process then sees the migration applied
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+				return err
+			}
+
+			var applied int64
+			if err := tx.Model(&schemaMigration{}).Where("name = ?", m.name).Count(&applied).Error; err != nil {
+				return err
+			}
+			if applied > 0 {
+				return nil
+			}
+
+			if err := m.run(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Name: m.name}).Error
+		}); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// Hosts imported before IPs were stored
+// Only addresses parsed as IPs are set, like on import: MAC addresses and other non-IP hosts are skipped
+func backfillHostIPs(tx *gorm.DB) error {
+	var hosts []models.NmapHost
+	return tx.Select("host_id", "host").Where("ip IS NULL").
+		FindInBatches(&hosts, migrationBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, host := range hosts {
+				ip := models.NewIPAddress(host.Host)
+				if ip == "" {
+					continue
+				}
+				if err := tx.Model(&models.NmapHost{}).Where("host_id = ?", host.HostID).Update("ip", ip).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// Scans imported before their hosts were linked: the hosts of their data
+// Hosts without any (e.g. down ones) weren't stored, they can't be linked
+func linkScanHosts(tx *gorm.DB) error {
+	return tx.Exec(`
+		INSERT INTO scan_hosts (nmap_scan_scan_id, nmap_host_host_id)
+		SELECT scan_id, host_id FROM nmap_scan_results
+		UNION SELECT scan_id, host_id FROM nmap_os_guesses
+		UNION SELECT scan_id, host_id FROM nmap_nse_scripts
+		ON CONFLICT DO NOTHING
+	`).Error
+}
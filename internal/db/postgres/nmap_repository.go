@@ -2,12 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/core/models/widgets"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -29,34 +35,60 @@ func (n *NmapRepositoryImpl) ReadyCheck() utils.Checker {
 	}
 }
 
-func (n *NmapRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (uint64, []models.NmapScan, error) {
-	return postgres.Search[models.NmapScan](ctx, n.db, params,
+// Rows of the scan results of a scan, with their host and service
+// Hosts and services are the ones of the scan results, so that "a host with port 22 running OpenSSH" is matched by one row
+const nmapScanResultRows = "nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id " +
+	"LEFT JOIN nmap_services ON nmap_services.service_id = nmap_scan_results.service_id"
+
+// Relations of a scan, searched with dotted parameters (e.g. "scan_results.port")
+var nmapScanRelations = []postgres.Relation[models.NmapScan]{
+	{Name: "hosts", Model: &models.NmapHost{}, Table: "nmap_hosts", From: nmapScanResultRows, Where: "nmap_scan_results.scan_id = nmap_scans.scan_id"},
+	{Name: "scan_results", Model: &models.ScanResult{}, Table: "nmap_scan_results", From: nmapScanResultRows, Where: "nmap_scan_results.scan_id = nmap_scans.scan_id"},
+	{Name: "service", Model: &models.Service{}, Table: "nmap_services", From: nmapScanResultRows, Where: "nmap_scan_results.scan_id = nmap_scans.scan_id"},
+	// Port and host scripts
+	{Name: "scripts", Model: &models.NmapScriptResult{}, Table: "nmap_nse_scripts", From: "nmap_nse_scripts", Where: "nmap_nse_scripts.scan_id = nmap_scans.scan_id"},
+}
+
+func (n *NmapRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error) {
+	return postgres.SearchRelated(ctx, n.db, params, nmapScanRelations,
 		postgres.Preload[models.NmapScan]{Association: "ScanResults", Fn: func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Scripts")
 		}},
-		postgres.Preload[models.NmapScan]{Association: "Hosts", Fn: nil},
+		postgres.Preload[models.NmapScan]{Association: "Hosts", Fn: func(db *gorm.DB) *gorm.DB {
+			return db.Preload("HostScripts", "scan_result_id IS NULL").Preload("OSGuesses")
+		}},
 	)
 }
 
+func (n *NmapRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.AggregateRelated(ctx, n.db, params, nmapScanRelations)
+}
+
 func (n *NmapRepositoryImpl) GetScan(ctx context.Context, scanID string) (*models.NmapScan, error) {
 	var scan models.NmapScan
-	if err := n.db.WithContext(ctx).
+	err := n.db.WithContext(ctx).
 		Where("scan_id = ?", scanID).
 		Preload("ScanResults", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Scripts")
 		}).
-		First(&scan).Error; err != nil {
+		First(&scan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "scan", ID: scanID}
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to get scan: %w", err)
 	}
 	return &scan, nil
 }
 
+// GetHosts fetches the hosts linked to a scan, with their OS guesses and host scripts of this scan
 func (n *NmapRepositoryImpl) GetHosts(ctx context.Context, scanID string) ([]models.NmapHost, error) {
 	var hosts []models.NmapHost
 	if err := n.db.WithContext(ctx).
-		Joins("JOIN nmap_scan_results ON nmap_hosts.host_id = nmap_scan_results.host_id").
-		Where("nmap_scan_results.scan_id = ?", scanID).
-		Distinct("nmap_hosts.*").
+		Joins("JOIN scan_hosts ON scan_hosts.nmap_host_host_id = nmap_hosts.host_id").
+		Where("scan_hosts.nmap_scan_scan_id = ?", scanID).
+		Preload("HostScripts", "scan_result_id IS NULL AND scan_id = ?", scanID).
+		Preload("OSGuesses", "scan_id = ?", scanID).
 		Find(&hosts).Error; err != nil {
 		return nil, fmt.Errorf("failed to get hosts: %w", err)
 	}
@@ -75,6 +107,150 @@ func (n *NmapRepositoryImpl) GetScanResults(ctx context.Context, scanID, hostID
 	return results, nil
 }
 
+// GetScanServices fetches the services found by a scan
+func (n *NmapRepositoryImpl) GetScanServices(ctx context.Context, scanID string) ([]models.Service, error) {
+	var services []models.Service
+	if err := n.db.WithContext(ctx).
+		Where("service_id IN (SELECT service_id FROM nmap_scan_results WHERE scan_id = ?)", scanID).
+		Find(&services).Error; err != nil {
+		return nil, fmt.Errorf("failed to get scan services: %w", err)
+	}
+	return services, nil
+}
+
+// Tables of other tools attached to nmap hosts and services: hosts and services they reference are never orphans
+var (
+	nmapHostReferences    = []string{"httpx_results", "nuclei_findings", "ffuf_results", "wpscan_scans", "wpscan_components", "wpscan_findings", "wpscan_vulnerabilities"}
+	nmapServiceReferences = []string{"httpx_results", "nuclei_findings", "ffuf_results", "wpscan_scans"}
+)
+
+// Condition of rows of table not referenced by any of the tables, on column
+func notReferenced(table, column string, tables ...string) string {
+	conditions := make([]string, 0, len(tables))
+	for _, other := range tables {
+		conditions = append(conditions, fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %[1]s WHERE %[1]s.%[2]s = %[3]s.%[2]s)", other, column, table))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// DeleteScan deletes a scan, everything it imported and its dashboard rows, in a single transaction
+// Hosts and services of the scan are deleted if nothing else references them anymore
+func (n *NmapRepositoryImpl) DeleteScan(ctx context.Context, scanID string) error {
+	return n.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scan := models.NmapScan{}
+		err := tx.Where("scan_id = ?", scanID).First(&scan).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return shiryoku_errors.NotFoundError{Resource: "scan", ID: scanID}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get scan: %w", err)
+		}
+
+		// Collected before their data goes
+		var hostIDs, serviceIDs []string
+		if err := tx.Raw("SELECT nmap_host_host_id FROM scan_hosts WHERE nmap_scan_scan_id = @scan "+
+			"UNION SELECT host_id FROM nmap_scan_results WHERE scan_id = @scan "+
+			"UNION SELECT host_id FROM nmap_os_guesses WHERE scan_id = @scan "+
+			"UNION SELECT host_id FROM nmap_nse_scripts WHERE scan_id = @scan", map[string]any{"scan": scanID}).
+			Scan(&hostIDs).Error; err != nil {
+			return fmt.Errorf("failed to get scan hosts: %w", err)
+		}
+		if err := tx.Raw("SELECT DISTINCT service_id FROM nmap_scan_results WHERE scan_id = ?", scanID).
+			Scan(&serviceIDs).Error; err != nil {
+			return fmt.Errorf("failed to get scan services: %w", err)
+		}
+
+		for _, model := range []any{&models.NmapScriptResult{}, &models.NmapOSGuess{}, &models.ScanResult{}, &widgets.WidgetDashboardScan{}} {
+			if err := tx.Where("scan_id = ?", scanID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to delete scan data: %w", err)
+			}
+		}
+		if err := tx.Model(&scan).Association("Hosts").Clear(); err != nil {
+			return fmt.Errorf("failed to delete scan hosts: %w", err)
+		}
+		if err := tx.Delete(&scan).Error; err != nil {
+			return fmt.Errorf("failed to delete scan: %w", err)
+		}
+
+		if len(hostIDs) > 0 {
+			if err := tx.
+				Where("host_id IN ?", hostIDs).
+				Where(notReferenced("nmap_hosts", "host_id", "nmap_scan_results", "nmap_os_guesses", "nmap_nse_scripts")).
+				Where("NOT EXISTS (SELECT 1 FROM scan_hosts WHERE scan_hosts.nmap_host_host_id = nmap_hosts.host_id)").
+				Where(notReferenced("nmap_hosts", "host_id", nmapHostReferences...)).
+				Delete(&models.NmapHost{}).Error; err != nil {
+				return fmt.Errorf("failed to delete orphaned hosts: %w", err)
+			}
+		}
+		if len(serviceIDs) > 0 {
+			if err := tx.
+				Where("service_id IN ?", serviceIDs).
+				Where(notReferenced("nmap_services", "service_id", "nmap_scan_results")).
+				Where(notReferenced("nmap_services", "service_id", nmapServiceReferences...)).
+				Delete(&models.Service{}).Error; err != nil {
+				return fmt.Errorf("failed to delete orphaned services: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// FindHostByAddress fetches the most recent host of a project owning an address or a hostname
+func (n *NmapRepositoryImpl) FindHostByAddress(ctx context.Context, project, address string) (*models.NmapHost, error) {
+	var host models.NmapHost
+	err := n.db.WithContext(ctx).
+		Where("project = ?", project).
+		Where("host = ? OR ? = ANY(addresses) OR ? = ANY(hostnames)", address, address, address).
+		Order("last_seen DESC NULLS LAST").
+		First(&host).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "host", ID: address}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find host: %w", err)
+	}
+	return &host, nil
+}
+
+// FindLatestScanResult fetches the result of the most recent scan of a host on a port
+func (n *NmapRepositoryImpl) FindLatestScanResult(ctx context.Context, hostID string, port uint16) (*models.ScanResult, error) {
+	var result models.ScanResult
+	err := n.db.WithContext(ctx).
+		Joins("JOIN nmap_scans ON nmap_scans.scan_id = nmap_scan_results.scan_id").
+		Where("nmap_scan_results.host_id = ? AND nmap_scan_results.port = ?", hostID, port).
+		Order("nmap_scans.scan_start DESC").
+		First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "scan result", ID: fmt.Sprintf("%s:%d", hostID, port)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scan result: %w", err)
+	}
+	return &result, nil
+}
+
+// FindScanByHash fetches the scan imported from a file with this hash in a project
+func (n *NmapRepositoryImpl) FindScanByHash(ctx context.Context, project, contentHash string) (*models.NmapScan, error) {
+	var scan models.NmapScan
+	err := n.db.WithContext(ctx).
+		Where("project = ? AND content_hash = ?", project, contentHash).
+		First(&scan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "scan", ID: contentHash}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scan: %w", err)
+	}
+	return &scan, nil
+}
+
+// WithTransaction runs fn with a repository bound to a single transaction
+func (n *NmapRepositoryImpl) WithTransaction(ctx context.Context, fn func(repo repositories.NmapRepository) error) error {
+	return n.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&NmapRepositoryImpl{db: tx})
+	})
+}
+
 // GetOrCreateService finds or creates a service by its signature
 func (n *NmapRepositoryImpl) GetOrCreateService(ctx context.Context, service *models.Service) (*models.Service, error) {
 	result := &models.Service{}
@@ -93,7 +269,13 @@ func (n *NmapRepositoryImpl) GetOrCreateService(ctx context.Context, service *mo
 func (n *NmapRepositoryImpl) InsertScan(ctx context.Context, scan *models.NmapScan) error {
 	return n.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Insert scan
-		if err := tx.Create(scan).Error; err != nil {
+		err := tx.Create(scan).Error
+		// Unique violation: the same file was imported in the same project meanwhile
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_scan_content_hash" {
+			return shiryoku_errors.AlreadyExistsError{Resource: "scan", ID: scan.ContentHash}
+		}
+		if err != nil {
 			return fmt.Errorf("failed to insert scan: %w", err)
 		}
 		return nil
@@ -105,11 +287,11 @@ func (n *NmapRepositoryImpl) InsertHosts(ctx context.Context, hosts []models.Nma
 	if len(hosts) == 0 {
 		return nil
 	}
-	// Use OnConflict to handle duplicate hosts (merge addresses and hostnames)
+	// Host IDs are stable: merge into the host imported before
 	if err := n.db.WithContext(ctx).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "host_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"addresses", "hostnames", "host_status", "os_name", "os_accuracy", "comment"}),
+			DoUpdates: hostMergeAssignments,
 		}).
 		CreateInBatches(hosts, 100).Error; err != nil {
 		return fmt.Errorf("failed to insert hosts: %w", err)
@@ -117,6 +299,57 @@ func (n *NmapRepositoryImpl) InsertHosts(ctx context.Context, hosts []models.Nma
 	return nil
 }
 
+// Union of two arrays, keeping the order of first appearance
+const arrayUnion = "ARRAY(SELECT v FROM unnest(COALESCE(nmap_hosts.%[1]s, '{}') || COALESCE(excluded.%[1]s, '{}')) WITH ORDINALITY AS t(v, i) GROUP BY v ORDER BY min(i))"
+
+// Whether the imported host comes from a scan at least as recent as the stored one
+// (imports are not always chronological)
+const excludedIsNewer = "(excluded.last_seen >= nmap_hosts.last_seen) IS NOT FALSE"
+
+// Value from the newest scan, unless it's empty
+func newestNonEmpty(column, empty string) clause.Expr {
+	return gorm.Expr(fmt.Sprintf(
+		"CASE WHEN excluded.%[1]s <> %[2]s AND (COALESCE(nmap_hosts.%[1]s, %[2]s) = %[2]s OR %[3]s) THEN excluded.%[1]s ELSE nmap_hosts.%[1]s END",
+		column, empty, excludedIsNewer,
+	))
+}
+
+var hostMergeAssignments = clause.Set{
+	// Hosts imported before IPs were stored
+	{Column: clause.Column{Name: "ip"}, Value: gorm.Expr("COALESCE(nmap_hosts.ip, excluded.ip)")},
+	{Column: clause.Column{Name: "addresses"}, Value: gorm.Expr(fmt.Sprintf(arrayUnion, "addresses"))},
+	{Column: clause.Column{Name: "hostnames"}, Value: gorm.Expr(fmt.Sprintf(arrayUnion, "hostnames"))},
+	{Column: clause.Column{Name: "host_status"}, Value: newestNonEmpty("host_status", "''")},
+	{Column: clause.Column{Name: "comment"}, Value: newestNonEmpty("comment", "''")},
+	// The OS name and accuracy go together
+	{Column: clause.Column{Name: "os_accuracy"}, Value: gorm.Expr(fmt.Sprintf(
+		"CASE WHEN excluded.os_name <> '' AND (COALESCE(nmap_hosts.os_name, '') = '' OR %s) THEN excluded.os_accuracy ELSE nmap_hosts.os_accuracy END",
+		excludedIsNewer,
+	))},
+	{Column: clause.Column{Name: "os_name"}, Value: newestNonEmpty("os_name", "''")},
+	// LEAST/GREATEST ignore NULLs (hosts imported before first/last seen existed)
+	{Column: clause.Column{Name: "first_seen"}, Value: gorm.Expr("LEAST(nmap_hosts.first_seen, excluded.first_seen)")},
+	{Column: clause.Column{Name: "last_seen"}, Value: gorm.Expr("GREATEST(nmap_hosts.last_seen, excluded.last_seen)")},
+}
+
+// InsertScanHosts links hosts to a scan (scan_hosts), ignoring the links that already exist
+func (n *NmapRepositoryImpl) InsertScanHosts(ctx context.Context, scanID string, hostIDs []uuid.UUID) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+	links := make([]map[string]any, 0, len(hostIDs))
+	for _, hostID := range hostIDs {
+		links = append(links, map[string]any{"nmap_scan_scan_id": scanID, "nmap_host_host_id": hostID})
+	}
+	if err := n.db.WithContext(ctx).
+		Table("scan_hosts").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(links, 500).Error; err != nil {
+		return fmt.Errorf("failed to link scan hosts: %w", err)
+	}
+	return nil
+}
+
 // InsertScanResults inserts scan result records (ports discovered in a scan on a host with a service)
 func (n *NmapRepositoryImpl) InsertScanResults(ctx context.Context, results []models.ScanResult) error {
 	if len(results) == 0 {
@@ -128,6 +361,17 @@ func (n *NmapRepositoryImpl) InsertScanResults(ctx context.Context, results []mo
 	return nil
 }
 
+// InsertOSGuesses inserts the OS matches of hosts
+func (n *NmapRepositoryImpl) InsertOSGuesses(ctx context.Context, guesses []models.NmapOSGuess) error {
+	if len(guesses) == 0 {
+		return nil
+	}
+	if err := n.db.WithContext(ctx).CreateInBatches(guesses, 100).Error; err != nil {
+		return fmt.Errorf("failed to insert os guesses: %w", err)
+	}
+	return nil
+}
+
 // InsertScripts inserts NSE script results
 func (n *NmapRepositoryImpl) InsertScripts(ctx context.Context, scripts []models.NmapScriptResult) error {
 	if len(scripts) == 0 {
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NucleiRepositoryImpl implements NucleiRepository interface for nuclei findings persistence
+type NucleiRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewNucleiRepository(db *gorm.DB) repositories.NucleiRepository {
+	return &NucleiRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (n *NucleiRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+func (n *NucleiRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NucleiFinding], error) {
+	return postgres.Search[models.NucleiFinding](ctx, n.db, params)
+}
+
+func (n *NucleiRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.NucleiFinding](ctx, n.db, params)
+}
+
+// InsertFindings inserts nuclei findings
+func (n *NucleiRepositoryImpl) InsertFindings(ctx context.Context, findings []models.NucleiFinding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	if err := n.db.WithContext(ctx).CreateInBatches(findings, 100).Error; err != nil {
+		return fmt.Errorf("failed to insert nuclei findings: %w", err)
+	}
+	return nil
+}
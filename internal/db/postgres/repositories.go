@@ -11,7 +11,10 @@ import (
 // DashboardRepository defines operations specific to dashboard views
 type DashboardRepository interface {
 	// Search retrieves paginated scan-host combinations from materialized view
-	Search(ctx context.Context, params *models.SearchParams) (uint64, []widgets.WidgetDashboardScan, error)
+	Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[widgets.WidgetDashboardScan], error)
+
+	// Aggregate counts scan-host combinations by group
+	Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
 
 	// RefreshMaterializedView refreshes the dashboard materialized view
 	RefreshMaterializedView(ctx context.Context) error
@@ -19,6 +22,9 @@ type DashboardRepository interface {
 	// TruncateDashboard removes everything
 	TruncateDashboard(ctx context.Context) error
 
+	// DeleteDashboardScans removes the rows of a scan
+	DeleteDashboardScans(ctx context.Context, scanID string) error
+
 	// Inserts dashboard scans
 	CreateDashboardScans(ctx context.Context, rows []widgets.WidgetDashboardScan) error
 
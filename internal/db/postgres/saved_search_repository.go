@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// SavedSearchRepositoryImpl implements SavedSearchRepository interface for saved searches
+type SavedSearchRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSavedSearchRepository(db *gorm.DB) repositories.SavedSearchRepository {
+	return &SavedSearchRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (s *SavedSearchRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+func (s *SavedSearchRepositoryImpl) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	if err := s.db.WithContext(ctx).Create(search).Error; err != nil {
+		return fmt.Errorf("failed to insert saved search: %w", err)
+	}
+	return nil
+}
+
+func (s *SavedSearchRepositoryImpl) GetSavedSearch(ctx context.Context, searchID string) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	err := s.db.WithContext(ctx).
+		Where("saved_search_id = ?", searchID).
+		First(&search).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "saved search", ID: searchID}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return &search, nil
+}
+
+func (s *SavedSearchRepositoryImpl) ListSavedSearches(ctx context.Context, target string) ([]models.SavedSearch, error) {
+	query := s.db.WithContext(ctx).Order("name").Order("saved_search_id")
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+
+	searches := []models.SavedSearch{}
+	if err := query.Find(&searches).Error; err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+func (s *SavedSearchRepositoryImpl) UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	result := s.db.WithContext(ctx).
+		Model(search).
+		Select("name", "description", "target", "search", "query", "variables", "updated_at").
+		Updates(search)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update saved search: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return shiryoku_errors.NotFoundError{Resource: "saved search", ID: search.SavedSearchID.String()}
+	}
+	return nil
+}
+
+func (s *SavedSearchRepositoryImpl) DeleteSavedSearch(ctx context.Context, searchID string) error {
+	result := s.db.WithContext(ctx).
+		Where("saved_search_id = ?", searchID).
+		Delete(&models.SavedSearch{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete saved search: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return shiryoku_errors.NotFoundError{Resource: "saved search", ID: searchID}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ServiceRepositoryImpl implements ServiceRepository interface for services found by nmap
+type ServiceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewServiceRepository(db *gorm.DB) repositories.ServiceRepository {
+	return &ServiceRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (s *ServiceRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+// Rows of the scan results of a service, with their host
+const serviceScanResultRows = "nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id"
+
+// Relations of a service, searched with dotted parameters (e.g. "hosts.host"), in any scan
+var serviceRelations = []postgres.Relation[models.Service]{
+	{Name: "scan_results", Model: &models.ScanResult{}, Table: "nmap_scan_results", From: serviceScanResultRows, Where: "nmap_scan_results.service_id = nmap_services.service_id"},
+	{Name: "hosts", Model: &models.NmapHost{}, Table: "nmap_hosts", From: serviceScanResultRows, Where: "nmap_scan_results.service_id = nmap_services.service_id"},
+}
+
+// Ports open as of the latest scan of their host, among the results matching a condition on hosts (%s)
+// A port is open if its latest result is, and comes from the latest scan that reported ports of the host:
+// as for host timelines, a port missing from it is closed
+const currentPortsSQL = `SELECT latest.* FROM (
+	SELECT DISTINCT ON (r.host_id, r.port, COALESCE(s.protocol, ''))
+		r.host_id, r.port, r.port_state, r.service_id, r.scan_id, sc.scan_start
+	FROM nmap_scan_results r
+	JOIN nmap_scans sc ON sc.scan_id = r.scan_id
+	LEFT JOIN nmap_services s ON s.service_id = r.service_id
+	WHERE %s
+	ORDER BY r.host_id, r.port, COALESCE(s.protocol, ''), sc.scan_start DESC, sc.scan_id DESC
+) latest
+WHERE latest.port_state = 'open' AND latest.scan_id = (
+	SELECT hr.scan_id FROM nmap_scan_results hr JOIN nmap_scans hs ON hs.scan_id = hr.scan_id
+	WHERE hr.host_id = latest.host_id
+	ORDER BY hs.scan_start DESC, hs.scan_id DESC
+	LIMIT 1
+)`
+
+func (s *ServiceRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.Service], error) {
+	return postgres.SearchRelated(ctx, s.db, params, serviceRelations)
+}
+
+func (s *ServiceRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.AggregateRelated(ctx, s.db, params, serviceRelations)
+}
+
+// GetService fetches a service, with the start of the oldest and latest scans that found it
+func (s *ServiceRepositoryImpl) GetService(ctx context.Context, serviceID string) (*models.ServiceDetail, error) {
+	var detail models.ServiceDetail
+	err := s.db.WithContext(ctx).
+		Model(&models.Service{}).
+		Select("nmap_services.*, MIN(nmap_scans.scan_start) AS first_seen, MAX(nmap_scans.scan_start) AS last_seen").
+		Joins("LEFT JOIN nmap_scan_results ON nmap_scan_results.service_id = nmap_services.service_id").
+		Joins("LEFT JOIN nmap_scans ON nmap_scans.scan_id = nmap_scan_results.scan_id").
+		Where("nmap_services.service_id = ?", serviceID).
+		Group("nmap_services.service_id").
+		Take(&detail).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shiryoku_errors.NotFoundError{Resource: "service", ID: serviceID}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return &detail, nil
+}
+
+// GetServiceExposures fetches the ports currently open with a service, by host and port
+func (s *ServiceRepositoryImpl) GetServiceExposures(ctx context.Context, serviceID string) ([]models.ServiceExposure, error) {
+	// Later results of the hosts of the service may have closed its ports, or changed their service
+	openPorts := fmt.Sprintf(currentPortsSQL, "r.host_id IN (SELECT host_id FROM nmap_scan_results WHERE service_id = @service)")
+
+	exposures := []models.ServiceExposure{}
+	if err := s.db.WithContext(ctx).
+		Raw("SELECT open_ports.host_id, nmap_hosts.host, nmap_hosts.hostnames, open_ports.port, open_ports.scan_id, open_ports.scan_start "+
+			"FROM ("+openPorts+") open_ports JOIN nmap_hosts ON nmap_hosts.host_id = open_ports.host_id "+
+			"WHERE open_ports.service_id = @service ORDER BY nmap_hosts.host, open_ports.port",
+			map[string]any{"service": serviceID}).
+		Scan(&exposures).Error; err != nil {
+		return nil, fmt.Errorf("failed to get service exposures: %w", err)
+	}
+	return exposures, nil
+}
+
+// GetServiceVersions counts, by version of a product, the hosts currently exposing it
+// Versions no longer exposed are kept, with no host
+func (s *ServiceRepositoryImpl) GetServiceVersions(ctx context.Context, product string) ([]models.ServiceVersion, error) {
+	const productFilter = "(@product = '' OR lower(%s.service_product) = lower(@product))"
+	openPorts := fmt.Sprintf(currentPortsSQL, "r.host_id IN (SELECT pr.host_id FROM nmap_scan_results pr "+
+		"JOIN nmap_services ps ON ps.service_id = pr.service_id WHERE "+fmt.Sprintf(productFilter, "ps")+")")
+
+	versions := []models.ServiceVersion{}
+	if err := s.db.WithContext(ctx).
+		Raw(`WITH seen AS (
+			SELECT s.service_product, s.service_version, MIN(sc.scan_start) AS first_seen, MAX(sc.scan_start) AS last_seen
+			FROM nmap_services s
+			JOIN nmap_scan_results r ON r.service_id = s.service_id
+			JOIN nmap_scans sc ON sc.scan_id = r.scan_id
+			WHERE `+fmt.Sprintf(productFilter, "s")+`
+			GROUP BY s.service_product, s.service_version
+		), exposed AS (
+			SELECT s.service_product, s.service_version, COUNT(DISTINCT open_ports.host_id) AS hosts
+			FROM (`+openPorts+`) open_ports
+			JOIN nmap_services s ON s.service_id = open_ports.service_id
+			WHERE `+fmt.Sprintf(productFilter, "s")+`
+			GROUP BY s.service_product, s.service_version
+		)
+		SELECT seen.service_product, seen.service_version, COALESCE(exposed.hosts, 0) AS hosts, seen.first_seen, seen.last_seen
+		FROM seen LEFT JOIN exposed USING (service_product, service_version)
+		ORDER BY seen.service_product, hosts DESC, seen.service_version`,
+			map[string]any{"product": product}).
+		Scan(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get service versions: %w", err)
+	}
+	return versions, nil
+}
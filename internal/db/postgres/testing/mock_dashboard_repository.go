@@ -0,0 +1,66 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/core/models/widgets"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockDashboardRepository struct {
+	SearchFn                  func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[widgets.WidgetDashboardScan], error)
+	AggregateFn               func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	RefreshMaterializedViewFn func(ctx context.Context) error
+	TruncateDashboardFn       func(ctx context.Context) error
+	DeleteDashboardScansFn    func(ctx context.Context, scanID string) error
+	CreateDashboardScansFn    func(ctx context.Context, rows []widgets.WidgetDashboardScan) error
+}
+
+func (m *MockDashboardRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[widgets.WidgetDashboardScan], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[widgets.WidgetDashboardScan]{Results: []widgets.WidgetDashboardScan{}}, nil
+}
+
+func (m *MockDashboardRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockDashboardRepository) RefreshMaterializedView(ctx context.Context) error {
+	if m.RefreshMaterializedViewFn != nil {
+		return m.RefreshMaterializedViewFn(ctx)
+	}
+	return nil
+}
+
+func (m *MockDashboardRepository) TruncateDashboard(ctx context.Context) error {
+	if m.TruncateDashboardFn != nil {
+		return m.TruncateDashboardFn(ctx)
+	}
+	return nil
+}
+
+func (m *MockDashboardRepository) DeleteDashboardScans(ctx context.Context, scanID string) error {
+	if m.DeleteDashboardScansFn != nil {
+		return m.DeleteDashboardScansFn(ctx, scanID)
+	}
+	return nil
+}
+
+func (m *MockDashboardRepository) CreateDashboardScans(ctx context.Context, rows []widgets.WidgetDashboardScan) error {
+	if m.CreateDashboardScansFn != nil {
+		return m.CreateDashboardScansFn(ctx, rows)
+	}
+	return nil
+}
+
+func (m *MockDashboardRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
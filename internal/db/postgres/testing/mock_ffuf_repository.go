@@ -0,0 +1,41 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockFfufRepository struct {
+	SearchFn        func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.FfufResult], error)
+	AggregateFn     func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	InsertResultsFn func(ctx context.Context, results []models.FfufResult) error
+}
+
+func (m *MockFfufRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.FfufResult], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[models.FfufResult]{Results: []models.FfufResult{}}, nil
+}
+
+func (m *MockFfufRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockFfufRepository) InsertResults(ctx context.Context, results []models.FfufResult) error {
+	if m.InsertResultsFn != nil {
+		return m.InsertResultsFn(ctx, results)
+	}
+	return nil
+}
+
+func (m *MockFfufRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -0,0 +1,58 @@
+package testing
+
+import (
+	"context"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockHostRepository struct {
+	SearchFn         func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error)
+	AggregateFn      func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	GetHostFn        func(ctx context.Context, hostID string) (*models.NmapHost, error)
+	GetHostScansFn   func(ctx context.Context, hostID string) ([]models.NmapScan, error)
+	GetHostHistoryFn func(ctx context.Context, hostID string) ([]models.HostScanResult, error)
+}
+
+func (m *MockHostRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[models.NmapHost]{Results: []models.NmapHost{}}, nil
+}
+
+func (m *MockHostRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockHostRepository) GetHost(ctx context.Context, hostID string) (*models.NmapHost, error) {
+	if m.GetHostFn != nil {
+		return m.GetHostFn(ctx, hostID)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "host", ID: hostID}
+}
+
+func (m *MockHostRepository) GetHostScans(ctx context.Context, hostID string) ([]models.NmapScan, error) {
+	if m.GetHostScansFn != nil {
+		return m.GetHostScansFn(ctx, hostID)
+	}
+	return []models.NmapScan{}, nil
+}
+
+func (m *MockHostRepository) GetHostHistory(ctx context.Context, hostID string) ([]models.HostScanResult, error) {
+	if m.GetHostHistoryFn != nil {
+		return m.GetHostHistoryFn(ctx, hostID)
+	}
+	return []models.HostScanResult{}, nil
+}
+
+func (m *MockHostRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -0,0 +1,41 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockHttpxRepository struct {
+	SearchFn        func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error)
+	AggregateFn     func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	InsertResultsFn func(ctx context.Context, results []models.HttpxResult) error
+}
+
+func (m *MockHttpxRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[models.HttpxResult]{Results: []models.HttpxResult{}}, nil
+}
+
+func (m *MockHttpxRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockHttpxRepository) InsertResults(ctx context.Context, results []models.HttpxResult) error {
+	if m.InsertResultsFn != nil {
+		return m.InsertResultsFn(ctx, results)
+	}
+	return nil
+}
+
+func (m *MockHttpxRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -0,0 +1,66 @@
+package testing
+
+import (
+	"context"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockJobRepository struct {
+	CreateJobFn         func(ctx context.Context, job *models.IngestionJob) error
+	GetJobFn            func(ctx context.Context, jobID string) (*models.IngestionJob, error)
+	ClaimJobFn          func(ctx context.Context) (*models.IngestionJob, error)
+	HeartbeatJobFn      func(ctx context.Context, jobID string) error
+	UpdateJobProgressFn func(ctx context.Context, jobID string, hosts, ports int64) error
+	FinishJobFn         func(ctx context.Context, job *models.IngestionJob) error
+}
+
+func (m *MockJobRepository) CreateJob(ctx context.Context, job *models.IngestionJob) error {
+	if m.CreateJobFn != nil {
+		return m.CreateJobFn(ctx, job)
+	}
+	return nil
+}
+
+func (m *MockJobRepository) GetJob(ctx context.Context, jobID string) (*models.IngestionJob, error) {
+	if m.GetJobFn != nil {
+		return m.GetJobFn(ctx, jobID)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "job", ID: jobID}
+}
+
+func (m *MockJobRepository) ClaimJob(ctx context.Context) (*models.IngestionJob, error) {
+	if m.ClaimJobFn != nil {
+		return m.ClaimJobFn(ctx)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "job", ID: string(models.JOB_STATUS_QUEUED)}
+}
+
+func (m *MockJobRepository) HeartbeatJob(ctx context.Context, jobID string) error {
+	if m.HeartbeatJobFn != nil {
+		return m.HeartbeatJobFn(ctx, jobID)
+	}
+	return nil
+}
+
+func (m *MockJobRepository) UpdateJobProgress(ctx context.Context, jobID string, hosts, ports int64) error {
+	if m.UpdateJobProgressFn != nil {
+		return m.UpdateJobProgressFn(ctx, jobID, hosts, ports)
+	}
+	return nil
+}
+
+func (m *MockJobRepository) FinishJob(ctx context.Context, job *models.IngestionJob) error {
+	if m.FinishJobFn != nil {
+		return m.FinishJobFn(ctx, job)
+	}
+	return nil
+}
+
+func (m *MockJobRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -3,27 +3,47 @@ package testing
 import (
 	"context"
 
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"github.com/google/uuid"
 )
 
 type MockNmapRepository struct {
-	SearchFn             func(ctx context.Context, params *models.SearchParams) (uint64, []models.NmapScan, error)
-	GetScanFn            func(ctx context.Context, scanID string) (*models.NmapScan, error)
-	GetHostsFn           func(ctx context.Context, scanID string) ([]models.NmapHost, error)
-	GetScanResultsFn     func(ctx context.Context, scanID, hostID string) ([]models.ScanResult, error)
-	GetOrCreateServiceFn func(ctx context.Context, service *models.Service) (*models.Service, error)
-	InsertScanFn         func(ctx context.Context, scan *models.NmapScan) error
-	InsertHostsFn        func(ctx context.Context, hosts []models.NmapHost) error
-	InsertScanResultsFn  func(ctx context.Context, results []models.ScanResult) error
-	InsertScriptsFn      func(ctx context.Context, scripts []models.NmapScriptResult) error
-	SearchWithHostsFn    func(ctx context.Context, params *models.SearchParams) (uint64, []models.NmapScan, error)
-}
-
-func (m *MockNmapRepository) Search(ctx context.Context, params *models.SearchParams) (uint64, []models.NmapScan, error) {
+	SearchFn               func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error)
+	AggregateFn            func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	GetScanFn              func(ctx context.Context, scanID string) (*models.NmapScan, error)
+	GetHostsFn             func(ctx context.Context, scanID string) ([]models.NmapHost, error)
+	GetScanResultsFn       func(ctx context.Context, scanID, hostID string) ([]models.ScanResult, error)
+	GetScanServicesFn      func(ctx context.Context, scanID string) ([]models.Service, error)
+	DeleteScanFn           func(ctx context.Context, scanID string) error
+	FindHostByAddressFn    func(ctx context.Context, project, address string) (*models.NmapHost, error)
+	FindLatestScanResultFn func(ctx context.Context, hostID string, port uint16) (*models.ScanResult, error)
+	FindScanByHashFn       func(ctx context.Context, project, contentHash string) (*models.NmapScan, error)
+	WithTransactionFn      func(ctx context.Context, fn func(repo repositories.NmapRepository) error) error
+	GetOrCreateServiceFn   func(ctx context.Context, service *models.Service) (*models.Service, error)
+	InsertScanFn           func(ctx context.Context, scan *models.NmapScan) error
+	InsertHostsFn          func(ctx context.Context, hosts []models.NmapHost) error
+	InsertScanHostsFn      func(ctx context.Context, scanID string, hostIDs []uuid.UUID) error
+	InsertScanResultsFn    func(ctx context.Context, results []models.ScanResult) error
+	InsertScriptsFn        func(ctx context.Context, scripts []models.NmapScriptResult) error
+	InsertOSGuessesFn      func(ctx context.Context, guesses []models.NmapOSGuess) error
+	SearchWithHostsFn      func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error)
+}
+
+func (m *MockNmapRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error) {
 	if m.SearchFn != nil {
 		return m.SearchFn(ctx, params)
 	}
-	return 0, []models.NmapScan{}, nil
+	return &models.SearchResult[models.NmapScan]{Results: []models.NmapScan{}}, nil
+}
+
+func (m *MockNmapRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
 }
 
 func (m *MockNmapRepository) GetScan(ctx context.Context, scanID string) (*models.NmapScan, error) {
@@ -47,6 +67,34 @@ func (m *MockNmapRepository) GetScanResults(ctx context.Context, scanID, hostID
 	return nil, nil
 }
 
+func (m *MockNmapRepository) GetScanServices(ctx context.Context, scanID string) ([]models.Service, error) {
+	if m.GetScanServicesFn != nil {
+		return m.GetScanServicesFn(ctx, scanID)
+	}
+	return nil, nil
+}
+
+func (m *MockNmapRepository) DeleteScan(ctx context.Context, scanID string) error {
+	if m.DeleteScanFn != nil {
+		return m.DeleteScanFn(ctx, scanID)
+	}
+	return shiryoku_errors.NotFoundError{Resource: "scan", ID: scanID}
+}
+
+func (m *MockNmapRepository) FindHostByAddress(ctx context.Context, project, address string) (*models.NmapHost, error) {
+	if m.FindHostByAddressFn != nil {
+		return m.FindHostByAddressFn(ctx, project, address)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "host", ID: address}
+}
+
+func (m *MockNmapRepository) FindLatestScanResult(ctx context.Context, hostID string, port uint16) (*models.ScanResult, error) {
+	if m.FindLatestScanResultFn != nil {
+		return m.FindLatestScanResultFn(ctx, hostID, port)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "scan result", ID: hostID}
+}
+
 func (m *MockNmapRepository) GetOrCreateService(ctx context.Context, service *models.Service) (*models.Service, error) {
 	if m.GetOrCreateServiceFn != nil {
 		return m.GetOrCreateServiceFn(ctx, service)
@@ -68,6 +116,13 @@ func (m *MockNmapRepository) InsertHosts(ctx context.Context, hosts []models.Nma
 	return nil
 }
 
+func (m *MockNmapRepository) InsertScanHosts(ctx context.Context, scanID string, hostIDs []uuid.UUID) error {
+	if m.InsertScanHostsFn != nil {
+		return m.InsertScanHostsFn(ctx, scanID, hostIDs)
+	}
+	return nil
+}
+
 func (m *MockNmapRepository) InsertScanResults(ctx context.Context, results []models.ScanResult) error {
 	if m.InsertScanResultsFn != nil {
 		return m.InsertScanResultsFn(ctx, results)
@@ -82,9 +137,37 @@ func (m *MockNmapRepository) InsertScripts(ctx context.Context, scripts []models
 	return nil
 }
 
-func (m *MockNmapRepository) SearchWithHosts(ctx context.Context, params *models.SearchParams) (uint64, []models.NmapScan, error) {
+func (m *MockNmapRepository) FindScanByHash(ctx context.Context, project, contentHash string) (*models.NmapScan, error) {
+	if m.FindScanByHashFn != nil {
+		return m.FindScanByHashFn(ctx, project, contentHash)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "scan", ID: contentHash}
+}
+
+// WithTransaction runs fn on the mock itself, unless overridden
+func (m *MockNmapRepository) WithTransaction(ctx context.Context, fn func(repo repositories.NmapRepository) error) error {
+	if m.WithTransactionFn != nil {
+		return m.WithTransactionFn(ctx, fn)
+	}
+	return fn(m)
+}
+
+func (m *MockNmapRepository) InsertOSGuesses(ctx context.Context, guesses []models.NmapOSGuess) error {
+	if m.InsertOSGuessesFn != nil {
+		return m.InsertOSGuessesFn(ctx, guesses)
+	}
+	return nil
+}
+
+func (m *MockNmapRepository) SearchWithHosts(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error) {
 	if m.SearchWithHostsFn != nil {
 		return m.SearchWithHostsFn(ctx, params)
 	}
-	return 0, []models.NmapScan{}, nil
+	return &models.SearchResult[models.NmapScan]{Results: []models.NmapScan{}}, nil
+}
+
+func (m *MockNmapRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
 }
@@ -0,0 +1,41 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockNucleiRepository struct {
+	SearchFn         func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NucleiFinding], error)
+	AggregateFn      func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	InsertFindingsFn func(ctx context.Context, findings []models.NucleiFinding) error
+}
+
+func (m *MockNucleiRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NucleiFinding], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[models.NucleiFinding]{Results: []models.NucleiFinding{}}, nil
+}
+
+func (m *MockNucleiRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockNucleiRepository) InsertFindings(ctx context.Context, findings []models.NucleiFinding) error {
+	if m.InsertFindingsFn != nil {
+		return m.InsertFindingsFn(ctx, findings)
+	}
+	return nil
+}
+
+func (m *MockNucleiRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -0,0 +1,58 @@
+package testing
+
+import (
+	"context"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockSavedSearchRepository struct {
+	CreateSavedSearchFn func(ctx context.Context, search *models.SavedSearch) error
+	GetSavedSearchFn    func(ctx context.Context, searchID string) (*models.SavedSearch, error)
+	ListSavedSearchesFn func(ctx context.Context, target string) ([]models.SavedSearch, error)
+	UpdateSavedSearchFn func(ctx context.Context, search *models.SavedSearch) error
+	DeleteSavedSearchFn func(ctx context.Context, searchID string) error
+}
+
+func (m *MockSavedSearchRepository) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	if m.CreateSavedSearchFn != nil {
+		return m.CreateSavedSearchFn(ctx, search)
+	}
+	return nil
+}
+
+func (m *MockSavedSearchRepository) GetSavedSearch(ctx context.Context, searchID string) (*models.SavedSearch, error) {
+	if m.GetSavedSearchFn != nil {
+		return m.GetSavedSearchFn(ctx, searchID)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "saved search", ID: searchID}
+}
+
+func (m *MockSavedSearchRepository) ListSavedSearches(ctx context.Context, target string) ([]models.SavedSearch, error) {
+	if m.ListSavedSearchesFn != nil {
+		return m.ListSavedSearchesFn(ctx, target)
+	}
+	return []models.SavedSearch{}, nil
+}
+
+func (m *MockSavedSearchRepository) UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	if m.UpdateSavedSearchFn != nil {
+		return m.UpdateSavedSearchFn(ctx, search)
+	}
+	return nil
+}
+
+func (m *MockSavedSearchRepository) DeleteSavedSearch(ctx context.Context, searchID string) error {
+	if m.DeleteSavedSearchFn != nil {
+		return m.DeleteSavedSearchFn(ctx, searchID)
+	}
+	return nil
+}
+
+func (m *MockSavedSearchRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -0,0 +1,58 @@
+package testing
+
+import (
+	"context"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockServiceRepository struct {
+	SearchFn              func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.Service], error)
+	AggregateFn           func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	GetServiceFn          func(ctx context.Context, serviceID string) (*models.ServiceDetail, error)
+	GetServiceExposuresFn func(ctx context.Context, serviceID string) ([]models.ServiceExposure, error)
+	GetServiceVersionsFn  func(ctx context.Context, product string) ([]models.ServiceVersion, error)
+}
+
+func (m *MockServiceRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.Service], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[models.Service]{Results: []models.Service{}}, nil
+}
+
+func (m *MockServiceRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockServiceRepository) GetService(ctx context.Context, serviceID string) (*models.ServiceDetail, error) {
+	if m.GetServiceFn != nil {
+		return m.GetServiceFn(ctx, serviceID)
+	}
+	return nil, shiryoku_errors.NotFoundError{Resource: "service", ID: serviceID}
+}
+
+func (m *MockServiceRepository) GetServiceExposures(ctx context.Context, serviceID string) ([]models.ServiceExposure, error) {
+	if m.GetServiceExposuresFn != nil {
+		return m.GetServiceExposuresFn(ctx, serviceID)
+	}
+	return []models.ServiceExposure{}, nil
+}
+
+func (m *MockServiceRepository) GetServiceVersions(ctx context.Context, product string) ([]models.ServiceVersion, error) {
+	if m.GetServiceVersionsFn != nil {
+		return m.GetServiceVersionsFn(ctx, product)
+	}
+	return []models.ServiceVersion{}, nil
+}
+
+func (m *MockServiceRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
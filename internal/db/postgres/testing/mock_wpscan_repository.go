@@ -0,0 +1,89 @@
+package testing
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+type MockWPScanRepository struct {
+	SearchFn                   func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanScan], error)
+	AggregateFn                func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	SearchComponentsFn         func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanComponent], error)
+	SearchFindingsFn           func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanFinding], error)
+	SearchVulnerabilitiesFn    func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanVulnerability], error)
+	AggregateComponentsFn      func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	AggregateFindingsFn        func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	AggregateVulnerabilitiesFn func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	InsertScanFn               func(ctx context.Context, scan *models.WPScanScan) error
+}
+
+func (m *MockWPScanRepository) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanScan], error) {
+	if m.SearchFn != nil {
+		return m.SearchFn(ctx, params)
+	}
+	return &models.SearchResult[models.WPScanScan]{Results: []models.WPScanScan{}}, nil
+}
+
+func (m *MockWPScanRepository) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFn != nil {
+		return m.AggregateFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockWPScanRepository) SearchComponents(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanComponent], error) {
+	if m.SearchComponentsFn != nil {
+		return m.SearchComponentsFn(ctx, params)
+	}
+	return &models.SearchResult[models.WPScanComponent]{Results: []models.WPScanComponent{}}, nil
+}
+
+func (m *MockWPScanRepository) SearchFindings(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanFinding], error) {
+	if m.SearchFindingsFn != nil {
+		return m.SearchFindingsFn(ctx, params)
+	}
+	return &models.SearchResult[models.WPScanFinding]{Results: []models.WPScanFinding{}}, nil
+}
+
+func (m *MockWPScanRepository) SearchVulnerabilities(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanVulnerability], error) {
+	if m.SearchVulnerabilitiesFn != nil {
+		return m.SearchVulnerabilitiesFn(ctx, params)
+	}
+	return &models.SearchResult[models.WPScanVulnerability]{Results: []models.WPScanVulnerability{}}, nil
+}
+
+func (m *MockWPScanRepository) AggregateComponents(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateComponentsFn != nil {
+		return m.AggregateComponentsFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockWPScanRepository) AggregateFindings(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateFindingsFn != nil {
+		return m.AggregateFindingsFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockWPScanRepository) AggregateVulnerabilities(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	if m.AggregateVulnerabilitiesFn != nil {
+		return m.AggregateVulnerabilitiesFn(ctx, params)
+	}
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func (m *MockWPScanRepository) InsertScan(ctx context.Context, scan *models.WPScanScan) error {
+	if m.InsertScanFn != nil {
+		return m.InsertScanFn(ctx, scan)
+	}
+	return nil
+}
+
+func (m *MockWPScanRepository) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// WPScanRepositoryImpl implements WPScanRepository interface for wpscan data persistence
+type WPScanRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWPScanRepository(db *gorm.DB) repositories.WPScanRepository {
+	return &WPScanRepositoryImpl{db: db}
+}
+
+// Check the db status
+func (w *WPScanRepositoryImpl) ReadyCheck() utils.Checker {
+	return func(ctx context.Context) (bool, error) {
+		// FIXME: Implement
+		return true, nil
+	}
+}
+
+func (w *WPScanRepositoryImpl) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanScan], error) {
+	return postgres.Search[models.WPScanScan](ctx, w.db, params,
+		postgres.Preload[models.WPScanScan]{Association: "Components", Fn: nil},
+		postgres.Preload[models.WPScanScan]{Association: "Findings", Fn: nil},
+		postgres.Preload[models.WPScanScan]{Association: "Vulnerabilities", Fn: nil},
+	)
+}
+
+func (w *WPScanRepositoryImpl) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.WPScanScan](ctx, w.db, params)
+}
+
+func (w *WPScanRepositoryImpl) SearchComponents(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanComponent], error) {
+	return postgres.Search[models.WPScanComponent](ctx, w.db, params)
+}
+
+func (w *WPScanRepositoryImpl) SearchFindings(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanFinding], error) {
+	return postgres.Search[models.WPScanFinding](ctx, w.db, params)
+}
+
+func (w *WPScanRepositoryImpl) SearchVulnerabilities(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanVulnerability], error) {
+	return postgres.Search[models.WPScanVulnerability](ctx, w.db, params)
+}
+
+func (w *WPScanRepositoryImpl) AggregateComponents(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.WPScanComponent](ctx, w.db, params)
+}
+
+func (w *WPScanRepositoryImpl) AggregateFindings(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.WPScanFinding](ctx, w.db, params)
+}
+
+func (w *WPScanRepositoryImpl) AggregateVulnerabilities(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return postgres.Aggregate[models.WPScanVulnerability](ctx, w.db, params)
+}
+
+// InsertScan inserts a scan, its components, findings and vulnerabilities at once
+func (w *WPScanRepositoryImpl) InsertScan(ctx context.Context, scan *models.WPScanScan) error {
+	return w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Associations are created along with the scan
+		if err := tx.Create(scan).Error; err != nil {
+			return fmt.Errorf("failed to insert wpscan scan: %w", err)
+		}
+		return nil
+	})
+}
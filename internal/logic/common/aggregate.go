@@ -0,0 +1,18 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// Aggregate is a generic wrapper that works with any AggregatableRepository[T]
+func Aggregate[T any](ctx context.Context, repo repositories.AggregatableRepository[T], params *models.AggregationParams) (*models.AggregationResult, error) {
+	result, err := repo.Aggregate(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("aggregation failed: %w", err)
+	}
+	return result, nil
+}
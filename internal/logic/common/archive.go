@@ -0,0 +1,164 @@
+package common
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Maximum decompressed size of an uploaded file, archives included
+const MaxExtractedSize = 4 << 30
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+	tarMagic  = []byte("ustar")
+)
+
+// The tar magic comes after the header's name, mode, ids, size, ...
+const tarMagicOffset = 257
+
+// Size of a tar header, enough to detect every format
+const peekSize = 512
+
+// WalkFiles calls fn on every file of an upload, decompressing it while it is read
+// Gzipped files are decompressed, and tar (.tar, .tar.gz) and zip archives expanded into their regular files
+// Other files are passed as they are. Zip archives need random access: unless r allows it (e.g. *os.File), they're spooled to disk
+// An error of fn stops the walk and is returned as is
+func WalkFiles(name string, r io.Reader, fn func(name string, r io.Reader) error) error {
+	budget := int64(MaxExtractedSize)
+	return walkFiles(name, r, &budget, fn)
+}
+
+func walkFiles(name string, r io.Reader, budget *int64, fn func(name string, r io.Reader) error) error {
+	buffered := bufio.NewReaderSize(r, peekSize)
+	// Shorter files just can't be archives
+	head, _ := buffered.Peek(tarMagicOffset + len(tarMagic))
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("%s: invalid gzip: %w", name, err)
+		}
+		defer reader.Close()
+
+		decompressed := bufio.NewReaderSize(&budgetReader{r: reader, budget: budget}, peekSize)
+		// A .tar.gz is a gzipped tar
+		if head, _ := decompressed.Peek(tarMagicOffset + len(tarMagic)); isTar(head) {
+			return walkTar(name, decompressed, budget, fn)
+		}
+		return walkFiles(strings.TrimSuffix(name, ".gz"), decompressed, budget, fn)
+
+	case bytes.HasPrefix(head, zipMagic):
+		return walkZip(name, r, buffered, budget, fn)
+
+	case isTar(head):
+		return walkTar(name, buffered, budget, fn)
+
+	default:
+		return fn(name, buffered)
+	}
+}
+
+func isTar(head []byte) bool {
+	return len(head) >= tarMagicOffset+len(tarMagic) && bytes.Equal(head[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic)
+}
+
+func walkTar(name string, r io.Reader, budget *int64, fn func(name string, r io.Reader) error) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: invalid tar: %w", name, err)
+		}
+		if header.Typeflag != tar.TypeReg || skipArchiveEntry(header.Name) {
+			continue
+		}
+
+		// Entries can be gzipped too
+		if err := walkFiles(name+"/"+header.Name, reader, budget, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// Stored files can be read at random, other zips (e.g. within a tar) are copied to a temporary file first
+func walkZip(name string, src io.Reader, buffered io.Reader, budget *int64, fn func(name string, r io.Reader) error) error {
+	file, ok := src.(interface {
+		io.ReaderAt
+		io.Seeker
+	})
+	if !ok {
+		spooled, err := os.CreateTemp("", "shiryoku-upload-*.zip")
+		if err != nil {
+			return fmt.Errorf("%s: failed to spool zip: %w", name, err)
+		}
+		defer os.Remove(spooled.Name())
+		defer spooled.Close()
+
+		if _, err := io.Copy(spooled, buffered); err != nil {
+			return fmt.Errorf("%s: failed to spool zip: %w", name, err)
+		}
+		file = spooled
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("%s: failed to read zip: %w", name, err)
+	}
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return fmt.Errorf("%s: invalid zip: %w", name, err)
+	}
+
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() || skipArchiveEntry(entry.Name) {
+			continue
+		}
+
+		content, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", name, entry.Name, err)
+		}
+		// Entries can be gzipped too
+		err = walkFiles(name+"/"+entry.Name, &budgetReader{r: content, budget: budget}, budget, fn)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Metadata added by archivers (e.g. macOS resource forks)
+func skipArchiveEntry(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._")
+}
+
+// budgetReader fails once the upload's decompressed size exceeds the budget
+type budgetReader struct {
+	r      io.Reader
+	budget *int64
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	*b.budget -= int64(n)
+	if *b.budget < 0 {
+		return n, fmt.Errorf("decompressed upload exceeds %d bytes", int64(MaxExtractedSize))
+	}
+	return n, err
+}
@@ -0,0 +1,352 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// Export writes every result matching the search, page after page, so that they are never all in memory
+// Pages follow the cursors of the search, or page numbers for unique combinations (distinct)
+// CSV and XLSX rows are flattened: nested fields and relations are dotted columns (e.g. hosts.host)
+// Nothing is written until the first page was read, so that failed searches can still be answered
+func Export[T any](ctx context.Context, repo repositories.SearchableRepository[T], params *models.SearchParams, format models.ExportFormat, w io.Writer) error {
+	params.SetDefaults()
+
+	page := *params
+	page.Page = 1
+	page.PerPage = models.MAX_RESULTS_PER_PAGE
+	page.Cursor = ""
+	page.Count = models.CountNone
+
+	writer, err := newExportWriter[T](format, w, params.Parameters)
+	if err != nil {
+		return err
+	}
+
+	for started := false; ; {
+		result, err := repo.Search(ctx, &page)
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+
+		if !started {
+			if err := writer.begin(); err != nil {
+				return err
+			}
+			started = true
+		}
+		for _, item := range result.Results {
+			if err := writer.write(item); err != nil {
+				return err
+			}
+		}
+		if err := writer.flush(); err != nil {
+			return err
+		}
+
+		if uint64(len(result.Results)) < page.PerPage {
+			break
+		}
+		if result.NextCursor != "" {
+			page.Cursor = result.NextCursor
+		} else {
+			page.Page++
+		}
+	}
+
+	return writer.end()
+}
+
+type exportWriter interface {
+	begin() error
+	write(item any) error
+	// flush sends what was written, after each page
+	flush() error
+	end() error
+}
+
+func newExportWriter[T any](format models.ExportFormat, w io.Writer, parameters []string) (exportWriter, error) {
+	// Selected columns, or every field
+	columns := parameters
+	if len(columns) == 0 {
+		columns = flatColumns(reflect.TypeOf(*new(T)), "", nil)
+	}
+
+	switch format {
+	case models.ExportNDJSON:
+		return &ndjsonWriter{w: w, parameters: parameters}, nil
+	case models.ExportCSV:
+		return &tableWriter{w: w, columns: columns, sheet: &csvSheet{writer: csv.NewWriter(w)}}, nil
+	case models.ExportXLSX:
+		return &tableWriter{w: w, columns: columns, sheet: &xlsxSheet{w: w}}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// flush sends the response as it is written, if possible (e.g. HTTP responses)
+func flush(w io.Writer) {
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+}
+
+// ndjsonWriter writes results as they are searched, with only the selected fields if any
+type ndjsonWriter struct {
+	w          io.Writer
+	parameters []string
+	encoder    *json.Encoder
+}
+
+func (n *ndjsonWriter) begin() error {
+	n.encoder = json.NewEncoder(n.w)
+	return nil
+}
+
+func (n *ndjsonWriter) write(item any) error {
+	if len(n.parameters) > 0 {
+		row, err := projectItem(item, n.parameters)
+		if err != nil {
+			return err
+		}
+		return n.encoder.Encode(row)
+	}
+	return n.encoder.Encode(item)
+}
+
+func (n *ndjsonWriter) flush() error {
+	flush(n.w)
+	return nil
+}
+
+func (n *ndjsonWriter) end() error {
+	return nil
+}
+
+// sheet is a table of cells: nil, strings, numbers (json.Number) or booleans
+type sheet interface {
+	row(values []any) error
+	flush() error
+	close() error
+}
+
+// tableWriter writes a header, then a row of flattened fields per result
+type tableWriter struct {
+	w       io.Writer
+	columns []string
+	sheet   sheet
+}
+
+func (t *tableWriter) begin() error {
+	header := make([]any, 0, len(t.columns))
+	for _, column := range t.columns {
+		header = append(header, column)
+	}
+	return t.sheet.row(header)
+}
+
+func (t *tableWriter) write(item any) error {
+	values, err := flatValues(item, t.columns)
+	if err != nil {
+		return err
+	}
+	return t.sheet.row(values)
+}
+
+func (t *tableWriter) flush() error {
+	if err := t.sheet.flush(); err != nil {
+		return err
+	}
+	flush(t.w)
+	return nil
+}
+
+func (t *tableWriter) end() error {
+	return t.sheet.close()
+}
+
+type csvSheet struct {
+	writer *csv.Writer
+}
+
+func (c *csvSheet) row(values []any) error {
+	record := make([]string, 0, len(values))
+	for _, value := range values {
+		cell := cellString(value)
+		// Scanned data (titles, banners...) isn't trusted: don't let spreadsheets run it as formulas
+		if _, text := value.(string); text && cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cell = "'" + cell
+		}
+		record = append(record, cell)
+	}
+	return c.writer.Write(record)
+}
+
+func (c *csvSheet) flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvSheet) close() error {
+	return c.flush()
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()
+)
+
+// flatColumns returns the JSON names of the fields of a struct, nested structs and relations being dotted (e.g. hosts.host)
+// seen holds the structs being flattened, as relations may lead back to them
+func flatColumns(t reflect.Type, prefix string, seen []reflect.Type) []string {
+	var columns []string
+	seen = append(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		nested := structType(field.Type)
+		// Embedded structs are inlined, like in JSON
+		if field.Anonymous && name == "" && nested != nil {
+			columns = append(columns, flatColumns(nested, prefix, seen)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if nested != nil {
+			for _, parent := range seen {
+				if parent == nested {
+					nested = nil
+					break
+				}
+			}
+		}
+		if nested != nil {
+			columns = append(columns, flatColumns(nested, prefix+name+".", seen)...)
+		} else {
+			columns = append(columns, prefix+name)
+		}
+	}
+	return columns
+}
+
+// structType returns the struct of nested fields and relations (e.g. []NmapHost)
+// nil for values, even structs marshalled as values (e.g. time.Time)
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for _, marshaler := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+			return nil
+		}
+	}
+	return t
+}
+
+// flatValues returns the cells of the columns of a result, from its JSON
+// Fields of relations are joined, e.g. "10.0.0.1, 10.0.0.2" for hosts.host
+func flatValues(item any, columns []string) ([]any, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export result: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to export result: %w", err)
+	}
+
+	values := make([]any, 0, len(columns))
+	for _, column := range columns {
+		leaves := lookupPath(document, strings.Split(column, "."))
+		switch len(leaves) {
+		case 0:
+			values = append(values, nil)
+		case 1:
+			values = append(values, leaves[0])
+		default:
+			cells := make([]string, 0, len(leaves))
+			for _, leaf := range leaves {
+				if cell := cellString(leaf); cell != "" {
+					cells = append(cells, cell)
+				}
+			}
+			values = append(values, strings.Join(cells, ", "))
+		}
+	}
+	return values, nil
+}
+
+// lookupPath returns the values at a dotted path of a JSON document, through the elements of arrays
+func lookupPath(document any, path []string) []any {
+	if len(path) == 0 {
+		if document == nil {
+			return nil
+		}
+		return []any{document}
+	}
+
+	switch node := document.(type) {
+	case map[string]any:
+		return lookupPath(node[path[0]], path[1:])
+	case []any:
+		var leaves []any
+		for _, element := range node {
+			leaves = append(leaves, lookupPath(element, path)...)
+		}
+		return leaves
+	default:
+		return nil
+	}
+}
+
+// cellString returns the text of a cell, arrays of values being joined and objects kept as JSON
+func cellString(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		cells := make([]string, 0, len(value))
+		for _, element := range value {
+			if _, object := element.(map[string]any); object {
+				data, _ := json.Marshal(value)
+				return string(data)
+			}
+			cells = append(cells, cellString(element))
+		}
+		return strings.Join(cells, ", ")
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
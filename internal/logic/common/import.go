@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// ImportOptions describe an imported scan output
+type ImportOptions struct {
+	// Scope of the imported hosts, empty for none
+	Project string
+	// Hash of the imported file (cf ContentHash) to detect re-uploads, empty to always import
+	ContentHash string
+}
+
+// ContentHash returns the SHA-256 of an imported file
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// FindImportedScan returns the scan already imported from the same file in the same project
+// Returns nil when there is none, or when re-uploads aren't detected (no content hash)
+func FindImportedScan(ctx context.Context, nmapRepo repositories.NmapRepository, opts ImportOptions) (*models.NmapScan, error) {
+	if opts.ContentHash == "" {
+		return nil, nil
+	}
+
+	scan, err := nmapRepo.FindScanByHash(ctx, opts.Project, opts.ContentHash)
+	switch {
+	case errors.As(err, &shiryoku_errors.NotFoundError{}):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to look for a previous upload: %w", err)
+	}
+	return scan, nil
+}
+
+// ImportOnce runs importFn in a transaction, unless the file was already imported in the project: the scan imported then is returned
+// A concurrent upload of the same file can be imported meanwhile, importFn failing on the unique index: its scan is returned too
+// importFn returns the ID of the imported scan
+func ImportOnce(ctx context.Context, nmapRepo repositories.NmapRepository, opts ImportOptions, importFn func(tx repositories.NmapRepository) (string, error)) (string, error) {
+	var scanID string
+	err := nmapRepo.WithTransaction(ctx, func(tx repositories.NmapRepository) error {
+		existing, err := FindImportedScan(ctx, tx, opts)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			scanID = existing.ScanID.String()
+			return nil
+		}
+
+		scanID, err = importFn(tx)
+		return err
+	})
+	if errors.As(err, &shiryoku_errors.AlreadyExistsError{}) {
+		// The transaction of the other upload is committed by now
+		existing, err := FindImportedScan(ctx, nmapRepo, opts)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			return existing.ScanID.String(), nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return scanID, nil
+}
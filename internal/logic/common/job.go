@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// ProgressFunc is told how many hosts and ports were written, e.g. after each batch
+type ProgressFunc func(hosts, ports int)
+
+// ImportFileFunc imports a single file of a job, and returns the ID of the imported scan
+// progress is called as rows are written: they are discounted if the import fails
+type ImportFileFunc func(r io.Reader, opts ImportOptions, progress ProgressFunc) (string, error)
+
+// RunImportJob imports the upload of a claimed job file by file with importFile, then saves the outcome of the job
+// The job is done once a file was imported, failed otherwise. Its stored upload is deleted either way
+func RunImportJob(ctx context.Context, job *models.IngestionJob, jobRepo repositories.JobRepository, importFile ImportFileFunc) error {
+	jobID := job.JobID.String()
+
+	saveProgress := func() {
+		if err := jobRepo.UpdateJobProgress(ctx, jobID, job.HostsProcessed, job.PortsProcessed); err != nil {
+			log.Printf("[job %s] %v", jobID, err)
+		}
+	}
+	// Progress is shared by all files of the upload
+	progress := func(hosts, ports int) {
+		job.HostsProcessed += int64(hosts)
+		job.PortsProcessed += int64(ports)
+		saveProgress()
+	}
+
+	imported := 0
+	results, err := ForEachStoredFile(job.StoragePath, func(name string, r io.Reader) models.JobFileResult {
+		hosts, ports := job.HostsProcessed, job.PortsProcessed
+
+		// Hashed first, so that re-uploads aren't imported again
+		var id string
+		err := HashFile(r, func(hash string, r io.Reader) error {
+			var err error
+			id, err = importFile(r, ImportOptions{Project: job.Project, ContentHash: hash}, progress)
+			return err
+		})
+		if err != nil {
+			// The import of the file was rolled back, its batches with it
+			if job.HostsProcessed != hosts || job.PortsProcessed != ports {
+				job.HostsProcessed, job.PortsProcessed = hosts, ports
+				saveProgress()
+			}
+			return models.JobFileResult{File: name, Error: err.Error()}
+		}
+		imported++
+		return models.JobFileResult{File: name, ScanID: id}
+	})
+
+	job.Files = results
+	job.Status = string(models.JOB_STATUS_DONE)
+	switch {
+	case err != nil:
+		job.Status = string(models.JOB_STATUS_FAILED)
+		job.Error = err.Error()
+	case imported == 0:
+		job.Status = string(models.JOB_STATUS_FAILED)
+		job.Error = "no scan could be imported"
+	}
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+
+	if err := os.RemoveAll(job.StoragePath); err != nil {
+		log.Printf("[job %s] failed to delete upload: %v", jobID, err)
+	}
+
+	return jobRepo.FinishJob(ctx, job)
+}
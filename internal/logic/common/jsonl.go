@@ -0,0 +1,40 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeJSONL reads one JSON document per line, as output by projectdiscovery tools
+// Empty lines are ignored, and errors report the faulty line
+func DecodeJSONL[T any](r io.Reader) ([]T, error) {
+	var results []T
+
+	scanner := bufio.NewScanner(r)
+	// Responses may be embedded in the output: allow long lines
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var result T
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		results = append(results, result)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", line+1, err)
+	}
+
+	return results, nil
+}
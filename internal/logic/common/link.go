@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// HostLinker links data from other tools to the nmap hosts and services seen on the same IP/port, in the same project
+// Lookups are cached, as an import usually targets the same hosts many times
+type HostLinker struct {
+	nmapRepo repositories.NmapRepository
+	// Scope of the linked hosts, empty for none (cf models.NewHostID)
+	project  string
+	hosts    map[string]*uuid.UUID
+	services map[string]*uuid.UUID
+}
+
+func NewHostLinker(nmapRepo repositories.NmapRepository, project string) *HostLinker {
+	return &HostLinker{
+		nmapRepo: nmapRepo,
+		project:  project,
+		hosts:    make(map[string]*uuid.UUID),
+		services: make(map[string]*uuid.UUID),
+	}
+}
+
+// Link returns the host owning the IP and the service last seen on its port
+// Both are nil when unknown (never scanned, or port 0)
+func (l *HostLinker) Link(ctx context.Context, ip string, port uint16) (*uuid.UUID, *uuid.UUID, error) {
+	if ip == "" {
+		return nil, nil, nil
+	}
+
+	hostID, err := l.findHostID(ctx, ip)
+	if err != nil || hostID == nil || port == 0 {
+		return hostID, nil, err
+	}
+
+	serviceID, err := l.findServiceID(ctx, *hostID, port)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hostID, serviceID, nil
+}
+
+// Returns the ID of the host owning the address, nil if never scanned
+func (l *HostLinker) findHostID(ctx context.Context, address string) (*uuid.UUID, error) {
+	if hostID, ok := l.hosts[address]; ok {
+		return hostID, nil
+	}
+
+	var hostID *uuid.UUID
+	host, err := l.nmapRepo.FindHostByAddress(ctx, l.project, address)
+	switch {
+	case errors.As(err, &shiryoku_errors.NotFoundError{}):
+	case err != nil:
+		return nil, fmt.Errorf("failed to find host %s: %w", address, err)
+	default:
+		hostID = &host.HostID
+	}
+
+	l.hosts[address] = hostID
+	return hostID, nil
+}
+
+// Returns the ID of the service last seen on the host port, nil if unknown
+func (l *HostLinker) findServiceID(ctx context.Context, hostID uuid.UUID, port uint16) (*uuid.UUID, error) {
+	key := fmt.Sprintf("%s:%d", hostID, port)
+	if serviceID, ok := l.services[key]; ok {
+		return serviceID, nil
+	}
+
+	var serviceID *uuid.UUID
+	result, err := l.nmapRepo.FindLatestScanResult(ctx, hostID.String(), port)
+	switch {
+	case errors.As(err, &shiryoku_errors.NotFoundError{}):
+	case err != nil:
+		return nil, fmt.Errorf("failed to find service on %s: %w", key, err)
+	case result.ServiceID != uuid.Nil:
+		serviceID = &result.ServiceID
+	}
+
+	l.services[key] = serviceID
+	return serviceID, nil
+}
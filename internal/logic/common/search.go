@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
@@ -10,12 +11,52 @@ import (
 
 // Search is a generic wrapper that works with any SearchableRepository[T]
 func Search[T any](ctx context.Context, repo repositories.SearchableRepository[T], params *models.SearchParams) (*models.SearchResult[T], error) {
-	total, results, err := repo.Search(ctx, params)
+	result, err := repo.Search(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
-	return &models.SearchResult[T]{
-		Total:   total,
-		Results: results,
-	}, nil
+	return result, nil
+}
+
+// Project keeps the fields of each result selected by SearchParams.Parameters
+// Fields the JSON of a result leaves out (omitempty) are null
+func Project[T any](result *models.SearchResult[T], parameters []string) (*models.SearchResult[map[string]any], error) {
+	projected := &models.SearchResult[map[string]any]{
+		Total:      result.Total,
+		Count:      result.Count,
+		Results:    make([]map[string]any, 0, len(result.Results)),
+		NextCursor: result.NextCursor,
+	}
+
+	for _, item := range result.Results {
+		row, err := projectItem(item, parameters)
+		if err != nil {
+			return nil, err
+		}
+		projected.Results = append(projected.Results, row)
+	}
+
+	return projected, nil
+}
+
+// projectItem keeps the fields of a result listed in parameters
+func projectItem(item any, parameters []string) (map[string]any, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to project result: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to project result: %w", err)
+	}
+
+	row := make(map[string]any, len(parameters))
+	for _, parameter := range parameters {
+		if value, ok := fields[parameter]; ok {
+			row[parameter] = value
+		} else {
+			row[parameter] = nil
+		}
+	}
+	return row, nil
 }
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+)
+
+// ForEachStoredFile calls fn on every file of an upload stored in dir, one numbered directory per uploaded file
+// Compressed files and archives are expanded (cf WalkFiles): files that can't be are reported as errors
+// Returns the result of every file, and an error when the stored upload itself can't be read
+func ForEachStoredFile(dir string, fn func(name string, r io.Reader) models.JobFileResult) ([]models.JobFileResult, error) {
+	// Sorted by name, so in upload order
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	var results []models.JobFileResult
+	for _, entry := range entries {
+		files, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+
+		for _, file := range files {
+			path := filepath.Join(dir, entry.Name(), file.Name())
+			if err := walkStoredFile(path, func(name string, r io.Reader) error {
+				results = append(results, fn(name, r))
+				return nil
+			}); err != nil {
+				results = append(results, models.JobFileResult{File: file.Name(), Error: err.Error()})
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func walkStoredFile(path string, fn func(name string, r io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	// Files can be read at random: zip archives aren't copied again
+	return WalkFiles(filepath.Base(path), file, fn)
+}
+
+// HashFile hashes a file of an upload (cf ContentHash) before fn reads it, e.g. to skip re-uploads
+// Files of archives can only be read once: the file is spooled to a temporary file while hashed, and fn reads the copy
+func HashFile(r io.Reader, fn func(hash string, r io.Reader) error) error {
+	spooled, err := os.CreateTemp("", "shiryoku-upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to spool file: %w", err)
+	}
+	defer os.Remove(spooled.Name())
+	defer spooled.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(spooled, hash), r); err != nil {
+		return fmt.Errorf("failed to spool file: %w", err)
+	}
+	if _, err := spooled.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to spool file: %w", err)
+	}
+
+	return fn(hex.EncodeToString(hash.Sum(nil)), spooled)
+}
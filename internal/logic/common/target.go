@@ -0,0 +1,64 @@
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PortNumber accepts both "443" (as output by projectdiscovery tools) and 443
+type PortNumber uint16
+
+func (p *PortNumber) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		*p = 0
+		return nil
+	}
+
+	port, err := strconv.ParseUint(raw, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q: %w", raw, err)
+	}
+	*p = PortNumber(port)
+	return nil
+}
+
+// TargetHost extracts the host part of "host", "host:port" or "scheme://host:port/path"
+func TargetHost(target string) string {
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		return host
+	}
+	return target
+}
+
+// TargetPort extracts the port of "host:port" or "scheme://host[:port]/path"
+// Returns 0 when there is none
+func TargetPort(target string) uint16 {
+	var rawPort string
+
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		rawPort = u.Port()
+		if rawPort == "" {
+			switch u.Scheme {
+			case "http":
+				return 80
+			case "https":
+				return 443
+			}
+		}
+	} else if _, p, err := net.SplitHostPort(target); err == nil {
+		rawPort = p
+	}
+
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(port)
+}
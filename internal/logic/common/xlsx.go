@@ -0,0 +1,135 @@
+package common
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Maximum number of characters of a cell in Excel
+const xlsxMaxCellLength = 32767
+
+// Parts of a workbook with a single sheet, before the sheet itself
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Results" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxSheet writes a workbook as its rows come, the sheet being the last file of the zip
+// Strings are inline, so that there is no shared strings table to hold until the end
+type xlsxSheet struct {
+	w       io.Writer
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+func (x *xlsxSheet) open() error {
+	x.archive = zip.NewWriter(x.w)
+	for _, part := range xlsxParts {
+		file, err := x.archive.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to write xlsx: %w", err)
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return fmt.Errorf("failed to write xlsx: %w", err)
+		}
+	}
+
+	file, err := x.archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	x.sheet = bufio.NewWriter(file)
+	_, err = x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+func (x *xlsxSheet) row(values []any) error {
+	if x.archive == nil {
+		if err := x.open(); err != nil {
+			return err
+		}
+	}
+
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, value := range values {
+		ref := xlsxColumn(i) + strconv.Itoa(x.rows)
+		switch value := value.(type) {
+		case nil:
+			continue
+		case json.Number:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+		case bool:
+			cell := "0"
+			if value {
+				cell = "1"
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="b"><v>%s</v></c>`, ref, cell)
+		default:
+			cell := []rune(cellString(value))
+			if len(cell) > xlsxMaxCellLength {
+				cell = cell[:xlsxMaxCellLength]
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(x.sheet, []byte(string(cell))); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxSheet) flush() error {
+	if x.archive == nil {
+		return nil
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.archive.Flush()
+}
+
+func (x *xlsxSheet) close() error {
+	if x.archive == nil {
+		if err := x.open(); err != nil {
+			return err
+		}
+	}
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// xlsxColumn returns the letters of a column, e.g. A, Z, AA
+func xlsxColumn(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,71 @@
+package ffuf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+)
+
+// Output is an `ffuf -of json` output file
+// Cf https://github.com/ffuf/ffuf/blob/master/pkg/output/file_json.go
+type Output struct {
+	CommandLine string    `json:"commandline"`
+	Time        time.Time `json:"time"`
+	Results     []Result  `json:"results"`
+}
+
+// Result is a single ffuf hit
+type Result struct {
+	Input            map[string]string `json:"input"`
+	Status           int               `json:"status"`
+	Length           int               `json:"length"`
+	Words            int               `json:"words"`
+	Lines            int               `json:"lines"`
+	ContentType      string            `json:"content-type"`
+	RedirectLocation string            `json:"redirectlocation"`
+	// In nanoseconds
+	Duration time.Duration `json:"duration"`
+	URL      string        `json:"url"`
+	Host     string        `json:"host"`
+}
+
+// Keyword added by ffuf to identify requests, not a user input
+const ffufHashKeyword = "FFUFHASH"
+
+// ParseJSON reads an ffuf JSON output
+func ParseJSON(r io.Reader) (*Output, error) {
+	var output Output
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, fmt.Errorf("invalid JSON format: %w", err)
+	}
+	return &output, nil
+}
+
+// Convert an ffuf hit into a model, without any link to nmap data
+func convertResultToModel(r *Result, timestamp time.Time) models.FfufResult {
+	input := make(map[string]string, len(r.Input))
+	for keyword, value := range r.Input {
+		if keyword != ffufHashKeyword {
+			input[keyword] = value
+		}
+	}
+
+	return models.FfufResult{
+		URL:              r.URL,
+		Host:             common.TargetHost(r.URL),
+		Port:             common.TargetPort(r.URL),
+		Input:            input,
+		Status:           r.Status,
+		Length:           r.Length,
+		Words:            r.Words,
+		Lines:            r.Lines,
+		ContentType:      r.ContentType,
+		RedirectLocation: r.RedirectLocation,
+		Duration:         r.Duration.Milliseconds(),
+		Timestamp:        timestamp,
+	}
+}
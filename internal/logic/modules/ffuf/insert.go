@@ -0,0 +1,43 @@
+package ffuf
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// SaveFfufResults saves ffuf hits, linking them to the nmap host and service they targeted
+// Hosts are looked up within the project (empty for none)
+// Returns the number of inserted hits
+func SaveFfufResults(
+	ctx context.Context,
+	output *Output,
+	project string,
+	ffufRepo repositories.FfufRepository,
+	nmapRepo repositories.NmapRepository,
+) (int, error) {
+	items := make([]models.FfufResult, 0, len(output.Results))
+	linker := common.NewHostLinker(nmapRepo, project)
+
+	for i := range output.Results {
+		item := convertResultToModel(&output.Results[i], output.Time)
+
+		hostID, serviceID, err := linker.Link(ctx, item.Host, item.Port)
+		if err != nil {
+			return 0, err
+		}
+		item.HostID = hostID
+		item.ServiceID = serviceID
+
+		items = append(items, item)
+	}
+
+	if err := ffufRepo.InsertResults(ctx, items); err != nil {
+		return 0, fmt.Errorf("failed to insert results: %w", err)
+	}
+
+	return len(items), nil
+}
@@ -0,0 +1,182 @@
+package hosts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// GetHostDetail returns a host with its ports open as of its latest scans
+func GetHostDetail(ctx context.Context, repo repositories.HostRepository, hostID string) (*models.HostDetail, error) {
+	host, err := repo.GetHost(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, open, err := replayHost(ctx, repo, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.HostDetail{NmapHost: *host, OpenPorts: make([]models.HostScanResult, 0, len(open))}
+	for _, key := range sortedPorts(open) {
+		detail.OpenPorts = append(detail.OpenPorts, open[key])
+	}
+	return detail, nil
+}
+
+// GetHostTimeline returns, scan by scan, the ports of a host that opened, closed or changed of service
+func GetHostTimeline(ctx context.Context, repo repositories.HostRepository, hostID string) (*models.HostTimeline, error) {
+	host, err := repo.GetHost(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	scans, _, err := replayHost(ctx, repo, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.HostTimeline{HostID: host.HostID, Scans: scans}, nil
+}
+
+// portKey identifies a port of a host across scans
+type portKey struct {
+	port     uint16
+	protocol string
+}
+
+// e.g. "22/tcp", or "22" if the protocol is unknown
+func (k portKey) String() string {
+	if k.protocol == "" {
+		return fmt.Sprintf("%d", k.port)
+	}
+	return fmt.Sprintf("%d/%s", k.port, k.protocol)
+}
+
+func resultPort(result *models.HostScanResult) portKey {
+	key := portKey{port: result.Port}
+	if result.Service != nil {
+		key.protocol = result.Service.Protocol
+	}
+	return key
+}
+
+// replayHost replays the scan results of a host, the oldest scans first
+// It returns the changes of each scan, and the latest result of the ports open after the last one
+//
+// A port missing from a scan that reported other ports of the host is closed: nmap doesn't list closed ports
+// beyond a threshold. Scans without ports (OS guesses or host scripts only) don't close any.
+func replayHost(ctx context.Context, repo repositories.HostRepository, hostID string) ([]models.HostTimelineScan, map[portKey]models.HostScanResult, error) {
+	scans, err := repo.GetHostScans(ctx, hostID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get host scans: %w", err)
+	}
+
+	history, err := repo.GetHostHistory(ctx, hostID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get host history: %w", err)
+	}
+
+	resultsByScan := make(map[uuid.UUID]map[portKey]models.HostScanResult)
+	for _, result := range history {
+		if resultsByScan[result.ScanID] == nil {
+			resultsByScan[result.ScanID] = make(map[portKey]models.HostScanResult)
+		}
+		resultsByScan[result.ScanID][resultPort(&result)] = result
+	}
+
+	open := make(map[portKey]models.HostScanResult)
+	timeline := make([]models.HostTimelineScan, 0, len(scans))
+	for _, scan := range scans {
+		reported := resultsByScan[scan.ScanID]
+		changes := []models.PortChange{}
+
+		for _, key := range sortedPorts(reported) {
+			result := reported[key]
+			previous, wasOpen := open[key]
+
+			if result.PortState != "open" {
+				if wasOpen {
+					delete(open, key)
+					changes = append(changes, models.PortChange{
+						Port:     key.port,
+						Protocol: key.protocol,
+						Event:    models.PORT_EVENT_CLOSED,
+						State:    result.PortState,
+						Service:  result.Service,
+					})
+				}
+				continue
+			}
+
+			open[key] = result
+			switch {
+			case !wasOpen:
+				changes = append(changes, models.PortChange{
+					Port:     key.port,
+					Protocol: key.protocol,
+					Event:    models.PORT_EVENT_OPENED,
+					State:    result.PortState,
+					Service:  result.Service,
+				})
+			case previous.ServiceID != result.ServiceID:
+				changes = append(changes, models.PortChange{
+					Port:     key.port,
+					Protocol: key.protocol,
+					Event:    models.PORT_EVENT_SERVICE_CHANGED,
+					State:    result.PortState,
+					Service:  result.Service,
+				})
+			}
+		}
+
+		if len(reported) > 0 {
+			for _, key := range sortedPorts(open) {
+				if _, exists := reported[key]; exists {
+					continue
+				}
+				delete(open, key)
+				changes = append(changes, models.PortChange{
+					Port:     key.port,
+					Protocol: key.protocol,
+					Event:    models.PORT_EVENT_CLOSED,
+				})
+			}
+		}
+
+		openPorts := make([]string, 0, len(open))
+		for _, key := range sortedPorts(open) {
+			openPorts = append(openPorts, key.String())
+		}
+
+		timeline = append(timeline, models.HostTimelineScan{
+			ScanID:    scan.ScanID,
+			ScanStart: scan.ScanStart,
+			Scanner:   scan.Scanner,
+			OpenPorts: openPorts,
+			Changes:   changes,
+		})
+	}
+
+	return timeline, open, nil
+}
+
+// sortedPorts returns the ports of results, by number then protocol
+func sortedPorts(results map[portKey]models.HostScanResult) []portKey {
+	keys := make([]portKey, 0, len(results))
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].port != keys[j].port {
+			return keys[i].port < keys[j].port
+		}
+		return keys[i].protocol < keys[j].protocol
+	})
+	return keys
+}
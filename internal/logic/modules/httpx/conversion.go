@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+)
+
+// Result is a single line of `httpx -json` output
+// Cf https://github.com/projectdiscovery/httpx/blob/main/runner/types.go
+type Result struct {
+	Timestamp     time.Time         `json:"timestamp"`
+	Port          common.PortNumber `json:"port"`
+	URL           string            `json:"url"`
+	Input         string            `json:"input"`
+	Title         string            `json:"title"`
+	Scheme        string            `json:"scheme"`
+	WebServer     string            `json:"webserver"`
+	ContentType   string            `json:"content_type"`
+	Host          string            `json:"host"`
+	Path          string            `json:"path"`
+	FaviconHash   json.Number       `json:"favicon"`
+	A             []string          `json:"a"`
+	Tech          []string          `json:"tech"`
+	Words         int               `json:"words"`
+	Lines         int               `json:"lines"`
+	StatusCode    int               `json:"status_code"`
+	ContentLength int               `json:"content_length"`
+	Failed        bool              `json:"failed"`
+	Hash          Hash              `json:"hash"`
+	TLS           *TLS              `json:"tls"`
+}
+
+// Hash contains the hashes httpx computed (with -hash)
+type Hash struct {
+	BodyMD5    string `json:"body_md5"`
+	BodyMMH3   string `json:"body_mmh3"`
+	BodySHA256 string `json:"body_sha256"`
+	HeaderMD5  string `json:"header_md5"`
+}
+
+// TLS contains the certificate grabbed (with -tls-grab)
+type TLS struct {
+	SubjectDN       string   `json:"subject_dn"`
+	SubjectCN       string   `json:"subject_cn"`
+	SubjectAN       []string `json:"subject_an"`
+	IssuerDN        string   `json:"issuer_dn"`
+	FingerprintHash struct {
+		SHA256 string `json:"sha256"`
+	} `json:"fingerprint_hash"`
+}
+
+// ParseJSONL reads httpx results, one JSON document per line
+func ParseJSONL(r io.Reader) ([]Result, error) {
+	return common.DecodeJSONL[Result](r)
+}
+
+// Convert an httpx result into a model, without any link to nmap data
+func convertResultToModel(r *Result) models.HttpxResult {
+	result := models.HttpxResult{
+		URL:           r.URL,
+		Input:         r.Input,
+		Scheme:        r.Scheme,
+		Host:          models.NewIPAddress(resolveIP(r)),
+		Port:          resolvePort(r),
+		Path:          r.Path,
+		StatusCode:    r.StatusCode,
+		Title:         r.Title,
+		WebServer:     r.WebServer,
+		ContentType:   r.ContentType,
+		ContentLength: r.ContentLength,
+		Words:         r.Words,
+		Lines:         r.Lines,
+		Tech:          strings.Join(r.Tech, ","),
+		FaviconHash:   r.FaviconHash.String(),
+		BodyMD5:       r.Hash.BodyMD5,
+		BodySHA256:    r.Hash.BodySHA256,
+		BodyMMH3:      r.Hash.BodyMMH3,
+		HeaderMD5:     r.Hash.HeaderMD5,
+		Timestamp:     r.Timestamp,
+	}
+
+	if r.TLS != nil {
+		result.TLSSubjectDN = r.TLS.SubjectDN
+		result.TLSSubjectCN = r.TLS.SubjectCN
+		result.TLSSubjectAN = r.TLS.SubjectAN
+		result.TLSIssuerDN = r.TLS.IssuerDN
+		result.TLSFingerprintSHA256 = r.TLS.FingerprintHash.SHA256
+	}
+
+	return result
+}
+
+// httpx reports the resolved IP as host, older versions only in "a"
+func resolveIP(r *Result) string {
+	if net.ParseIP(r.Host) != nil {
+		return r.Host
+	}
+	if len(r.A) > 0 {
+		return r.A[0]
+	}
+
+	host := common.TargetHost(r.URL)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	return ""
+}
+
+// The port httpx reported, or the one of the URL
+func resolvePort(r *Result) uint16 {
+	if r.Port != 0 {
+		return uint16(r.Port)
+	}
+	return common.TargetPort(r.URL)
+}
@@ -0,0 +1,47 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// SaveHttpxResults saves httpx results, attaching them to nmap hosts and services by IP/port
+// Hosts are looked up within the project (empty for none)
+// Failed probes are skipped. Returns the number of inserted results
+func SaveHttpxResults(
+	ctx context.Context,
+	results []Result,
+	project string,
+	httpxRepo repositories.HttpxRepository,
+	nmapRepo repositories.NmapRepository,
+) (int, error) {
+	items := make([]models.HttpxResult, 0, len(results))
+	linker := common.NewHostLinker(nmapRepo, project)
+
+	for i := range results {
+		if results[i].Failed {
+			continue
+		}
+
+		item := convertResultToModel(&results[i])
+
+		hostID, serviceID, err := linker.Link(ctx, string(item.Host), item.Port)
+		if err != nil {
+			return 0, err
+		}
+		item.HostID = hostID
+		item.ServiceID = serviceID
+
+		items = append(items, item)
+	}
+
+	if err := httpxRepo.InsertResults(ctx, items); err != nil {
+		return 0, fmt.Errorf("failed to insert results: %w", err)
+	}
+
+	return len(items), nil
+}
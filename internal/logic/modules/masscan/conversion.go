@@ -0,0 +1,190 @@
+package masscan
+
+import (
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/google/uuid"
+)
+
+const MASSCAN_SCANNER = "masscan"
+
+// Masscan banner types having an nmap script equivalent
+// Other banners are stored as the nmap "banner" script
+var bannerScripts = map[string]string{
+	"title":       "http-title",
+	"http.server": "http-server-header",
+	"X509":        "ssl-cert",
+	"X509CA":      "ssl-cert",
+}
+
+// Banner types that describe a tunnel rather than a service
+var bannerTunnels = map[string]bool{
+	"ssl": true,
+}
+
+// ScanDocuments are the rows produced by a masscan output
+type ScanDocuments struct {
+	Scan        models.NmapScan
+	Hosts       []models.NmapHost
+	Services    []models.Service
+	ScanResults []models.ScanResult
+	// Index in Services of the service of each scan result
+	ResultServices []int
+	Scripts        []models.NmapScriptResult
+}
+
+// A port seen on a host, before being turned into a scan result
+type portKey struct {
+	ip       string
+	protocol string
+	port     uint16
+}
+
+type portInfo struct {
+	state   string
+	name    string
+	tunnel  string
+	banners []Record
+}
+
+// ConvertScanIntoDocuments converts a masscan output into database-usable structs
+// Masscan emits one record per port or banner: they are grouped by host and port
+// Hosts are identified by their IP within the project (empty for none)
+func ConvertScanIntoDocuments(scan *Scan, project string) *ScanDocuments {
+	documents := &ScanDocuments{
+		Scan: models.NmapScan{
+			ScanID:      uuid.New(),
+			ScanStart:   scan.Start,
+			Scanner:     MASSCAN_SCANNER,
+			NmapVersion: scan.Version,
+			Project:     project,
+		},
+		Hosts:          []models.NmapHost{},
+		Services:       []models.Service{},
+		ScanResults:    []models.ScanResult{},
+		ResultServices: []int{},
+		Scripts:        []models.NmapScriptResult{},
+	}
+
+	hostIndexes := make(map[string]int)
+	ports := make(map[portKey]*portInfo)
+	// Keep masscan's order
+	var portOrder []portKey
+
+	for _, record := range scan.Records {
+		hostIndex, exists := hostIndexes[record.IP]
+		if !exists {
+			hostIndex = len(documents.Hosts)
+			hostIndexes[record.IP] = hostIndex
+			documents.Hosts = append(documents.Hosts, convertHostToModel(record.IP, project, scan.Start))
+		}
+		updateSeen(&documents.Hosts[hostIndex], record.Timestamp)
+
+		key := portKey{ip: record.IP, protocol: record.Protocol, port: record.Port}
+		info, exists := ports[key]
+		if !exists {
+			info = &portInfo{}
+			ports[key] = info
+			portOrder = append(portOrder, key)
+		}
+
+		if record.State != "" {
+			info.state = record.State
+		}
+
+		if record.Service != "" {
+			switch {
+			case bannerTunnels[record.Service]:
+				info.tunnel = record.Service
+			case bannerScripts[record.Service] == "" && info.name == "":
+				info.name = record.Service
+			}
+			info.banners = append(info.banners, record)
+		}
+	}
+
+	serviceIndexes := make(map[string]int)
+	for _, key := range portOrder {
+		info := ports[key]
+
+		service := models.Service{
+			ServiceName:   info.name,
+			Protocol:      key.protocol,
+			ServiceTunnel: info.tunnel,
+		}
+		serviceKey := service.ServiceName + "|" + service.Protocol + "|" + service.ServiceTunnel
+		serviceIndex, exists := serviceIndexes[serviceKey]
+		if !exists {
+			serviceIndex = len(documents.Services)
+			serviceIndexes[serviceKey] = serviceIndex
+			documents.Services = append(documents.Services, service)
+		}
+
+		// Banners are only grabbed on open ports
+		state := info.state
+		if state == "" {
+			state = string(models.NMAP_PORT_OPEN)
+		}
+
+		scanResult := models.ScanResult{
+			// Set now so that scripts can reference it
+			ScanResultID: uuid.New(),
+			ScanID:       documents.Scan.ScanID,
+			HostID:       documents.Hosts[hostIndexes[key.ip]].HostID,
+			Port:         key.port,
+			PortState:    state,
+		}
+		documents.ScanResults = append(documents.ScanResults, scanResult)
+		documents.ResultServices = append(documents.ResultServices, serviceIndex)
+
+		for _, banner := range info.banners {
+			documents.Scripts = append(documents.Scripts, convertBannerToScript(banner, &scanResult))
+		}
+	}
+
+	return documents
+}
+
+// Masscan only knows the IP
+func convertHostToModel(ip string, project string, scanStart time.Time) models.NmapHost {
+	return models.NmapHost{
+		HostID:     models.NewHostID(ip, project),
+		Host:       ip,
+		IP:         models.NewIPAddress(ip),
+		Project:    project,
+		Addresses:  []string{ip},
+		HostStatus: "up",
+		FirstSeen:  scanStart,
+		LastSeen:   scanStart,
+	}
+}
+
+// Widen the first/last seen dates of a host to a record's timestamp
+func updateSeen(host *models.NmapHost, timestamp time.Time) {
+	if timestamp.IsZero() {
+		return
+	}
+	if host.FirstSeen.IsZero() || timestamp.Before(host.FirstSeen) {
+		host.FirstSeen = timestamp
+	}
+	if timestamp.After(host.LastSeen) {
+		host.LastSeen = timestamp
+	}
+}
+
+// Banners are stored like their nmap script equivalent
+func convertBannerToScript(banner Record, scanResult *models.ScanResult) models.NmapScriptResult {
+	scriptID, ok := bannerScripts[banner.Service]
+	if !ok {
+		scriptID = "banner"
+	}
+
+	return models.NmapScriptResult{
+		ScanID:       scanResult.ScanID,
+		HostID:       scanResult.HostID,
+		ScanResultID: &scanResult.ScanResultID,
+		ScriptID:     scriptID,
+		ScriptOutput: banner.Banner,
+	}
+}
@@ -0,0 +1,78 @@
+package masscan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// SaveMasscanScan saves a masscan output as an nmap scan, with service deduplication
+// Hosts are merged with the ones of previous scans of the same project, and everything is saved in a single transaction
+// Re-uploading a file (same content hash and project) returns the scan imported the first time
+// progress, if any, is called once the scan is written
+// Returns the scan ID
+func SaveMasscanScan(ctx context.Context, scan *Scan, opts common.ImportOptions, progress common.ProgressFunc, nmapRepo repositories.NmapRepository) (string, error) {
+	documents := ConvertScanIntoDocuments(scan, opts.Project)
+	documents.Scan.ContentHash = opts.ContentHash
+
+	return common.ImportOnce(ctx, nmapRepo, opts, func(tx repositories.NmapRepository) (string, error) {
+		if err := insertScanDocuments(ctx, documents, tx); err != nil {
+			return "", err
+		}
+		if progress != nil {
+			progress(len(documents.Hosts), len(documents.ScanResults))
+		}
+		return documents.Scan.ScanID.String(), nil
+	})
+}
+
+// Insert every row of a scan, in dependency order
+func insertScanDocuments(ctx context.Context, documents *ScanDocuments, nmapRepo repositories.NmapRepository) error {
+	// 1. Insert or merge hosts
+	if err := nmapRepo.InsertHosts(ctx, documents.Hosts); err != nil {
+		return fmt.Errorf("failed to insert hosts: %w", err)
+	}
+
+	// 2. Get or create services (dedup by signature)
+	for i := range documents.Services {
+		createdService, err := nmapRepo.GetOrCreateService(ctx, &documents.Services[i])
+		if err != nil {
+			return fmt.Errorf("failed to get or create service: %w", err)
+		}
+		documents.Services[i] = *createdService
+	}
+
+	// 3. Link each scan result to its own service
+	for i := range documents.ScanResults {
+		documents.ScanResults[i].ServiceID = documents.Services[documents.ResultServices[i]].ServiceID
+	}
+
+	// 4. Insert scan
+	if err := nmapRepo.InsertScan(ctx, &documents.Scan); err != nil {
+		return fmt.Errorf("failed to insert scan: %w", err)
+	}
+
+	// 5. Link hosts to the scan
+	hostIDs := make([]uuid.UUID, 0, len(documents.Hosts))
+	for _, host := range documents.Hosts {
+		hostIDs = append(hostIDs, host.HostID)
+	}
+	if err := nmapRepo.InsertScanHosts(ctx, documents.Scan.ScanID.String(), hostIDs); err != nil {
+		return fmt.Errorf("failed to link hosts to scan: %w", err)
+	}
+
+	// 6. Insert scan results
+	if err := nmapRepo.InsertScanResults(ctx, documents.ScanResults); err != nil {
+		return fmt.Errorf("failed to insert scan results: %w", err)
+	}
+
+	// 7. Insert banners (after scan results exist)
+	if err := nmapRepo.InsertScripts(ctx, documents.Scripts); err != nil {
+		return fmt.Errorf("failed to insert banners: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package masscan
+
+import (
+	"context"
+	"io"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// JOB_TOOL identifies the ingestion jobs of masscan uploads
+const JOB_TOOL = "masscan"
+
+// RunImportJob imports the masscan outputs of a claimed job, in the format of the job or detected (cf common.RunImportJob)
+func RunImportJob(ctx context.Context, job *models.IngestionJob, jobRepo repositories.JobRepository, nmapRepo repositories.NmapRepository) error {
+	return common.RunImportJob(ctx, job, jobRepo, func(r io.Reader, opts common.ImportOptions, progress common.ProgressFunc) (string, error) {
+		scan, err := Parse(r, Format(job.Format))
+		if err != nil {
+			return "", err
+		}
+		return SaveMasscanScan(ctx, scan, opts, progress, nmapRepo)
+	})
+}
@@ -0,0 +1,326 @@
+package masscan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is one of the masscan output formats
+type Format string
+
+const (
+	FORMAT_XML  Format = "xml"  // -oX
+	FORMAT_JSON Format = "json" // -oJ (and -oD)
+	FORMAT_LIST Format = "list" // -oL
+)
+
+func (f Format) IsValid() bool {
+	switch f {
+	case FORMAT_XML, FORMAT_JSON, FORMAT_LIST:
+		return true
+	default:
+		return false
+	}
+}
+
+// Record is a single masscan line: either a port state, or a banner grabbed on a port
+type Record struct {
+	IP        string
+	Timestamp time.Time
+	Port      uint16
+	Protocol  string
+	// open / closed, empty for banners
+	State string
+	// Banner type (http, ssh, title, ...) and content
+	Service string
+	Banner  string
+}
+
+// Scan is a parsed masscan output
+type Scan struct {
+	Version string
+	Start   time.Time
+	Records []Record
+}
+
+// Parse reads a masscan output in the given format
+// An empty format means it is detected from the first character
+func Parse(r io.Reader, format Format) (*Scan, error) {
+	reader := bufio.NewReader(r)
+
+	if format == "" {
+		var err error
+		if format, err = detectFormat(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case FORMAT_XML:
+		return parseXML(reader)
+	case FORMAT_JSON:
+		return parseJSON(reader)
+	case FORMAT_LIST:
+		return parseList(reader)
+	default:
+		return nil, fmt.Errorf("unknown masscan format: %s", format)
+	}
+}
+
+// XML starts with '<', JSON with '[' or '{', anything else is a list
+func detectFormat(reader *bufio.Reader) (Format, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err == io.EOF {
+			return "", fmt.Errorf("empty masscan output")
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			// Skip leading whitespaces
+			if _, err := reader.ReadByte(); err != nil {
+				return "", err
+			}
+		case '<':
+			return FORMAT_XML, nil
+		case '[', '{':
+			return FORMAT_JSON, nil
+		default:
+			return FORMAT_LIST, nil
+		}
+	}
+}
+
+// Cf https://github.com/robertdavidgraham/masscan/blob/master/src/out-xml.c
+type xmlRun struct {
+	Start   int64     `xml:"start,attr"`
+	Version string    `xml:"version,attr"`
+	Hosts   []xmlHost `xml:"host"`
+}
+
+type xmlHost struct {
+	EndTime int64 `xml:"endtime,attr"`
+	Address struct {
+		Addr string `xml:"addr,attr"`
+	} `xml:"address"`
+	Ports []xmlPort `xml:"ports>port"`
+}
+
+type xmlPort struct {
+	Protocol string `xml:"protocol,attr"`
+	PortID   uint16 `xml:"portid,attr"`
+	State    struct {
+		State string `xml:"state,attr"`
+	} `xml:"state"`
+	Service *struct {
+		Name   string `xml:"name,attr"`
+		Banner string `xml:"banner,attr"`
+	} `xml:"service"`
+}
+
+func parseXML(r io.Reader) (*Scan, error) {
+	var run xmlRun
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, fmt.Errorf("invalid XML format: %w", err)
+	}
+
+	scan := &Scan{Version: run.Version}
+
+	for _, host := range run.Hosts {
+		for _, port := range host.Ports {
+			record := Record{
+				IP:        host.Address.Addr,
+				Timestamp: unixTime(host.EndTime),
+				Port:      port.PortID,
+				Protocol:  port.Protocol,
+				State:     port.State.State,
+			}
+			if port.Service != nil {
+				record.Service = port.Service.Name
+				record.Banner = port.Service.Banner
+			}
+			scan.Records = append(scan.Records, record)
+		}
+	}
+
+	scan.Start = earliestTimestamp(scan.Records)
+	if run.Start != 0 {
+		scan.Start = unixTime(run.Start)
+	}
+
+	return scan, nil
+}
+
+// Cf https://github.com/robertdavidgraham/masscan/blob/master/src/out-json.c
+type jsonRecord struct {
+	IP        string      `json:"ip"`
+	Timestamp json.Number `json:"timestamp"`
+	Ports     []struct {
+		Port    uint16 `json:"port"`
+		Proto   string `json:"proto"`
+		Status  string `json:"status"`
+		Service *struct {
+			Name   string `json:"name"`
+			Banner string `json:"banner"`
+		} `json:"service"`
+	} `json:"ports"`
+}
+
+// Masscan JSON is an array with one record per line, and older versions
+// emit trailing commas or a `{finished: 1}` line: records are read line by line
+func parseJSON(r io.Reader) (*Scan, error) {
+	scan := &Scan{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		data = bytes.TrimPrefix(data, []byte("["))
+		data = bytes.TrimSuffix(data, []byte("]"))
+		data = bytes.Trim(data, ", \t")
+		if len(data) == 0 || bytes.HasPrefix(data, []byte("{finished")) {
+			continue
+		}
+
+		var record jsonRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("invalid JSON format at line %d: %w", line, err)
+		}
+
+		timestamp, err := parseTimestamp(record.Timestamp.String())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		for _, port := range record.Ports {
+			r := Record{
+				IP:        record.IP,
+				Timestamp: timestamp,
+				Port:      port.Port,
+				Protocol:  port.Proto,
+				State:     port.Status,
+			}
+			if port.Service != nil {
+				r.Service = port.Service.Name
+				r.Banner = port.Service.Banner
+			}
+			scan.Records = append(scan.Records, r)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+
+	scan.Start = earliestTimestamp(scan.Records)
+	return scan, nil
+}
+
+// Cf https://github.com/robertdavidgraham/masscan/blob/master/src/out-grepable.c
+// open tcp 80 10.0.0.1 1490000000
+// banner tcp 80 10.0.0.1 1490000000 http HTTP/1.0 200 OK
+func parseList(r io.Reader) (*Scan, error) {
+	scan := &Scan{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(text, " ", 7)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("invalid list format at line %d: expected at least 5 fields", line)
+		}
+
+		port, err := strconv.ParseUint(fields[2], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port at line %d: %w", line, err)
+		}
+
+		timestamp, err := parseTimestamp(fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		record := Record{
+			IP:        fields[3],
+			Timestamp: timestamp,
+			Port:      uint16(port),
+			Protocol:  fields[1],
+		}
+
+		switch fields[0] {
+		case "banner":
+			if len(fields) > 5 {
+				record.Service = fields[5]
+			}
+			if len(fields) > 6 {
+				record.Banner = fields[6]
+			}
+		default:
+			record.State = fields[0]
+		}
+
+		scan.Records = append(scan.Records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read list: %w", err)
+	}
+
+	scan.Start = earliestTimestamp(scan.Records)
+	return scan, nil
+}
+
+// Timestamps are epoch seconds
+func parseTimestamp(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", raw, err)
+	}
+	return unixTime(seconds), nil
+}
+
+// Zero means unknown
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// Used when the output doesn't contain the scan start
+func earliestTimestamp(records []Record) time.Time {
+	var earliest time.Time
+	for _, record := range records {
+		if record.Timestamp.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || record.Timestamp.Before(earliest) {
+			earliest = record.Timestamp
+		}
+	}
+	return earliest
+}
@@ -1,6 +1,9 @@
 package nmap
 
 import (
+	"html"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
@@ -8,56 +11,122 @@ import (
 	"github.com/google/uuid"
 )
 
+// FullScanResults are the rows of a batch of hosts of a scan, the scan itself excluded
 type FullScanResults struct {
-	Scan        models.NmapScan
 	Hosts       []models.NmapHost
 	Services    []models.Service
 	ScanResults []models.ScanResult
-	Scripts     []models.NmapScriptResult
+	// Signature (cf generateServiceKey) of the service of each scan result
+	ResultServiceKeys []string
+	Scripts           []models.NmapScriptResult
+	OSGuesses         []models.NmapOSGuess
 }
 
-// Converts a full nmap scan into database-usable structs
-func ConvertFullScanIntoDocuments(results *nmap.Run) *FullScanResults {
-	fullScanResults := &FullScanResults{
-		Hosts:       []models.NmapHost{},
-		Services:    []models.Service{},
-		ScanResults: []models.ScanResult{},
-		Scripts:     []models.NmapScriptResult{},
+// convertedRows are the ports and host scripts already converted for a scan
+// A host reported more than once (e.g. scanned by IP and by name) only keeps the first result of each
+type convertedRows struct {
+	ports       map[portRow]bool
+	hostScripts map[hostScriptRow]bool
+}
+
+type portRow struct {
+	hostID   uuid.UUID
+	protocol string
+	port     uint16
+}
+
+type hostScriptRow struct {
+	hostID   uuid.UUID
+	scriptID string
+}
+
+func newConvertedRows() *convertedRows {
+	return &convertedRows{
+		ports:       make(map[portRow]bool),
+		hostScripts: make(map[hostScriptRow]bool),
 	}
+}
 
-	scanInfo := convertScanInfoToModel(results)
-	fullScanResults.Scan = scanInfo
+// Converts a batch of hosts of a scan into database-usable structs, converted rows being kept across batches
+// Hosts are identified by their address within the project of the scan (empty for none)
+func convertHosts(hosts []nmap.Host, scanInfo *models.NmapScan, converted *convertedRows) *FullScanResults {
+	project := scanInfo.Project
+	fullScanResults := &FullScanResults{
+		Hosts:             []models.NmapHost{},
+		Services:          []models.Service{},
+		ScanResults:       []models.ScanResult{},
+		ResultServiceKeys: []string{},
+		Scripts:           []models.NmapScriptResult{},
+		OSGuesses:         []models.NmapOSGuess{},
+	}
 
 	// Track services we've already seen (for deduplication)
-	serviceMap := make(map[string]*models.Service)
+	serviceMap := make(map[string]bool)
+	// The same address can be reported more than once (e.g. scanned by IP and by name)
+	hostIndexes := make(map[uuid.UUID]int)
+
+	for _, host := range hosts {
+		hostItem := convertHostToModel(&host, project, scanInfo.ScanStart)
+		if index, exists := hostIndexes[hostItem.HostID]; exists {
+			mergeHosts(&fullScanResults.Hosts[index], &hostItem)
+		} else {
+			hostIndexes[hostItem.HostID] = len(fullScanResults.Hosts)
+			fullScanResults.Hosts = append(fullScanResults.Hosts, hostItem)
+		}
 
-	for _, host := range results.Hosts {
-		hostItem := convertHostToModel(&host)
-		fullScanResults.Hosts = append(fullScanResults.Hosts, hostItem)
+		fullScanResults.OSGuesses = append(fullScanResults.OSGuesses, convertOSGuesses(host.OS.Matches, scanInfo.ScanID, hostItem.HostID, hostItem.LastSeen)...)
 
-		// Convert ports to scan results and services
-		scanResultItems, serviceItems := convertHostPortsToModels(&host, hostItem.HostID, scanInfo.ScanID, serviceMap)
+		// Convert ports to scan results, services and port scripts
+		scanResultItems, serviceKeys, serviceItems, scriptItems := convertHostPortsToModels(&host, hostItem.HostID, scanInfo.ScanID, serviceMap, converted)
 
 		fullScanResults.ScanResults = append(fullScanResults.ScanResults, scanResultItems...)
+		fullScanResults.ResultServiceKeys = append(fullScanResults.ResultServiceKeys, serviceKeys...)
 		fullScanResults.Services = append(fullScanResults.Services, serviceItems...)
+		fullScanResults.Scripts = append(fullScanResults.Scripts, scriptItems...)
+
+		// Host scripts are not bound to a port
+		for _, script := range convertScripts(host.HostScripts, scanInfo.ScanID, hostItem.HostID, nil) {
+			row := hostScriptRow{hostID: hostItem.HostID, scriptID: script.ScriptID}
+			if converted.hostScripts[row] {
+				continue
+			}
+			converted.hostScripts[row] = true
+			fullScanResults.Scripts = append(fullScanResults.Scripts, script)
+		}
 	}
 
 	return fullScanResults
 }
 
 // Convert all ports info to scan results and services
-// Returns both scan results and any new services discovered
+// Returns scan results, the service signature of each of them, any new services discovered and the port scripts
+// Ports already converted for the host are skipped, with their scripts
 // Cf https://github.com/Ullaakut/nmap/blob/5b5552b95453ccf933110e2b48c58cf67160ce1c/xml.go#L175C1-L182C2
-func convertHostPortsToModels(h *nmap.Host, hostID uuid.UUID, scanID uuid.UUID, serviceMap map[string]*models.Service) ([]models.ScanResult, []models.Service) {
+func convertHostPortsToModels(h *nmap.Host, hostID uuid.UUID, scanID uuid.UUID, serviceMap map[string]bool, converted *convertedRows) ([]models.ScanResult, []string, []models.Service, []models.NmapScriptResult) {
 	var scanResults []models.ScanResult
+	var serviceKeys []string
 	var newServices []models.Service
+	var scripts []models.NmapScriptResult
 
 	for _, port := range h.Ports {
+		row := portRow{hostID: hostID, protocol: port.Protocol, port: port.ID}
+		if converted.ports[row] {
+			continue
+		}
+		converted.ports[row] = true
+
 		// Create or reference service
+		// Ports nmap couldn't fingerprint get the empty service of their protocol
+		// Only RPC services have a version range (lowver/highver) instead of a version
+		version := port.Service.Version
+		if version == "" {
+			version = port.Service.HighVersion
+		}
+
 		service := models.Service{
 			ServiceName:      port.Service.Name,
 			ServiceProduct:   port.Service.Product,
-			ServiceVersion:   port.Service.HighVersion,
+			ServiceVersion:   version,
 			ServiceExtraInfo: port.Service.ExtraInfo,
 			Protocol:         port.Protocol, // Detect protocol from nmap data
 			ServiceTunnel:    port.Service.Tunnel,
@@ -66,37 +135,31 @@ func convertHostPortsToModels(h *nmap.Host, hostID uuid.UUID, scanID uuid.UUID,
 		// Generate service signature for deduplication
 		serviceKey := generateServiceKey(&service)
 
-		// Check if we've already added this service
-		var servicePtr *models.Service
-		if existingService, exists := serviceMap[serviceKey]; exists {
-			servicePtr = existingService
-		} else {
-			// New service - will be created in DB later and we'll need to get its ID
-			// For now, add to our tracking and to the results
+		// New service - will be created in DB later and we'll need to get its ID
+		if !serviceMap[serviceKey] {
 			newServices = append(newServices, service)
-			servicePtr = &newServices[len(newServices)-1]
-			serviceMap[serviceKey] = servicePtr
+			serviceMap[serviceKey] = true
 		}
 
 		// Create scan result
+		// ServiceID is set from the signature once services exist in DB
 		scanResult := models.ScanResult{
-			ScanID:    scanID,
-			HostID:    hostID,
-			ServiceID: servicePtr.ServiceID, // Will be set after DB insert
-			Port:      port.ID,
-			PortState: string(port.Status()),
+			// Set now so that scripts can reference it
+			ScanResultID: uuid.New(),
+			ScanID:       scanID,
+			HostID:       hostID,
+			Port:         port.ID,
+			PortState:    string(port.Status()),
 		}
 
 		scanResults = append(scanResults, scanResult)
+		serviceKeys = append(serviceKeys, serviceKey)
 
 		// Convert scripts for this port/scan result
-		// Note: Scripts will be linked to ScanResultID after DB insert
-		scripts := convertScripts(port.Scripts)
-		// We'll handle script assignment in SaveNmapScans after ScanResults are inserted
-		_ = scripts // placeholder until we refactor
+		scripts = append(scripts, convertScripts(port.Scripts, scanID, hostID, &scanResult.ScanResultID)...)
 	}
 
-	return scanResults, newServices
+	return scanResults, serviceKeys, newServices, scripts
 }
 
 // Generate a unique key for service deduplication
@@ -110,6 +173,7 @@ func convertScanInfoToModel(si *nmap.Run) models.NmapScan {
 	return models.NmapScan{
 		ScanID:      uuid.New(),
 		ScanArgs:    si.Args,
+		Scanner:     si.Scanner,
 		NmapVersion: si.Version,
 		ScanStart:   time.Time(si.Start),
 	}
@@ -117,28 +181,76 @@ func convertScanInfoToModel(si *nmap.Run) models.NmapScan {
 
 // Convert a host data to a single model
 // Cf https://github.com/Ullaakut/nmap/blob/5b5552b95453ccf933110e2b48c58cf67160ce1c/xml.go#L101C1-L121C2
-func convertHostToModel(h *nmap.Host) models.NmapHost {
-	addresses := convertAddresses(h.Addresses)
+func convertHostToModel(h *nmap.Host, project string, scanStart time.Time) models.NmapHost {
+	host := primaryAddress(h.Addresses)
+	osName, accuracy := convertOS(h.OS.Matches)
 
-	var host string
-	if len(addresses) > 0 {
-		host = addresses[0] // takes first address
+	// Hosts without their own times were seen when the scan started
+	firstSeen, lastSeen := time.Time(h.StartTime), time.Time(h.EndTime)
+	if firstSeen.IsZero() {
+		firstSeen = scanStart
+	}
+	if lastSeen.IsZero() {
+		lastSeen = firstSeen
 	}
-
-	osName, accuracy := convertOS(h.OS.Matches)
 
 	return models.NmapHost{
-		HostID:     uuid.New(),
+		HostID:     models.NewHostID(host, project),
 		Host:       host,
-		Addresses:  addresses,
+		IP:         models.NewIPAddress(host),
+		Project:    project,
+		Addresses:  convertAddresses(h.Addresses),
 		Hostnames:  convertHostnames(h.Hostnames),
 		HostStatus: h.Status.State,
 		Comment:    h.Comment,
 		OSName:     osName,
 		OSAccuracy: accuracy,
+		FirstSeen:  firstSeen,
+		LastSeen:   lastSeen,
+	}
+}
+
+// The address identifying a host: its first IP, or its first address (e.g. MAC) without any
+func primaryAddress(addresses []nmap.Address) string {
+	for _, address := range addresses {
+		if address.AddrType == "ipv4" || address.AddrType == "ipv6" {
+			return address.Addr
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0].Addr
+	}
+	return ""
+}
+
+// Merge a host reported twice in the same scan into its first occurrence
+func mergeHosts(host *models.NmapHost, other *models.NmapHost) {
+	host.Addresses = appendMissing(host.Addresses, other.Addresses)
+	host.Hostnames = appendMissing(host.Hostnames, other.Hostnames)
+
+	if other.FirstSeen.Before(host.FirstSeen) {
+		host.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(host.LastSeen) {
+		host.LastSeen = other.LastSeen
+	}
+	if other.OSAccuracy > host.OSAccuracy {
+		host.OSName, host.OSAccuracy = other.OSName, other.OSAccuracy
+	}
+	if host.HostStatus != "up" && other.HostStatus != "" {
+		host.HostStatus = other.HostStatus
 	}
 }
 
+func appendMissing(values []string, others []string) []string {
+	for _, other := range others {
+		if !slices.Contains(values, other) {
+			values = append(values, other)
+		}
+	}
+	return values
+}
+
 // Get all ips
 func convertAddresses(addresses []nmap.Address) []string {
 	var ips []string
@@ -181,15 +293,98 @@ func convertOS(matches []nmap.OSMatch) (string, int) {
 	return maxAccName, maxAcc
 }
 
-func convertScripts(rawScripts []nmap.Script) []models.NmapScriptResult {
+// Keep every OS match of the host, not only the best one
+// Cf https://github.com/Ullaakut/nmap/blob/5b5552b95453ccf933110e2b48c58cf67160ce1c/xml.go#L287C1-L302C2
+func convertOSGuesses(matches []nmap.OSMatch, scanID uuid.UUID, hostID uuid.UUID, seenAt time.Time) []models.NmapOSGuess {
+	var guesses []models.NmapOSGuess
+
+	for _, match := range matches {
+		guess := models.NmapOSGuess{
+			HostID:   hostID,
+			ScanID:   scanID,
+			Name:     match.Name,
+			Accuracy: match.Accuracy,
+			SeenAt:   seenAt,
+		}
+		if len(match.Classes) > 0 {
+			guess.Vendor = match.Classes[0].Vendor
+			guess.Family = match.Classes[0].Family
+			guess.Generation = match.Classes[0].OSGeneration
+		}
+		guesses = append(guesses, guess)
+	}
+
+	return guesses
+}
+
+// Convert scripts of a port (scanResultID set) or of a host (scanResultID nil)
+// Cf https://github.com/Ullaakut/nmap/blob/5b5552b95453ccf933110e2b48c58cf67160ce1c/xml.go#L252C1-L270C2
+func convertScripts(rawScripts []nmap.Script, scanID uuid.UUID, hostID uuid.UUID, scanResultID *uuid.UUID) []models.NmapScriptResult {
 	var scripts []models.NmapScriptResult
 
 	for _, script := range rawScripts {
+		var data map[string]any
+		if len(script.Elements) > 0 || len(script.Tables) > 0 {
+			data = convertScriptEntries(script.Elements, script.Tables)
+		}
+
 		scripts = append(scripts, models.NmapScriptResult{
+			ScanID:       scanID,
+			HostID:       hostID,
+			ScanResultID: scanResultID,
 			ScriptID:     script.ID,
 			ScriptOutput: script.Output,
+			ScriptData:   data,
 		})
 	}
 
 	return scripts
 }
+
+// NSE structured outputs are Lua tables: keyed entries keep their key,
+// unkeyed entries are keyed by their position (1-based, elements first)
+func convertScriptEntries(elements []nmap.Element, tables []nmap.Table) map[string]any {
+	entries := make(map[string]any, len(elements)+len(tables))
+	position := 0
+
+	nextKey := func(key string) string {
+		if key != "" {
+			return key
+		}
+		position++
+		return strconv.Itoa(position)
+	}
+
+	for _, element := range elements {
+		// innerxml keeps entities escaped
+		entries[nextKey(element.Key)] = html.UnescapeString(element.Value)
+	}
+	for _, table := range tables {
+		entries[nextKey(table.Key)] = convertScriptTable(&table)
+	}
+
+	return entries
+}
+
+// Tables with unkeyed entries only are lists (e.g. ssl-cert's pubkey exponents, vulners' CVEs)
+func convertScriptTable(t *nmap.Table) any {
+	for _, element := range t.Elements {
+		if element.Key != "" {
+			return convertScriptEntries(t.Elements, t.Tables)
+		}
+	}
+	for _, table := range t.Tables {
+		if table.Key != "" {
+			return convertScriptEntries(t.Elements, t.Tables)
+		}
+	}
+
+	list := make([]any, 0, len(t.Elements)+len(t.Tables))
+	for _, element := range t.Elements {
+		list = append(list, html.UnescapeString(element.Value))
+	}
+	for _, table := range t.Tables {
+		list = append(list, convertScriptTable(&table))
+	}
+	return list
+}
@@ -4,68 +4,133 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
 	"github.com/Ullaakut/nmap/v4"
+	"github.com/google/uuid"
 )
 
-// SaveNmapScans saves nmap scans with proper service deduplication and cascading relationships
-func SaveNmapScans(ctx context.Context, nmapData *nmap.Run, nmapRepo repositories.NmapRepository) ([]string, error) {
-	bulkItems := ConvertFullScanIntoDocuments(nmapData)
+// Number of hosts written at once, which bounds memory when streaming
+const hostBatchSize = 500
 
-	// 1. Insert or reference hosts (upsert by host IP)
+// scanImporter writes the hosts of a scan by batches, with proper service deduplication and cascading relationships
+// Hosts are merged with the ones of previous scans of the same project
+type scanImporter struct {
+	nmapRepo repositories.NmapRepository
+	scan     *models.NmapScan
+	// Service signature -> ServiceID, across batches
+	services map[string]uuid.UUID
+	// Ports and host scripts written, across batches
+	converted *convertedRows
+	pending   []nmap.Host
+	// Optional
+	progress common.ProgressFunc
+}
+
+// Insert the scan, so that its hosts can be added
+func newScanImporter(ctx context.Context, nmapRepo repositories.NmapRepository, scan *models.NmapScan, progress common.ProgressFunc) (*scanImporter, error) {
+	if err := nmapRepo.InsertScan(ctx, scan); err != nil {
+		return nil, fmt.Errorf("failed to insert scan: %w", err)
+	}
+
+	return &scanImporter{
+		nmapRepo:  nmapRepo,
+		scan:      scan,
+		services:  make(map[string]uuid.UUID),
+		converted: newConvertedRows(),
+		pending:   make([]nmap.Host, 0, hostBatchSize),
+		progress:  progress,
+	}, nil
+}
+
+// Add a host, writing the pending ones once a batch is full
+func (i *scanImporter) add(ctx context.Context, host nmap.Host) error {
+	i.pending = append(i.pending, host)
+	if len(i.pending) < hostBatchSize {
+		return nil
+	}
+	return i.flush(ctx)
+}
+
+// Write the pending hosts
+func (i *scanImporter) flush(ctx context.Context) error {
+	if len(i.pending) == 0 {
+		return nil
+	}
+
+	bulkItems := convertHosts(i.pending, i.scan, i.converted)
+	if err := i.insertDocuments(ctx, bulkItems); err != nil {
+		return err
+	}
+	if i.progress != nil {
+		i.progress(len(bulkItems.Hosts), len(bulkItems.ScanResults))
+	}
+
+	i.pending = i.pending[:0]
+	return nil
+}
+
+// Insert every row of a batch of hosts, in dependency order
+func (i *scanImporter) insertDocuments(ctx context.Context, bulkItems *FullScanResults) error {
+	// 1. Insert or merge hosts (stable ID from IP and project), and link them to the scan, even without ports
 	if len(bulkItems.Hosts) > 0 {
-		if err := nmapRepo.InsertHosts(ctx, bulkItems.Hosts); err != nil {
-			return nil, fmt.Errorf("failed to insert hosts: %w", err)
+		if err := i.nmapRepo.InsertHosts(ctx, bulkItems.Hosts); err != nil {
+			return fmt.Errorf("failed to insert hosts: %w", err)
+		}
+		hostIDs := make([]uuid.UUID, 0, len(bulkItems.Hosts))
+		for _, host := range bulkItems.Hosts {
+			hostIDs = append(hostIDs, host.HostID)
+		}
+		if err := i.nmapRepo.InsertScanHosts(ctx, i.scan.ScanID.String(), hostIDs); err != nil {
+			return fmt.Errorf("failed to link hosts to scan: %w", err)
 		}
 	}
 
-	// 2. Get or create services (dedup by signature)
-	serviceSignatureMap := make(map[string]*models.Service) // signature -> Service with populated ServiceID
-	for i, service := range bulkItems.Services {
-		createdService, err := nmapRepo.GetOrCreateService(ctx, &service)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get or create service: %w", err)
+	// 2. Get or create services (dedup by signature), unless a previous batch did
+	for j := range bulkItems.Services {
+		// Signature of the converted service, the one scan results reference
+		signature := generateServiceKey(&bulkItems.Services[j])
+		if _, exists := i.services[signature]; exists {
+			continue
 		}
-		// Store the service with its populated ServiceID
-		signature := generateServiceKey(createdService)
-		serviceSignatureMap[signature] = createdService
-		bulkItems.Services[i] = *createdService
-	}
 
-	// 3. Update scan results with actual service IDs
-	for i := range bulkItems.ScanResults {
-		// ServiceID is already set from conversion, just need to populate from map
-		// Find matching service by iterating through created services
-		for _, service := range bulkItems.Services {
-			if service.ServiceName == "" && service.ServiceProduct == "" {
-				// Skip empty services
-				continue
-			}
-			// Use the service ID from the created service
-			bulkItems.ScanResults[i].ServiceID = service.ServiceID
-			break
+		createdService, err := i.nmapRepo.GetOrCreateService(ctx, &bulkItems.Services[j])
+		if err != nil {
+			return fmt.Errorf("failed to get or create service: %w", err)
 		}
+		i.services[signature] = createdService.ServiceID
 	}
 
-	// 4. Insert scan
-	if err := nmapRepo.InsertScan(ctx, &bulkItems.Scan); err != nil {
-		return nil, fmt.Errorf("failed to insert scan: %w", err)
+	// 3. Link each scan result to the service of its own signature
+	for j := range bulkItems.ScanResults {
+		serviceID, ok := i.services[bulkItems.ResultServiceKeys[j]]
+		if !ok {
+			return fmt.Errorf("no service for port %d", bulkItems.ScanResults[j].Port)
+		}
+		bulkItems.ScanResults[j].ServiceID = serviceID
 	}
 
-	// 5. Insert scan results
+	// 4. Insert scan results
 	if len(bulkItems.ScanResults) > 0 {
-		if err := nmapRepo.InsertScanResults(ctx, bulkItems.ScanResults); err != nil {
-			return nil, fmt.Errorf("failed to insert scan results: %w", err)
+		if err := i.nmapRepo.InsertScanResults(ctx, bulkItems.ScanResults); err != nil {
+			return fmt.Errorf("failed to insert scan results: %w", err)
 		}
 	}
 
-	// 6. Insert scripts (after scan results exist)
+	// 5. Insert scripts (after scan results exist)
 	if len(bulkItems.Scripts) > 0 {
-		if err := nmapRepo.InsertScripts(ctx, bulkItems.Scripts); err != nil {
-			return nil, fmt.Errorf("failed to insert scripts: %w", err)
+		if err := i.nmapRepo.InsertScripts(ctx, bulkItems.Scripts); err != nil {
+			return fmt.Errorf("failed to insert scripts: %w", err)
+		}
+	}
+
+	// 6. Insert OS guesses
+	if len(bulkItems.OSGuesses) > 0 {
+		if err := i.nmapRepo.InsertOSGuesses(ctx, bulkItems.OSGuesses); err != nil {
+			return fmt.Errorf("failed to insert os guesses: %w", err)
 		}
 	}
 
-	return []string{bulkItems.Scan.ScanID.String()}, nil
+	return nil
 }
@@ -0,0 +1,20 @@
+package nmap
+
+import (
+	"context"
+	"io"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// JOB_TOOL identifies the ingestion jobs of nmap uploads
+const JOB_TOOL = "nmap"
+
+// RunImportJob imports the nmap XML files of a claimed job, streamed by batches (cf common.RunImportJob)
+func RunImportJob(ctx context.Context, job *models.IngestionJob, jobRepo repositories.JobRepository, nmapRepo repositories.NmapRepository) error {
+	return common.RunImportJob(ctx, job, jobRepo, func(r io.Reader, opts common.ImportOptions, progress common.ProgressFunc) (string, error) {
+		return SaveNmapStream(ctx, r, opts, progress, nmapRepo)
+	})
+}
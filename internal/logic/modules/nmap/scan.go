@@ -0,0 +1,72 @@
+package nmap
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/widgets/dashboard"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// GetScanDetail returns a scan with its hosts, and the ports, services and scripts found on each
+func GetScanDetail(ctx context.Context, nmapRepo repositories.NmapRepository, scanID string) (*models.NmapScanDetail, error) {
+	scan, err := nmapRepo.GetScan(ctx, scanID)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts, err := nmapRepo.GetHosts(ctx, scanID)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := nmapRepo.GetScanServices(ctx, scanID)
+	if err != nil {
+		return nil, err
+	}
+	servicesByID := make(map[uuid.UUID]*models.Service, len(services))
+	for i := range services {
+		servicesByID[services[i].ServiceID] = &services[i]
+	}
+
+	portsByHost := make(map[uuid.UUID][]models.NmapScanPort)
+	for _, result := range scan.ScanResults {
+		portsByHost[result.HostID] = append(portsByHost[result.HostID], models.NmapScanPort{
+			ScanResult: result,
+			Service:    servicesByID[result.ServiceID],
+		})
+	}
+	// Listed by host instead
+	scan.ScanResults = nil
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Host < hosts[j].Host
+	})
+
+	detail := &models.NmapScanDetail{NmapScan: *scan, Hosts: make([]models.NmapScanDetailHost, 0, len(hosts))}
+	for _, host := range hosts {
+		ports := portsByHost[host.HostID]
+		if ports == nil {
+			ports = []models.NmapScanPort{}
+		}
+		sort.Slice(ports, func(i, j int) bool {
+			return ports[i].Port < ports[j].Port
+		})
+		detail.Hosts = append(detail.Hosts, models.NmapScanDetailHost{NmapHost: host, Ports: ports})
+	}
+
+	return detail, nil
+}
+
+// DeleteScan deletes a scan, what it imported, and its dashboard rows, all at once
+func DeleteScan(ctx context.Context, nmapRepo repositories.NmapRepository, scanID string) error {
+	return nmapRepo.DeleteScan(ctx, scanID)
+}
+
+// ReprocessScan rebuilds the data derived from a scan (its dashboard rows), and returns the number of dashboard rows
+func ReprocessScan(ctx context.Context, nmapRepo repositories.NmapRepository, dashboardRepo postgres.DashboardRepository, scanID string) (int, error) {
+	return dashboard.BuildScanDashboard(ctx, nmapRepo, dashboardRepo, scanID)
+}
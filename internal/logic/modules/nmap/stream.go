@@ -0,0 +1,101 @@
+package nmap
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/Ullaakut/nmap/v4"
+)
+
+// ErrInvalidXML is returned when a file isn't a valid nmap XML output
+var ErrInvalidXML = errors.New("invalid XML format")
+
+// SaveNmapStream imports an nmap XML output while reading it, decoding hosts one at a time
+// Hosts are written by batches, so memory stays flat whatever the size of the file
+// Re-uploading a file (same content hash and project) returns the scan imported the first time, without reading it
+// progress, if any, is called after each batch
+func SaveNmapStream(ctx context.Context, r io.Reader, opts common.ImportOptions, progress common.ProgressFunc, nmapRepo repositories.NmapRepository) (string, error) {
+	decoder := xml.NewDecoder(r)
+
+	return common.ImportOnce(ctx, nmapRepo, opts, func(tx repositories.NmapRepository) (string, error) {
+		var importer *scanImporter
+		for {
+			token, err := decoder.Token()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("%w: %w", ErrInvalidXML, err)
+			}
+
+			start, ok := token.(xml.StartElement)
+			if !ok {
+				continue
+			}
+
+			switch start.Name.Local {
+			case "nmaprun":
+				if importer != nil {
+					return "", fmt.Errorf("%w: nested nmaprun element", ErrInvalidXML)
+				}
+				run, err := parseRunAttributes(&start)
+				if err != nil {
+					return "", err
+				}
+
+				scan := convertScanInfoToModel(run)
+				scan.Project = opts.Project
+				scan.ContentHash = opts.ContentHash
+				if importer, err = newScanImporter(ctx, tx, &scan, progress); err != nil {
+					return "", err
+				}
+
+			case "host":
+				if importer == nil {
+					return "", fmt.Errorf("%w: host outside of nmaprun", ErrInvalidXML)
+				}
+				var host nmap.Host
+				if err := decoder.DecodeElement(&host, &start); err != nil {
+					return "", fmt.Errorf("%w: %w", ErrInvalidXML, err)
+				}
+				if err := importer.add(ctx, host); err != nil {
+					return "", err
+				}
+			}
+		}
+
+		if importer == nil {
+			return "", fmt.Errorf("%w: no nmaprun element", ErrInvalidXML)
+		}
+		if err := importer.flush(ctx); err != nil {
+			return "", err
+		}
+
+		return importer.scan.ScanID.String(), nil
+	})
+}
+
+// Read the scan information of the nmaprun element, its children being streamed
+func parseRunAttributes(start *xml.StartElement) (*nmap.Run, error) {
+	run := &nmap.Run{}
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "args":
+			run.Args = attr.Value
+		case "scanner":
+			run.Scanner = attr.Value
+		case "version":
+			run.Version = attr.Value
+		case "start":
+			if err := run.Start.ParseTime(attr.Value); err != nil {
+				return nil, fmt.Errorf("%w: invalid start time: %w", ErrInvalidXML, err)
+			}
+		}
+	}
+	return run, nil
+}
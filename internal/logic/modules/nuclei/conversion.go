@@ -0,0 +1,135 @@
+package nuclei
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+)
+
+// Result is a single line of `nuclei -jsonl` output
+// Cf https://github.com/projectdiscovery/nuclei/blob/dev/pkg/output/output.go
+type Result struct {
+	TemplateID       string            `json:"template-id"`
+	Info             Info              `json:"info"`
+	Type             string            `json:"type"`
+	Host             string            `json:"host"`
+	Port             common.PortNumber `json:"port"`
+	URL              string            `json:"url"`
+	MatchedAt        string            `json:"matched-at"`
+	ExtractedResults []string          `json:"extracted-results"`
+	IP               string            `json:"ip"`
+	Timestamp        time.Time         `json:"timestamp"`
+	MatcherName      string            `json:"matcher-name"`
+}
+
+// Info contains the template metadata
+type Info struct {
+	Name           string          `json:"name"`
+	Tags           stringSlice     `json:"tags"`
+	Severity       string          `json:"severity"`
+	Classification *Classification `json:"classification"`
+}
+
+// Classification contains the CVE/CWE references of a template
+type Classification struct {
+	CVEID     stringSlice `json:"cve-id"`
+	CWEID     stringSlice `json:"cwe-id"`
+	CVSSScore float64     `json:"cvss-score"`
+}
+
+// stringSlice mirrors nuclei's StringSlice: either a string ("a,b") or an array
+type stringSlice []string
+
+func (s *stringSlice) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err == nil {
+		*s = values
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("expected string or array of strings: %w", err)
+	}
+
+	*s = nil
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*s = append(*s, part)
+		}
+	}
+	return nil
+}
+
+// ParseJSONL reads nuclei results, one JSON document per line
+func ParseJSONL(r io.Reader) ([]Result, error) {
+	return common.DecodeJSONL[Result](r)
+}
+
+// Convert a nuclei result into a finding, without any link to nmap data
+func convertResultToModel(r *Result) models.NucleiFinding {
+	finding := models.NucleiFinding{
+		TemplateID:       r.TemplateID,
+		TemplateName:     r.Info.Name,
+		Severity:         convertSeverity(r.Info.Severity),
+		Tags:             []string(r.Info.Tags),
+		Type:             r.Type,
+		Host:             r.Host,
+		IP:               models.NewIPAddress(resolveIP(r)),
+		Port:             resolvePort(r),
+		MatchedAt:        r.MatchedAt,
+		MatcherName:      r.MatcherName,
+		ExtractedResults: r.ExtractedResults,
+		Timestamp:        r.Timestamp,
+	}
+
+	if r.Info.Classification != nil {
+		finding.CVEIDs = []string(r.Info.Classification.CVEID)
+		finding.CWEIDs = []string(r.Info.Classification.CWEID)
+		finding.CVSSScore = r.Info.Classification.CVSSScore
+	}
+
+	return finding
+}
+
+// Unknown severities are kept as "unknown"
+func convertSeverity(severity string) string {
+	s := models.NucleiSeverity(strings.ToLower(severity))
+	if !s.IsValid() {
+		return string(models.NUCLEI_SEVERITY_UNKNOWN)
+	}
+	return string(s)
+}
+
+// The IP nuclei resolved, or the host itself if it's an IP literal
+func resolveIP(r *Result) string {
+	if r.IP != "" {
+		return r.IP
+	}
+
+	host := common.TargetHost(r.Host)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	return ""
+}
+
+// The port nuclei reported, or the one found in the matched URL
+func resolvePort(r *Result) uint16 {
+	if r.Port != 0 {
+		return uint16(r.Port)
+	}
+
+	for _, target := range []string{r.MatchedAt, r.URL, r.Host} {
+		if port := common.TargetPort(target); port != 0 {
+			return port
+		}
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package nuclei
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// SaveNucleiFindings saves nuclei results, linking them to nmap hosts and services by IP/port
+// Hosts are looked up within the project (empty for none)
+// Returns the number of inserted findings
+func SaveNucleiFindings(
+	ctx context.Context,
+	results []Result,
+	project string,
+	nucleiRepo repositories.NucleiRepository,
+	nmapRepo repositories.NmapRepository,
+) (int, error) {
+	findings := make([]models.NucleiFinding, 0, len(results))
+	linker := common.NewHostLinker(nmapRepo, project)
+
+	for i := range results {
+		finding := convertResultToModel(&results[i])
+
+		hostID, serviceID, err := linker.Link(ctx, string(finding.IP), finding.Port)
+		if err != nil {
+			return 0, err
+		}
+		finding.HostID = hostID
+		finding.ServiceID = serviceID
+
+		findings = append(findings, finding)
+	}
+
+	if err := nucleiRepo.InsertFindings(ctx, findings); err != nil {
+		return 0, fmt.Errorf("failed to insert findings: %w", err)
+	}
+
+	return len(findings), nil
+}
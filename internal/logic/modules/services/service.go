@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// GetServiceDetail returns a service, when it was seen, and the hosts and ports currently exposing it
+func GetServiceDetail(ctx context.Context, repo repositories.ServiceRepository, serviceID string) (*models.ServiceDetail, error) {
+	detail, err := repo.GetService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	exposures, err := repo.GetServiceExposures(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	detail.Exposures = exposures
+
+	return detail, nil
+}
+
+// GetServiceVersions returns the versions of a product (of every product if empty), with the hosts currently exposing each
+func GetServiceVersions(ctx context.Context, repo repositories.ServiceRepository, product string) ([]models.ServiceVersion, error) {
+	return repo.GetServiceVersions(ctx, product)
+}
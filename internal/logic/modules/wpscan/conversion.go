@@ -0,0 +1,246 @@
+package wpscan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Report is a `wpscan --format json` output
+// Cf https://github.com/wpscanteam/wpscan/tree/master/app/views/json
+type Report struct {
+	StartTime           int64                `json:"start_time"`
+	TargetURL           string               `json:"target_url"`
+	TargetIP            string               `json:"target_ip"`
+	EffectiveURL        string               `json:"effective_url"`
+	InterestingFindings []Finding            `json:"interesting_findings"`
+	Version             *Version             `json:"version"`
+	MainTheme           *Component           `json:"main_theme"`
+	Plugins             map[string]Component `json:"plugins"`
+	Themes              map[string]Component `json:"themes"`
+}
+
+// Finding is an interesting finding (headers, robots.txt, xmlrpc, ...)
+type Finding struct {
+	URL                string              `json:"url"`
+	ToS                string              `json:"to_s"`
+	Type               string              `json:"type"`
+	FoundBy            string              `json:"found_by"`
+	Confidence         int                 `json:"confidence"`
+	InterestingEntries []string            `json:"interesting_entries"`
+	References         map[string][]string `json:"references"`
+}
+
+// Version is a detected version, with the vulnerabilities affecting it
+type Version struct {
+	Number          string          `json:"number"`
+	Status          string          `json:"status"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// wpscan outputs `false` instead of null for some undetected versions
+func (v *Version) UnmarshalJSON(data []byte) error {
+	if string(data) == "false" {
+		*v = Version{}
+		return nil
+	}
+
+	type version Version
+	return json.Unmarshal(data, (*version)(v))
+}
+
+// Component is an enumerated plugin or theme
+type Component struct {
+	Slug            string          `json:"slug"`
+	Location        string          `json:"location"`
+	LatestVersion   string          `json:"latest_version"`
+	Outdated        bool            `json:"outdated"`
+	Version         *Version        `json:"version"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// Vulnerability is a vulnerability listed by the WPScan API
+type Vulnerability struct {
+	Title      string              `json:"title"`
+	FixedIn    string              `json:"fixed_in"`
+	References map[string][]string `json:"references"`
+}
+
+// WordPress itself is stored as a component, under this slug
+const wordpressSlug = "wordpress"
+
+// ParseJSON reads wpscan reports
+// Several reports can be concatenated in the same body
+func ParseJSON(r io.Reader) ([]Report, error) {
+	var reports []Report
+
+	decoder := json.NewDecoder(r)
+	for {
+		var report Report
+		err := decoder.Decode(&report)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("report %d: %w", len(reports)+1, err)
+		}
+		if report.TargetURL == "" {
+			return nil, fmt.Errorf("report %d: missing target_url", len(reports)+1)
+		}
+		reports = append(reports, report)
+	}
+
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("no wpscan report found")
+	}
+	return reports, nil
+}
+
+// Convert a wpscan report into a scan with its components, findings and vulnerabilities
+// IDs are generated here so that vulnerabilities can reference their component
+func convertReportToModel(r *Report) models.WPScanScan {
+	scan := models.WPScanScan{
+		ScanID:       uuid.New(),
+		TargetURL:    r.TargetURL,
+		TargetIP:     resolveIP(r),
+		EffectiveURL: r.EffectiveURL,
+		Port:         common.TargetPort(r.TargetURL),
+		ScanStart:    time.Unix(r.StartTime, 0),
+	}
+
+	if r.Version != nil && r.Version.Number != "" {
+		scan.WordPressVersion = r.Version.Number
+		scan.WordPressStatus = r.Version.Status
+		addComponent(&scan, models.WPSCAN_COMPONENT_WORDPRESS, &Component{
+			Slug:            wordpressSlug,
+			Version:         r.Version,
+			Outdated:        r.Version.Status != "" && r.Version.Status != "latest",
+			Vulnerabilities: r.Version.Vulnerabilities,
+		})
+	}
+
+	// The main theme is usually listed again in themes when enumerating them
+	if r.MainTheme != nil && r.MainTheme.Slug != "" {
+		scan.MainTheme = r.MainTheme.Slug
+		if _, ok := r.Themes[r.MainTheme.Slug]; !ok {
+			addComponent(&scan, models.WPSCAN_COMPONENT_THEME, r.MainTheme)
+		}
+	}
+
+	for _, slug := range sortedSlugs(r.Themes) {
+		theme := r.Themes[slug]
+		addComponent(&scan, models.WPSCAN_COMPONENT_THEME, &theme)
+	}
+	for _, slug := range sortedSlugs(r.Plugins) {
+		plugin := r.Plugins[slug]
+		addComponent(&scan, models.WPSCAN_COMPONENT_PLUGIN, &plugin)
+	}
+
+	for _, f := range r.InterestingFindings {
+		scan.Findings = append(scan.Findings, models.WPScanFinding{
+			FindingID:          uuid.New(),
+			ScanID:             scan.ScanID,
+			TargetURL:          scan.TargetURL,
+			URL:                f.URL,
+			Type:               f.Type,
+			Description:        f.ToS,
+			FoundBy:            f.FoundBy,
+			Confidence:         f.Confidence,
+			InterestingEntries: f.InterestingEntries,
+			References:         f.References,
+		})
+	}
+
+	return scan
+}
+
+// Adds a component and its vulnerabilities to the scan
+func addComponent(scan *models.WPScanScan, componentType models.WPScanComponentType, c *Component) {
+	component := models.WPScanComponent{
+		ComponentID:   uuid.New(),
+		ScanID:        scan.ScanID,
+		TargetURL:     scan.TargetURL,
+		Type:          string(componentType),
+		Slug:          c.Slug,
+		LatestVersion: c.LatestVersion,
+		Outdated:      c.Outdated,
+		Location:      c.Location,
+	}
+
+	// Vulnerabilities are listed on the component, and on the version when it's known
+	vulnerabilities := c.Vulnerabilities
+	if c.Version != nil {
+		component.Version = c.Version.Number
+		if componentType != models.WPSCAN_COMPONENT_WORDPRESS {
+			vulnerabilities = append(vulnerabilities, c.Version.Vulnerabilities...)
+		}
+	}
+
+	seen := make(map[string]bool, len(vulnerabilities))
+	for _, v := range vulnerabilities {
+		if seen[v.Title] {
+			continue
+		}
+		seen[v.Title] = true
+
+		scan.Vulnerabilities = append(scan.Vulnerabilities, models.WPScanVulnerability{
+			VulnerabilityID:  uuid.New(),
+			ScanID:           scan.ScanID,
+			ComponentID:      component.ComponentID,
+			TargetURL:        scan.TargetURL,
+			ComponentType:    component.Type,
+			ComponentSlug:    component.Slug,
+			ComponentVersion: component.Version,
+			Title:            v.Title,
+			FixedIn:          v.FixedIn,
+			CVEIDs:           convertCVEs(v.References["cve"]),
+			References:       v.References,
+		})
+	}
+
+	scan.Components = append(scan.Components, component)
+}
+
+// wpscan lists CVEs without their prefix ("2020-35489")
+func convertCVEs(cves []string) []string {
+	ids := make([]string, 0, len(cves))
+	for _, cve := range cves {
+		if !strings.HasPrefix(strings.ToUpper(cve), "CVE-") {
+			cve = "CVE-" + cve
+		}
+		ids = append(ids, strings.ToUpper(cve))
+	}
+	return ids
+}
+
+// The IP wpscan resolved, or the host itself if it's an IP literal
+func resolveIP(r *Report) string {
+	if r.TargetIP != "" {
+		return r.TargetIP
+	}
+
+	host := common.TargetHost(r.TargetURL)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	return ""
+}
+
+// Map keys are sorted to keep a stable order
+func sortedSlugs(components map[string]Component) []string {
+	slugs := make([]string, 0, len(components))
+	for slug := range components {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	return slugs
+}
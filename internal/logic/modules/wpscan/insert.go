@@ -0,0 +1,52 @@
+package wpscan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+// SaveWPScanReports saves wpscan reports, linking them to nmap hosts and services by target IP/port
+// Hosts are looked up within the project (empty for none)
+// Returns the IDs of the inserted scans
+func SaveWPScanReports(
+	ctx context.Context,
+	reports []Report,
+	project string,
+	wpscanRepo repositories.WPScanRepository,
+	nmapRepo repositories.NmapRepository,
+) ([]string, error) {
+	scanIDs := make([]string, 0, len(reports))
+	linker := common.NewHostLinker(nmapRepo, project)
+
+	for i := range reports {
+		scan := convertReportToModel(&reports[i])
+
+		hostID, serviceID, err := linker.Link(ctx, scan.TargetIP, scan.Port)
+		if err != nil {
+			return nil, err
+		}
+		scan.HostID = hostID
+		scan.ServiceID = serviceID
+
+		// Copied so that components and vulnerabilities can be searched by host directly
+		for j := range scan.Components {
+			scan.Components[j].HostID = hostID
+		}
+		for j := range scan.Findings {
+			scan.Findings[j].HostID = hostID
+		}
+		for j := range scan.Vulnerabilities {
+			scan.Vulnerabilities[j].HostID = hostID
+		}
+
+		if err := wpscanRepo.InsertScan(ctx, &scan); err != nil {
+			return nil, fmt.Errorf("failed to insert scan of %s: %w", scan.TargetURL, err)
+		}
+		scanIDs = append(scanIDs, scan.ScanID.String())
+	}
+
+	return scanIDs, nil
+}
@@ -2,6 +2,7 @@ package dashboard
 
 import (
 	"context"
+	"time"
 
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/core/models/widgets"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres"
@@ -9,54 +10,45 @@ import (
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
 )
 
-// BuildDashboardScans rebuilds the dashboard table from the last `days` of scans
+// BuildDashboardScans rebuilds the dashboard table from the scans of the last `days`
+// Rows of older scans (e.g. reprocessed) are dropped
 func BuildDashboardScans(
 	ctx context.Context,
 	nmapRepo repositories.NmapRepository,
 	dashboardRepo postgres.DashboardRepository,
 	days int,
 ) error {
-	// Fetch all recent scans
+	// Fetch all recent scans, page by page
 	params := &models.SearchParams{
-		Sort: []models.SortSpec{{Parameter: "scan_start", Direction: "DESC"}},
-	}
-	_, scans, err := nmapRepo.Search(ctx, params)
-	if err != nil {
-		return err
+		Search: []models.SearchSpec{{Scalar: &models.ScalarSearchSpec{
+			Parameter: "scan_start",
+			Operator:  models.OpGt,
+			Value:     time.Now().AddDate(0, 0, -days),
+		}}},
+		Sort:    []models.SortSpec{{Parameter: "scan_start", Direction: "DESC"}},
+		PerPage: models.MAX_RESULTS_PER_PAGE,
+		Count:   models.CountNone,
 	}
 
-	dashboardRows := make([]widgets.WidgetDashboardScan, 0, len(scans))
-
-	for _, scan := range scans {
-		hosts, err := nmapRepo.GetHosts(ctx, scan.ScanID.String())
+	var dashboardRows []widgets.WidgetDashboardScan
+	for {
+		result, err := nmapRepo.Search(ctx, params)
 		if err != nil {
 			return err
 		}
 
-		for _, host := range hosts {
-			results, err := nmapRepo.GetScanResults(ctx, scan.ScanID.String(), host.HostID.String())
+		for _, scan := range result.Results {
+			rows, err := scanDashboardRows(ctx, nmapRepo, &scan)
 			if err != nil {
 				return err
 			}
+			dashboardRows = append(dashboardRows, rows...)
+		}
 
-			// Convert scan results to []int
-			ports := make([]int, 0, len(results))
-			for _, r := range results {
-				ports = append(ports, int(r.Port))
-			}
-
-			row := widgets.WidgetDashboardScan{
-				ScanID:     scan.ScanID.String(),
-				HostID:     host.HostID.String(),
-				ScanStart:  scan.ScanStart,
-				Host:       host.Host,
-				HostNames:  host.Hostnames,
-				Ports:      ports,
-				PortNumber: len(ports),
-			}
-
-			dashboardRows = append(dashboardRows, row)
+		if result.NextCursor == "" {
+			break
 		}
+		params.Cursor = result.NextCursor
 	}
 
 	// Flush table
@@ -73,3 +65,64 @@ func BuildDashboardScans(
 
 	return nil
 }
+
+// BuildScanDashboard rebuilds the dashboard rows of a single scan, and returns how many there are
+func BuildScanDashboard(
+	ctx context.Context,
+	nmapRepo repositories.NmapRepository,
+	dashboardRepo postgres.DashboardRepository,
+	scanID string,
+) (int, error) {
+	scan, err := nmapRepo.GetScan(ctx, scanID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := scanDashboardRows(ctx, nmapRepo, scan)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := dashboardRepo.DeleteDashboardScans(ctx, scanID); err != nil {
+		return 0, err
+	}
+	if err := dashboardRepo.CreateDashboardScans(ctx, rows); err != nil {
+		return 0, err
+	}
+
+	return len(rows), nil
+}
+
+// scanDashboardRows returns a dashboard row per host of a scan, with its ports
+func scanDashboardRows(ctx context.Context, nmapRepo repositories.NmapRepository, scan *models.NmapScan) ([]widgets.WidgetDashboardScan, error) {
+	hosts, err := nmapRepo.GetHosts(ctx, scan.ScanID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]widgets.WidgetDashboardScan, 0, len(hosts))
+	for _, host := range hosts {
+		results, err := nmapRepo.GetScanResults(ctx, scan.ScanID.String(), host.HostID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		// Convert scan results to []int
+		ports := make([]int, 0, len(results))
+		for _, r := range results {
+			ports = append(ports, int(r.Port))
+		}
+
+		rows = append(rows, widgets.WidgetDashboardScan{
+			ScanID:     scan.ScanID.String(),
+			HostID:     host.HostID.String(),
+			ScanStart:  scan.ScanStart,
+			Host:       host.Host,
+			HostNames:  host.Hostnames,
+			Ports:      ports,
+			PortNumber: len(ports),
+		})
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,72 @@
+package dashboard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/core/models/widgets"
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDashboardScans(t *testing.T) {
+	// Three pages of scans
+	pages := [][]models.NmapScan{
+		{{ScanID: uuid.New()}, {ScanID: uuid.New()}},
+		{{ScanID: uuid.New()}},
+		{{ScanID: uuid.New()}},
+	}
+	var searched []*models.SearchParams
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error) {
+			copied := *params
+			searched = append(searched, &copied)
+
+			page := len(searched) - 1
+			result := &models.SearchResult[models.NmapScan]{Results: pages[page]}
+			if page < len(pages)-1 {
+				result.NextCursor = pages[page+1][0].ScanID.String()
+			}
+			return result, nil
+		},
+		GetHostsFn: func(ctx context.Context, scanID string) ([]models.NmapHost, error) {
+			return []models.NmapHost{{HostID: uuid.New(), Host: "10.0.0.1"}}, nil
+		},
+	}
+
+	truncated := false
+	var created []widgets.WidgetDashboardScan
+	dashboardRepo := &postgres_testing.MockDashboardRepository{
+		TruncateDashboardFn: func(ctx context.Context) error {
+			truncated = true
+			return nil
+		},
+		CreateDashboardScansFn: func(ctx context.Context, rows []widgets.WidgetDashboardScan) error {
+			created = append(created, rows...)
+			return nil
+		},
+	}
+
+	start := time.Now()
+	assert.NoError(t, BuildDashboardScans(context.Background(), nmapRepo, dashboardRepo, 7))
+
+	// Every page of the scans of the last days is rebuilt, not only the first one
+	assert.True(t, truncated)
+	assert.Len(t, created, 4)
+	if !assert.Len(t, searched, 3) {
+		return
+	}
+	assert.Empty(t, searched[0].Cursor)
+	assert.Equal(t, pages[1][0].ScanID.String(), searched[1].Cursor)
+	assert.Equal(t, pages[2][0].ScanID.String(), searched[2].Cursor)
+
+	if assert.Len(t, searched[0].Search, 1) && assert.NotNil(t, searched[0].Search[0].Scalar) {
+		since := searched[0].Search[0].Scalar
+		assert.Equal(t, "scan_start", since.Parameter)
+		assert.Equal(t, models.OpGt, since.Operator)
+		assert.WithinDuration(t, start.AddDate(0, 0, -7), since.Value.(time.Time), time.Minute)
+	}
+}
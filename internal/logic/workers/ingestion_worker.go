@@ -0,0 +1,150 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/masscan"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/nmap"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/config"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/db"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+)
+
+const INGESTION_WORKER_NAME = "ingestion-worker"
+
+// IngestionWorker imports the uploads queued as ingestion jobs, one at a time
+type IngestionWorker struct {
+	config   *config.WorkerConfig
+	provider repositories.RepositoryProvider
+	ticker   *time.Ticker
+	done     chan bool
+}
+
+// NewIngestionWorker creates a new ingestion worker instance
+func NewIngestionWorker(workerConfig *config.WorkerConfig) (*IngestionWorker, error) {
+	// Initialize database
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		workerConfig.DBConfig.Username,
+		workerConfig.DBConfig.Password,
+		workerConfig.DBConfig.Host,
+		workerConfig.DBConfig.Port,
+		workerConfig.DBConfig.Database,
+	)
+	provider, err := db.InitDB(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return &IngestionWorker{
+		config:   workerConfig,
+		provider: provider,
+		ticker:   time.NewTicker(workerConfig.JobFrequency),
+		done:     make(chan bool),
+	}, nil
+}
+
+// Start begins the worker's polling loop
+func (w *IngestionWorker) Start(ctx context.Context) {
+	log.Printf("[%s] Starting worker with frequency: %v", INGESTION_WORKER_NAME, w.config.JobFrequency)
+	go func() {
+		for {
+			select {
+			case <-w.ticker.C:
+				w.runQueuedJobs(ctx)
+			case <-w.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop gracefully shuts down the worker, once the running job (if any) is over
+func (w *IngestionWorker) Stop() {
+	log.Printf("[%s] Stopping worker", INGESTION_WORKER_NAME)
+	w.ticker.Stop()
+	w.done <- true
+}
+
+// runQueuedJobs runs jobs until none is queued
+func (w *IngestionWorker) runQueuedJobs(ctx context.Context) {
+	jobRepo := w.provider.GetRepository(repositories.JOB_REPOSITORY).(repositories.JobRepository)
+	nmapRepo := w.provider.GetRepository(repositories.NMAP_REPOSITORY).(repositories.NmapRepository)
+
+	for ctx.Err() == nil {
+		job, err := jobRepo.ClaimJob(ctx)
+		if errors.As(err, &shiryoku_errors.NotFoundError{}) {
+			return
+		}
+		if err != nil {
+			log.Printf("[%s] Error claiming a job: %v", INGESTION_WORKER_NAME, err)
+			return
+		}
+
+		start := time.Now()
+		log.Printf("[%s] Running %s job %s (attempt %d)", INGESTION_WORKER_NAME, job.Tool, job.JobID, job.Attempts)
+
+		stopHeartbeat := w.heartbeat(ctx, jobRepo, job.JobID.String())
+		switch {
+		case job.Attempts > models.MAX_JOB_ATTEMPTS:
+			err = failJob(ctx, jobRepo, job, fmt.Sprintf("the worker stopped while running the job %d times", models.MAX_JOB_ATTEMPTS))
+		case job.Tool == nmap.JOB_TOOL:
+			err = nmap.RunImportJob(ctx, job, jobRepo, nmapRepo)
+		case job.Tool == masscan.JOB_TOOL:
+			err = masscan.RunImportJob(ctx, job, jobRepo, nmapRepo)
+		default:
+			err = failJob(ctx, jobRepo, job, fmt.Sprintf("unknown tool %s", job.Tool))
+		}
+		stopHeartbeat()
+		if err != nil {
+			log.Printf("[%s] Error running job %s: %v", INGESTION_WORKER_NAME, job.JobID, err)
+			continue
+		}
+		log.Printf("[%s] Job %s %s in %v", INGESTION_WORKER_NAME, job.JobID, job.Status, time.Since(start))
+	}
+}
+
+// heartbeat saves the heartbeat of a running job until stopped, so that it isn't claimed again meanwhile
+func (w *IngestionWorker) heartbeat(ctx context.Context, jobRepo repositories.JobRepository, jobID string) (stop func()) {
+	ticker := time.NewTicker(models.JOB_HEARTBEAT_INTERVAL)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := jobRepo.HeartbeatJob(ctx, jobID); err != nil {
+					log.Printf("[%s] %v", INGESTION_WORKER_NAME, err)
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// failJob saves a job failed without running it, deleting its stored upload
+func failJob(ctx context.Context, jobRepo repositories.JobRepository, job *models.IngestionJob, reason string) error {
+	job.Status = string(models.JOB_STATUS_FAILED)
+	job.Error = reason
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+
+	if err := os.RemoveAll(job.StoragePath); err != nil {
+		log.Printf("[%s] Failed to delete upload of job %s: %v", INGESTION_WORKER_NAME, job.JobID, err)
+	}
+	return jobRepo.FinishJob(ctx, job)
+}
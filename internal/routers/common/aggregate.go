@@ -0,0 +1,49 @@
+package common
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// Aggregate is a generic Gin handler factory for any AggregatableRepository[T].
+// It takes the same search specs as Search, and counts the matching results by group.
+func Aggregate[T any](repo repositories.AggregatableRepository[T], allowedMaps ...map[string]utils.FieldTypeInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params models.AggregationParams
+
+		if err := c.ShouldBindJSON(&params); err != nil {
+			utils.ParseJSONError(c, err)
+			return
+		}
+
+		if !utils.ValidateAndRespond(c, &params, utils.AggregationSchema) {
+			return
+		}
+
+		params.SetDefaults()
+
+		// Filters of the query string must match too
+		specs, ok := utils.ParseQueryAndRespond(c, allowedMaps...)
+		if !ok {
+			return
+		}
+		params.Search = append(params.Search, specs...)
+
+		// Validate all parameters exist in allowed maps and types match
+		if err := utils.ValidateAggregationParams(&params, allowedMaps...); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := common.Aggregate[T](c.Request.Context(), repo, &params)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, result)
+	}
+}
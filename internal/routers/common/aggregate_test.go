@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAggregateRouter counts hosts by group with aggregate
+func setupAggregateRouter(aggregate repositories.AggregateFunc[models.NmapHost]) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+	r.POST("/aggregate", Aggregate[models.NmapHost](aggregate, utils.NmapHostFields, utils.NmapHostRelationFields))
+	return r
+}
+
+// emptyAggregate finds nothing
+func emptyAggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+}
+
+func TestAggregate(t *testing.T) {
+	var received *models.AggregationParams
+	router := setupAggregateRouter(func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+		received = params
+		return &models.AggregationResult{
+			Total: 5,
+			Buckets: []models.AggregationBucket{
+				{Key: map[string]any{"os_name": "Linux 5.X", "first_seen": "2024-01-01T00:00:00Z"}, Count: 3},
+				{Key: map[string]any{"os_name": "Windows Server 2019", "first_seen": "2024-01-01T00:00:00Z"}, Count: 2},
+			},
+		}, nil
+	})
+
+	w := postSearch(router, "/aggregate", `{
+		"search": [{"parameter": "host_status", "operator": "eq", "value": "up"}],
+		"group_by": ["os_name", "first_seen"],
+		"interval": "day"
+	}`)
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	if assert.NotNil(t, received) {
+		assert.Equal(t, []string{"os_name", "first_seen"}, received.GroupBy)
+		assert.Equal(t, models.IntervalDay, received.Interval)
+		assert.Len(t, received.Search, 1)
+		// Default number of buckets
+		assert.Equal(t, uint64(models.DEFAULT_RESULTS_PER_PAGE), received.Limit)
+	}
+
+	var result models.AggregationResult
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, uint64(5), result.Total)
+	if assert.Len(t, result.Buckets, 2) {
+		assert.Equal(t, "Linux 5.X", result.Buckets[0].Key["os_name"])
+		assert.Equal(t, uint64(3), result.Buckets[0].Count)
+	}
+}
+
+func TestAggregateValidation(t *testing.T) {
+	router := setupAggregateRouter(emptyAggregate)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Hosts per OS",
+			payload:        `{"group_by": ["os_name"], "limit": 20}`,
+			expectedStatus: 200,
+			description:    "Should accept a single group without interval",
+		},
+		{
+			name:           "Top open ports",
+			payload:        `{"group_by": ["scan_results.port"], "search": [{"parameter": "scan_results.port_state", "operator": "eq", "value": "open"}], "limit": 20}`,
+			expectedStatus: 200,
+			description:    "Should accept groups on fields of relations",
+		},
+		{
+			name:           "Missing group_by",
+			payload:        `{"search": [{"parameter": "host_status", "operator": "eq", "value": "up"}]}`,
+			expectedStatus: 422,
+			description:    "Should require at least one group",
+		},
+		{
+			name:           "Unknown group",
+			payload:        `{"group_by": ["scan_args"]}`,
+			expectedStatus: 400,
+			description:    "Should reject fields of other models",
+		},
+		{
+			name:           "Array group",
+			payload:        `{"group_by": ["hostnames"]}`,
+			expectedStatus: 400,
+			description:    "Should reject groups on arrays",
+		},
+		{
+			name:           "Relation group",
+			payload:        `{"group_by": ["scan_results"]}`,
+			expectedStatus: 400,
+			description:    "Should reject groups on relations themselves",
+		},
+		{
+			name:           "Invalid interval",
+			payload:        `{"group_by": ["first_seen"], "interval": "month"}`,
+			expectedStatus: 400,
+			description:    "Should reject unknown intervals",
+		},
+		{
+			name:           "Interval without time field",
+			payload:        `{"group_by": ["os_name"], "interval": "hour"}`,
+			expectedStatus: 400,
+			description:    "Should reject intervals without any time field to truncate",
+		},
+		{
+			name:           "Invalid search",
+			payload:        `{"group_by": ["os_name"], "search": [{"parameter": "os_name", "operator": "eq", "value": 42}]}`,
+			expectedStatus: 400,
+			description:    "Should validate search specs like searches",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postSearch(router, "/aggregate", tc.payload)
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// Export is a generic Gin handler factory for any SearchableRepository[T].
+// It takes the same search as Search, and streams every matching result as a file (?format=csv|ndjson|xlsx).
+func Export[T any](repo repositories.SearchableRepository[T], filename string, allowedMaps ...map[string]utils.FieldTypeInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := models.ExportFormat(c.DefaultQuery("format", string(models.ExportCSV)))
+		if !format.IsValid() {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid export format: %s", format)})
+			return
+		}
+
+		params, ok := bindSearchParams(c, allowedMaps...)
+		if !ok {
+			return
+		}
+
+		w := &exportResponse{c: c, format: format, filename: filename}
+		if err := common.Export[T](c.Request.Context(), repo, params, format, w); err != nil {
+			// Once the file started, its status was sent already
+			if !w.started {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+			_ = c.Error(err)
+		}
+	}
+}
+
+// exportResponse sends the headers of the file with its first bytes, so that errors before can still be answered as JSON
+type exportResponse struct {
+	c        *gin.Context
+	format   models.ExportFormat
+	filename string
+	started  bool
+}
+
+func (e *exportResponse) Write(data []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.c.Header("Content-Type", e.format.ContentType())
+		e.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename+"."+string(e.format)))
+		e.c.Status(200)
+	}
+	return e.c.Writer.Write(data)
+}
+
+func (e *exportResponse) Flush() {
+	if e.started {
+		e.c.Writer.Flush()
+	}
+}
@@ -0,0 +1,192 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupExportRouter exports httpx results, found by search
+func setupExportRouter(search repositories.SearchFunc[models.HttpxResult]) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+	r.POST("/export", Export[models.HttpxResult](search, "httpx_results", utils.HttpxResultFields))
+	return r
+}
+
+func TestExport(t *testing.T) {
+	var pages []models.SearchParams
+	router := setupExportRouter(func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+		pages = append(pages, *params)
+		// A full page, then the last one
+		if params.Cursor == "" {
+			results := make([]models.HttpxResult, params.PerPage)
+			for i := range results {
+				results[i] = models.HttpxResult{URL: fmt.Sprintf("https://10.0.0.1/%d", i), StatusCode: 200}
+			}
+			return &models.SearchResult[models.HttpxResult]{Results: results, NextCursor: "next"}, nil
+		}
+		return &models.SearchResult[models.HttpxResult]{Results: []models.HttpxResult{
+			{URL: "https://10.0.0.2", StatusCode: 302, Title: "=HYPERLINK(\"http://evil\")", TLSSubjectAN: []string{"a.example.com", "b.example.com"}},
+		}}, nil
+	})
+
+	payload := `{"parameters": ["url", "status_code", "title", "tls_subject_an"], "per_page": 10}`
+	req, _ := http.NewRequest("POST", "/export?format=csv", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="httpx_results.csv"`, w.Header().Get("Content-Disposition"))
+
+	// Every page is read, whatever the page size of the search
+	if assert.Len(t, pages, 2) {
+		assert.Equal(t, uint64(models.MAX_RESULTS_PER_PAGE), pages[0].PerPage)
+		assert.Equal(t, models.CountNone, pages[0].Count)
+		assert.Equal(t, "next", pages[1].Cursor)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, models.MAX_RESULTS_PER_PAGE+2) {
+		assert.Equal(t, []string{"url", "status_code", "title", "tls_subject_an"}, records[0])
+		assert.Equal(t, []string{"https://10.0.0.1/0", "200", "", ""}, records[1])
+		// Formulas are escaped, and arrays joined
+		assert.Equal(t, []string{"https://10.0.0.2", "302", "'=HYPERLINK(\"http://evil\")", "a.example.com, b.example.com"}, records[len(records)-1])
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	router := setupExportRouter(func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+		return &models.SearchResult[models.HttpxResult]{Results: []models.HttpxResult{
+			{URL: "https://10.0.0.1", StatusCode: 200, Title: "Intranet & Login"},
+		}}, nil
+	})
+
+	export := func(format string, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/export?format="+format, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("NDJSON", func(t *testing.T) {
+		w := export("ndjson", `{"parameters": ["url", "title"]}`)
+
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"url": "https://10.0.0.1", "title": "Intranet & Login"}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("CSV of every field", func(t *testing.T) {
+		w := export("csv", `{}`)
+
+		assert.Equal(t, 200, w.Code)
+		records, err := csv.NewReader(w.Body).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, records, 2) {
+			assert.Contains(t, records[0], "httpx_result_id")
+			assert.Contains(t, records[0], "timestamp")
+			// Not serialized
+			assert.NotContains(t, records[0], "CreatedAt")
+			assert.Len(t, records[1], len(records[0]))
+		}
+	})
+
+	t.Run("XLSX", func(t *testing.T) {
+		w := export("xlsx", `{"parameters": ["url", "status_code", "title"]}`)
+
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, `attachment; filename="httpx_results.xlsx"`, w.Header().Get("Content-Disposition"))
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if !assert.NoError(t, err) {
+			return
+		}
+		files := make(map[string]string)
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			assert.NoError(t, err)
+			content, _ := io.ReadAll(reader)
+			files[file.Name] = string(content)
+		}
+		assert.Contains(t, files, "[Content_Types].xml")
+		assert.Contains(t, files, "xl/workbook.xml")
+		sheet := files["xl/worksheets/sheet1.xml"]
+		assert.Contains(t, sheet, `<c r="C1" t="inlineStr"><is><t xml:space="preserve">title</t></is></c>`)
+		// Numbers are numbers, text is escaped
+		assert.Contains(t, sheet, `<c r="B2"><v>200</v></c>`)
+		assert.Contains(t, sheet, `Intranet &amp; Login`)
+	})
+}
+
+func TestExportErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		payload        string
+		err            error
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Unknown format",
+			query:          "?format=pdf",
+			payload:        `{}`,
+			expectedStatus: 400,
+			description:    "Should reject formats that can't be exported",
+		},
+		{
+			name:           "Invalid search",
+			query:          "?format=csv",
+			payload:        `{"parameters": ["scan_args"]}`,
+			expectedStatus: 400,
+			description:    "Should validate the search like search does",
+		},
+		{
+			name:           "Search failure",
+			query:          "",
+			payload:        `{}`,
+			err:            errors.New("connection refused"),
+			expectedStatus: 500,
+			description:    "Should answer errors before the file starts",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupExportRouter(func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+				if tc.err != nil {
+					return nil, tc.err
+				}
+				return &models.SearchResult[models.HttpxResult]{Results: []models.HttpxResult{}}, nil
+			})
+
+			req, _ := http.NewRequest("POST", "/export"+tc.query, bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json", tc.description)
+		})
+	}
+}
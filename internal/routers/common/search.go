@@ -12,32 +12,69 @@ import (
 // It validates field names + types, and maps to actual DB columns.
 func Search[T any](repo repositories.SearchableRepository[T], allowedMaps ...map[string]utils.FieldTypeInfo) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var params models.SearchParams
-
-		if err := c.ShouldBindJSON(&params); err != nil {
-			utils.ParseJSONError(c, err)
+		params, ok := bindSearchParams(c, allowedMaps...)
+		if !ok {
 			return
 		}
 
-		if !utils.ValidateAndRespond(c, &params, utils.SearchSchema) {
-			return
-		}
-
-		params.SetDefaults()
+		SearchAndRespond(c, repo, params)
+	}
+}
 
-		// Validate all parameters exist in allowed maps and types match
-		if err := utils.ValidateSearchParamTypesPrecomputed(&params, allowedMaps...); err != nil {
-			c.JSON(400, gin.H{"error": err.Error()})
-			return
-		}
+// SearchAndRespond runs a validated search, and answers its results
+// Only the columns of its parameters, if any
+func SearchAndRespond[T any](c *gin.Context, repo repositories.SearchableRepository[T], params *models.SearchParams) {
+	result, err := common.Search[T](c.Request.Context(), repo, params)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 
-		// Execute the search
-		result, err := common.Search[T](c.Request.Context(), repo, &params)
+	// Only the selected columns
+	if len(params.Parameters) > 0 {
+		projected, err := common.Project(result, params.Parameters)
 		if err != nil {
 			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(200, projected)
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// bindSearchParams binds and validates the search of a request, its body and its query string (?q=)
+// Invalid searches are answered, and ok is false
+func bindSearchParams(c *gin.Context, allowedMaps ...map[string]utils.FieldTypeInfo) (*models.SearchParams, bool) {
+	var params models.SearchParams
+
+	// The body is optional with a query string (?q=port:443)
+	if c.Query("q") == "" || c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			utils.ParseJSONError(c, err)
+			return nil, false
+		}
+	}
 
-		c.JSON(200, result)
+	if !utils.ValidateAndRespond(c, &params, utils.SearchSchema) {
+		return nil, false
 	}
+
+	params.SetDefaults()
+
+	// Filters of the query string must match too
+	specs, ok := utils.ParseQueryAndRespond(c, allowedMaps...)
+	if !ok {
+		return nil, false
+	}
+	params.Search = append(params.Search, specs...)
+
+	// Validate all parameters exist in allowed maps and types match
+	if err := utils.ValidateSearchParamTypesPrecomputed(&params, allowedMaps...); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return &params, true
 }
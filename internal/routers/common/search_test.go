@@ -0,0 +1,312 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupSearchRouter serves the searches of hosts, answered by search
+func setupSearchRouter(search repositories.SearchFunc[models.NmapHost]) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+	r.POST("/search", Search[models.NmapHost](search, utils.NmapHostFields, utils.NmapHostRelationFields))
+	return r
+}
+
+// emptySearch finds nothing
+func emptySearch(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+	return &models.SearchResult[models.NmapHost]{Results: []models.NmapHost{}}, nil
+}
+
+func postSearch(router *gin.Engine, path, payload string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSearchGroups(t *testing.T) {
+	var received *models.SearchParams
+	router := setupSearchRouter(func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+		received = params
+		return emptySearch(ctx, params)
+	})
+
+	w := postSearch(router, "/search", `{
+		"search": [
+			{
+				"or": [
+					{"parameter": "scan_results.port", "operator": "eq", "value": 80},
+					{
+						"and": [
+							{"parameter": "scan_results.port", "operator": "in", "values": [8080, 8443]},
+							{"parameter": "host_status", "operator": "eq", "value": "up"}
+						]
+					}
+				]
+			},
+			{
+				"not": {"parameter": "hostnames", "operator": "any_like", "value": "internal"}
+			}
+		]
+	}`)
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	if assert.NotNil(t, received) && assert.Len(t, received.Search, 2) {
+		or := received.Search[0].Group
+		if assert.NotNil(t, or) && assert.Len(t, or.Specs, 2) {
+			assert.Equal(t, models.OpOr, or.Operator)
+			assert.Equal(t, models.OpAnd, or.Specs[1].Group.Operator)
+			assert.Equal(t, "host_status", or.Specs[1].Group.Specs[1].Scalar.Parameter)
+		}
+		not := received.Search[1].Group
+		if assert.NotNil(t, not) && assert.Len(t, not.Specs, 1) {
+			assert.Equal(t, models.OpNot, not.Operator)
+			assert.Equal(t, "hostnames", not.Specs[0].Scalar.Parameter)
+		}
+	}
+}
+
+func TestSearchGroupErrors(t *testing.T) {
+	router := setupSearchRouter(emptySearch)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Empty group",
+			payload:        `{"search": [{"or": []}]}`,
+			expectedStatus: 400,
+			description:    "Should fail with 400 when a group has no spec",
+		},
+		{
+			name:           "Group with other keys",
+			payload:        `{"search": [{"and": [{"parameter": "host", "operator": "eq", "value": "a"}], "parameter": "host"}]}`,
+			expectedStatus: 400,
+			description:    "Should fail with 400 when a group is mixed with a spec",
+		},
+		{
+			name:           "Not group with a list",
+			payload:        `{"search": [{"not": [{"parameter": "host", "operator": "eq", "value": "a"}]}]}`,
+			expectedStatus: 400,
+			description:    "Should fail with 400 when not is given several specs",
+		},
+		{
+			name:           "Invalid operator in group",
+			payload:        `{"search": [{"or": [{"parameter": "host", "operator": "invalid_op", "value": "a"}]}]}`,
+			expectedStatus: 400,
+			description:    "Should fail with 400 when a nested spec has an unknown operator",
+		},
+		{
+			name:           "Missing value in group",
+			payload:        `{"search": [{"or": [{"not": {"parameter": "host", "operator": "eq"}}]}]}`,
+			expectedStatus: 422,
+			description:    "Should fail validation when a nested spec is missing its value",
+		},
+		{
+			name:           "Invalid parameter in group",
+			payload:        `{"search": [{"and": [{"parameter": "host", "operator": "eq", "value": "a"}, {"parameter": "unknown", "operator": "eq", "value": "x"}]}]}`,
+			expectedStatus: 400,
+			description:    "Should fail with 400 when a nested spec targets an unknown field",
+		},
+		{
+			name:           "Invalid parameter in nested group",
+			payload:        `{"search": [{"or": [{"parameter": "host", "operator": "eq", "value": "a"}, {"not": {"parameter": "unknown", "operator": "eq", "value": "x"}}]}]}`,
+			expectedStatus: 400,
+			description:    "Should validate specs at any depth",
+		},
+		{
+			name:           "Wrong type in nested group",
+			payload:        `{"search": [{"not": {"or": [{"parameter": "os_accuracy", "operator": "gt", "value": "high"}]}}]}`,
+			expectedStatus: 400,
+			description:    "Should check value types at any depth",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postSearch(router, "/search", tc.payload)
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestSearchProjection(t *testing.T) {
+	var received *models.SearchParams
+	router := setupSearchRouter(func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+		received = params
+		// Only the selected columns are read
+		return &models.SearchResult[models.NmapHost]{Total: 2, Count: models.CountExact, Results: []models.NmapHost{
+			{OSName: "Linux 5.X", HostStatus: "up"},
+			{OSName: "Windows Server 2019"},
+		}}, nil
+	})
+
+	w := postSearch(router, "/search", `{"parameters": ["os_name", "host_status"], "distinct": true, "sort": [{"parameter": "os_name", "direction": "asc"}]}`)
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	if assert.NotNil(t, received) {
+		assert.Equal(t, []string{"os_name", "host_status"}, received.Parameters)
+		assert.True(t, received.Distinct)
+	}
+
+	var result models.SearchResult[map[string]any]
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, uint64(2), result.Total)
+	// Only the selected fields, even empty ones
+	assert.Equal(t, []map[string]any{
+		{"os_name": "Linux 5.X", "host_status": "up"},
+		{"os_name": "Windows Server 2019", "host_status": nil},
+	}, result.Results)
+}
+
+func TestSearchProjectionErrors(t *testing.T) {
+	router := setupSearchRouter(emptySearch)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Unknown parameter",
+			payload:        `{"parameters": ["os_name", "scan_args"]}`,
+			expectedStatus: 400,
+			description:    "Should reject parameters of other models",
+		},
+		{
+			name:           "Related parameter",
+			payload:        `{"parameters": ["scan_results.port"]}`,
+			expectedStatus: 400,
+			description:    "Should reject parameters of relations",
+		},
+		{
+			name:           "Distinct without parameters",
+			payload:        `{"distinct": true}`,
+			expectedStatus: 400,
+			description:    "Should reject distinct rows of every column",
+		},
+		{
+			name:           "Distinct sorted by another column",
+			payload:        `{"parameters": ["os_name"], "distinct": true, "sort": [{"parameter": "host", "direction": "asc"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject sorts on columns that aren't selected",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postSearch(router, "/search", tc.payload)
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestSearchCursor(t *testing.T) {
+	cursor, err := (&models.Cursor{Keys: []string{"last_seen", "host_id"}, Values: []any{"2024-05-01T10:00:00Z", uuid.NewString()}}).Encode()
+	assert.NoError(t, err)
+
+	var received *models.SearchParams
+	router := setupSearchRouter(func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+		received = params
+		return &models.SearchResult[models.NmapHost]{Count: params.Count, Results: []models.NmapHost{{Host: "10.0.0.1"}}, NextCursor: "next"}, nil
+	})
+
+	w := postSearch(router, "/search", `{"sort": [{"parameter": "last_seen", "direction": "desc"}], "cursor": "`+cursor+`", "count": "none"}`)
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	if assert.NotNil(t, received) {
+		assert.Equal(t, cursor, received.Cursor)
+		assert.Equal(t, models.CountNone, received.Count)
+	}
+
+	var result models.SearchResult[models.NmapHost]
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, models.CountNone, result.Count)
+	assert.Equal(t, "next", result.NextCursor)
+}
+
+func TestSearchCursorErrors(t *testing.T) {
+	router := setupSearchRouter(emptySearch)
+
+	cursor, err := (&models.Cursor{Keys: []string{"last_seen", "host_id"}, Values: []any{"2024-05-01T10:00:00Z", uuid.NewString()}}).Encode()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Invalid count",
+			payload:        `{"count": "approximate"}`,
+			expectedStatus: 400,
+			description:    "Should reject unknown count modes",
+		},
+		{
+			name:           "Estimated count",
+			payload:        `{"count": "estimated"}`,
+			expectedStatus: 200,
+			description:    "Should accept estimated counts",
+		},
+		{
+			name:           "Invalid cursor",
+			payload:        `{"cursor": "not a cursor"}`,
+			expectedStatus: 400,
+			description:    "Should reject cursors that weren't returned by a search",
+		},
+		{
+			name:           "Cursor of another sort",
+			payload:        `{"sort": [{"parameter": "host", "direction": "asc"}], "cursor": "` + cursor + `"}`,
+			expectedStatus: 400,
+			description:    "Should reject cursors of another order",
+		},
+		{
+			name:           "Cursor and page",
+			payload:        `{"sort": [{"parameter": "last_seen", "direction": "desc"}], "cursor": "` + cursor + `", "page": 3}`,
+			expectedStatus: 400,
+			description:    "Should reject cursors with pages",
+		},
+		{
+			name:           "Cursor and distinct",
+			payload:        `{"parameters": ["last_seen"], "distinct": true, "sort": [{"parameter": "last_seen", "direction": "desc"}], "cursor": "` + cursor + `"}`,
+			expectedStatus: 400,
+			description:    "Should reject cursors over unique combinations",
+		},
+		{
+			name:           "Unknown sort parameter",
+			payload:        `{"sort": [{"parameter": "scan_args", "direction": "desc"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject sorts on fields of other models",
+		},
+		{
+			name:           "Invalid sort direction",
+			payload:        `{"sort": [{"parameter": "last_seen", "direction": "down"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject unknown directions",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postSearch(router, "/search", tc.payload)
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"errors"
+
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetJob returns the status of an ingestion job: queued / running / done / failed,
+// the number of hosts and ports processed so far, and the outcome of every file once over
+func GetJob(jobRepo repositories.JobRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		jobID := c.Param("id")
+		if _, err := uuid.Parse(jobID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid job ID"})
+			return
+		}
+
+		job, err := jobRepo.GetJob(c.Request.Context(), jobID)
+		if errors.As(err, &shiryoku_errors.NotFoundError{}) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, job)
+	}
+}
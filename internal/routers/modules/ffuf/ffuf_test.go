@@ -0,0 +1,136 @@
+package ffuf
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(ffufRepo *postgres_testing.MockFfufRepository, nmapRepo *postgres_testing.MockNmapRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &FfufModule{ffufRepo: ffufRepo, nmapRepo: nmapRepo}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			ffuf_group := modules_group.Group("/ffuf")
+			ffuf_group.POST("/search", module.searchFfufResults())
+			ffuf_group.POST("/batch", module.insertFfufResults())
+		}
+	}
+	return r
+}
+
+func TestSearchFfufResults(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockFfufRepository{}, &postgres_testing.MockNmapRepository{})
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name: "Status and URL",
+			payload: `{"search": [
+				{"parameter": "status", "operator": "eq", "value": 200},
+				{"parameter": "url", "operator": "like", "value": "admin"}
+			]}`,
+			expectedStatus: 200,
+			description:    "Should accept status eq 200 and url like admin",
+		},
+		{
+			name:           "Status as string",
+			payload:        `{"search": [{"parameter": "status", "operator": "eq", "value": "200"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject strings for numeric fields",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/modules/ffuf/search", bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestInsertFfufResults(t *testing.T) {
+	hostID := uuid.New()
+
+	var inserted []models.FfufResult
+	ffufRepo := &postgres_testing.MockFfufRepository{
+		InsertResultsFn: func(ctx context.Context, results []models.FfufResult) error {
+			inserted = results
+			return nil
+		},
+	}
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		FindHostByAddressFn: func(ctx context.Context, project, address string) (*models.NmapHost, error) {
+			assert.Equal(t, "intranet.corp.local", address)
+			return &models.NmapHost{HostID: hostID, Host: "10.0.0.1"}, nil
+		},
+	}
+	router := setupRouter(ffufRepo, nmapRepo)
+
+	payload := `{
+		"commandline": "ffuf -u https://intranet.corp.local:8443/FUZZ -w words.txt -of json -o out.json",
+		"time": "2024-01-01T10:00:00+01:00",
+		"results": [
+			{"input": {"FFUFHASH": "a1b2", "FUZZ": "admin"}, "position": 1, "status": 301, "length": 0, "words": 1, "lines": 1, "content-type": "", "redirectlocation": "/admin/", "scraper": {}, "duration": 12000000, "resultfile": "", "url": "https://intranet.corp.local:8443/admin", "host": "intranet.corp.local:8443"},
+			{"input": {"FFUFHASH": "a1b3", "FUZZ": "login"}, "position": 2, "status": 200, "length": 4096, "words": 120, "lines": 30, "content-type": "text/html", "redirectlocation": "", "scraper": {}, "duration": 8000000, "resultfile": "", "url": "https://intranet.corp.local:8443/login", "host": "intranet.corp.local:8443"}
+		],
+		"config": {"url": "https://intranet.corp.local:8443/FUZZ", "method": "GET"}
+	}`
+
+	req, _ := http.NewRequest("POST", "/api/modules/ffuf/batch", bytes.NewBufferString(payload))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code)
+	if assert.Len(t, inserted, 2) {
+		hit := inserted[0]
+		assert.Equal(t, "https://intranet.corp.local:8443/admin", hit.URL)
+		assert.Equal(t, "intranet.corp.local", hit.Host)
+		assert.Equal(t, uint16(8443), hit.Port)
+		assert.Equal(t, map[string]string{"FUZZ": "admin"}, hit.Input)
+		assert.Equal(t, 301, hit.Status)
+		assert.Equal(t, "/admin/", hit.RedirectLocation)
+		assert.Equal(t, int64(12), hit.Duration)
+		assert.Equal(t, &hostID, hit.HostID)
+		// Port never scanned by nmap
+		assert.Nil(t, hit.ServiceID)
+
+		assert.Equal(t, 200, inserted[1].Status)
+		assert.Equal(t, 4096, inserted[1].Length)
+		assert.False(t, inserted[1].Timestamp.IsZero())
+	}
+}
+
+func TestInsertFfufResultsInvalidJSON(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockFfufRepository{}, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/ffuf/batch", bytes.NewBufferString(`{"results": [`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,29 @@
+package ffuf
+
+import (
+	internal_ffuf "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/ffuf"
+	"github.com/gin-gonic/gin"
+)
+
+// insertFfufResults inserts hits from an `ffuf -of json` output
+// Hits are linked to the hosts of the project given with ?project= (none by default)
+func (m *FfufModule) insertFfufResults() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		output, err := internal_ffuf.ParseJSON(c.Request.Body)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		count, err := internal_ffuf.SaveFfufResults(c.Request.Context(), output, c.Query("project"), m.ffufRepo, m.nmapRepo)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(201, gin.H{
+			"count":   count,
+			"message": "ffuf results inserted successfully",
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package ffuf
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+type FfufModule struct {
+	ffufRepo repositories.FfufRepository
+	nmapRepo repositories.NmapRepository
+}
+
+func (m *FfufModule) Name() string {
+	return "ffuf"
+}
+
+func (m *FfufModule) Description() string {
+	return "ffuf discovered web paths"
+}
+
+func (m *FfufModule) SetupRoutes(ffuf_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.FFUF_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.FFUF_REPOSITORY)
+	}
+
+	ffufRepo, ok := repo.(repositories.FfufRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an FfufRepository", repositories.FFUF_REPOSITORY)
+	}
+
+	// Hits are linked to nmap hosts and services
+	repo = provider.GetRepository(repositories.NMAP_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.NMAP_REPOSITORY)
+	}
+
+	nmapRepo, ok := repo.(repositories.NmapRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an NmapRepository", repositories.NMAP_REPOSITORY)
+	}
+
+	m.ffufRepo = ffufRepo
+	m.nmapRepo = nmapRepo
+
+	search_group := ffuf_group.Group("/search")
+	search_group.POST("", m.searchFfufResults())
+	ffuf_group.POST("/aggregate", m.aggregateFfufResults())
+	ffuf_group.POST("/export", m.exportFfufResults())
+	ffuf_group.POST("/batch", m.insertFfufResults())
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package ffuf
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// searchFfufResults returns a handler for searching ffuf hits
+func (m *FfufModule) searchFfufResults() gin.HandlerFunc {
+	return common.Search(m.ffufRepo, utils.FfufResultFields)
+}
+
+// aggregateFfufResults returns a handler for counting ffuf hits by group
+func (m *FfufModule) aggregateFfufResults() gin.HandlerFunc {
+	return common.Aggregate[models.FfufResult](m.ffufRepo, utils.FfufResultFields)
+}
+
+// exportFfufResults returns a handler for exporting ffuf hits
+func (m *FfufModule) exportFfufResults() gin.HandlerFunc {
+	return common.Export(m.ffufRepo, "ffuf_results", utils.FfufResultFields)
+}
@@ -0,0 +1,60 @@
+package hosts
+
+import (
+	"errors"
+
+	internal_hosts "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/hosts"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// getHost returns a host with its open ports, OS guesses and host scripts
+func (m *HostsModule) getHost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hostID := c.Param("id")
+		if _, err := uuid.Parse(hostID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid host ID"})
+			return
+		}
+
+		detail, err := internal_hosts.GetHostDetail(c.Request.Context(), m.hostRepo, hostID)
+		if !respondHostError(c, err) {
+			return
+		}
+
+		c.JSON(200, detail)
+	}
+}
+
+// getHostTimeline returns the changes of the ports of a host, scan by scan
+func (m *HostsModule) getHostTimeline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hostID := c.Param("id")
+		if _, err := uuid.Parse(hostID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid host ID"})
+			return
+		}
+
+		timeline, err := internal_hosts.GetHostTimeline(c.Request.Context(), m.hostRepo, hostID)
+		if !respondHostError(c, err) {
+			return
+		}
+
+		c.JSON(200, timeline)
+	}
+}
+
+// respondHostError answers 404 for unknown hosts, 500 for other errors
+// ok is false if there is an error
+func respondHostError(c *gin.Context, err error) bool {
+	if errors.As(err, &shiryoku_errors.NotFoundError{}) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
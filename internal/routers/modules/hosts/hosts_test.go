@@ -0,0 +1,249 @@
+package hosts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(hostRepo *postgres_testing.MockHostRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &HostsModule{hostRepo: hostRepo}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			hosts_group := modules_group.Group("/hosts")
+			hosts_group.POST("/search", module.searchHosts())
+			hosts_group.GET("/:id", module.getHost())
+			hosts_group.GET("/:id/timeline", module.getHostTimeline())
+		}
+	}
+	return r
+}
+
+// hostHistoryRepo is a host seen by 4 scans:
+//  1. 22/tcp (OpenSSH 7) and 80/tcp open
+//  2. 22/tcp open with OpenSSH 8, 80/tcp filtered, 443/tcp open
+//  3. OS guesses only
+//  4. 22/tcp open, 443/tcp not reported
+func hostHistoryRepo(host models.NmapHost) *postgres_testing.MockHostRepository {
+	ssh7 := &models.Service{ServiceID: uuid.New(), ServiceName: "ssh", ServiceProduct: "OpenSSH", ServiceVersion: "7", Protocol: "tcp"}
+	ssh8 := &models.Service{ServiceID: uuid.New(), ServiceName: "ssh", ServiceProduct: "OpenSSH", ServiceVersion: "8", Protocol: "tcp"}
+	web := &models.Service{ServiceID: uuid.New(), ServiceName: "http", Protocol: "tcp"}
+	https := &models.Service{ServiceID: uuid.New(), ServiceName: "https", Protocol: "tcp"}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scans := make([]models.NmapScan, 4)
+	for i := range scans {
+		scans[i] = models.NmapScan{ScanID: uuid.New(), ScanStart: start.AddDate(0, 0, i), Scanner: "nmap"}
+	}
+
+	result := func(scan int, port uint16, state string, service *models.Service) models.HostScanResult {
+		return models.HostScanResult{
+			ScanResult: models.ScanResult{
+				ScanResultID: uuid.New(),
+				ScanID:       scans[scan].ScanID,
+				HostID:       host.HostID,
+				ServiceID:    service.ServiceID,
+				Port:         port,
+				PortState:    state,
+			},
+			ScanStart: scans[scan].ScanStart,
+			Scanner:   "nmap",
+			Service:   service,
+		}
+	}
+
+	history := []models.HostScanResult{
+		result(0, 22, "open", ssh7),
+		result(0, 80, "open", web),
+		result(1, 22, "open", ssh8),
+		result(1, 80, "filtered", web),
+		result(1, 443, "open", https),
+		result(3, 22, "open", ssh8),
+	}
+
+	return &postgres_testing.MockHostRepository{
+		GetHostFn: func(ctx context.Context, hostID string) (*models.NmapHost, error) {
+			return &host, nil
+		},
+		GetHostScansFn: func(ctx context.Context, hostID string) ([]models.NmapScan, error) {
+			return scans, nil
+		},
+		GetHostHistoryFn: func(ctx context.Context, hostID string) ([]models.HostScanResult, error) {
+			return history, nil
+		},
+	}
+}
+
+func get(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSearchHosts(t *testing.T) {
+	var received *models.SearchParams
+	router := setupRouter(&postgres_testing.MockHostRepository{
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapHost], error) {
+			received = params
+			return &models.SearchResult[models.NmapHost]{Results: []models.NmapHost{}}, nil
+		},
+	})
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Search by hostname",
+			payload:        `{"search": [{"parameter": "hostnames", "operator": "any_like", "value": "mail"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept host fields",
+		},
+		{
+			name:           "Search by port",
+			payload:        `{"search": [{"parameter": "scan_results.port", "operator": "eq", "value": 22}]}`,
+			expectedStatus: 200,
+			description:    "Should accept fields of the scan results of hosts",
+		},
+		{
+			name:           "Search by OS guess",
+			payload:        `{"search": [{"parameter": "os_guesses.family", "operator": "eq", "value": "Linux"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept fields of the OS guesses of hosts",
+		},
+		{
+			name:           "Unknown relation",
+			payload:        `{"search": [{"parameter": "hosts.host", "operator": "eq", "value": "10.0.0.1"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject fields of scans only",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/modules/hosts/search", bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+
+	req, _ := http.NewRequest("POST", "/api/modules/hosts/search?q=service:ssh", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	if assert.NotNil(t, received) && assert.Len(t, received.Search, 1) {
+		assert.Equal(t, "service.service_name", received.Search[0].Scalar.Parameter, "IVRE aliases should resolve to relations")
+	}
+}
+
+func TestGetHost(t *testing.T) {
+	host := models.NmapHost{
+		HostID:    uuid.New(),
+		Host:      "10.0.0.1",
+		Hostnames: []string{"mail.example.com"},
+		OSGuesses: []models.NmapOSGuess{{Name: "Linux 5.0 - 5.14", Accuracy: 98}},
+	}
+	router := setupRouter(hostHistoryRepo(host))
+
+	w := get(router, "/api/modules/hosts/"+host.HostID.String())
+	assert.Equal(t, 200, w.Code)
+
+	var detail models.HostDetail
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, host.HostID, detail.HostID)
+	assert.Equal(t, []string{"mail.example.com"}, []string(detail.Hostnames))
+	assert.Len(t, detail.OSGuesses, 1)
+
+	// 80 was filtered, 443 wasn't reported by the latest scan with ports
+	if assert.Len(t, detail.OpenPorts, 1) {
+		assert.Equal(t, uint16(22), detail.OpenPorts[0].Port)
+		assert.Equal(t, "8", detail.OpenPorts[0].Service.ServiceVersion)
+	}
+}
+
+func TestGetHostTimeline(t *testing.T) {
+	host := models.NmapHost{HostID: uuid.New(), Host: "10.0.0.1"}
+	router := setupRouter(hostHistoryRepo(host))
+
+	w := get(router, "/api/modules/hosts/"+host.HostID.String()+"/timeline")
+	assert.Equal(t, 200, w.Code)
+
+	var timeline models.HostTimeline
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &timeline))
+	assert.Equal(t, host.HostID, timeline.HostID)
+	if !assert.Len(t, timeline.Scans, 4) {
+		return
+	}
+
+	events := func(scan models.HostTimelineScan) []string {
+		var events []string
+		for _, change := range scan.Changes {
+			events = append(events, change.Protocol+"/"+string(change.Event)+"/"+change.State)
+		}
+		return events
+	}
+
+	assert.Equal(t, []string{"22/tcp", "80/tcp"}, timeline.Scans[0].OpenPorts)
+	assert.Equal(t, []string{"tcp/opened/open", "tcp/opened/open"}, events(timeline.Scans[0]))
+
+	assert.Equal(t, []string{"22/tcp", "443/tcp"}, timeline.Scans[1].OpenPorts)
+	if assert.Len(t, timeline.Scans[1].Changes, 3) {
+		assert.Equal(t, uint16(22), timeline.Scans[1].Changes[0].Port)
+		assert.Equal(t, models.PORT_EVENT_SERVICE_CHANGED, timeline.Scans[1].Changes[0].Event)
+		assert.Equal(t, "8", timeline.Scans[1].Changes[0].Service.ServiceVersion)
+		assert.Equal(t, uint16(80), timeline.Scans[1].Changes[1].Port)
+		assert.Equal(t, models.PORT_EVENT_CLOSED, timeline.Scans[1].Changes[1].Event)
+		assert.Equal(t, "filtered", timeline.Scans[1].Changes[1].State)
+		assert.Equal(t, uint16(443), timeline.Scans[1].Changes[2].Port)
+		assert.Equal(t, models.PORT_EVENT_OPENED, timeline.Scans[1].Changes[2].Event)
+	}
+
+	// Without ports, a scan doesn't close any
+	assert.Equal(t, []string{"22/tcp", "443/tcp"}, timeline.Scans[2].OpenPorts)
+	assert.Empty(t, timeline.Scans[2].Changes)
+
+	assert.Equal(t, []string{"22/tcp"}, timeline.Scans[3].OpenPorts)
+	if assert.Len(t, timeline.Scans[3].Changes, 1) {
+		assert.Equal(t, uint16(443), timeline.Scans[3].Changes[0].Port)
+		assert.Equal(t, models.PORT_EVENT_CLOSED, timeline.Scans[3].Changes[0].Event)
+		assert.Empty(t, timeline.Scans[3].Changes[0].State, "Ports not reported have no state")
+	}
+}
+
+func TestGetHostErrors(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockHostRepository{})
+
+	for _, path := range []string{"/api/modules/hosts/not-a-uuid", "/api/modules/hosts/not-a-uuid/timeline"} {
+		assert.Equal(t, 400, get(router, path).Code, path)
+	}
+
+	unknown := uuid.New().String()
+	for _, path := range []string{"/api/modules/hosts/" + unknown, "/api/modules/hosts/" + unknown + "/timeline"} {
+		assert.Equal(t, 404, get(router, path).Code, path)
+	}
+}
@@ -0,0 +1,43 @@
+package hosts
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+type HostsModule struct {
+	hostRepo repositories.HostRepository
+}
+
+func (m *HostsModule) Name() string {
+	return "hosts"
+}
+
+func (m *HostsModule) Description() string {
+	return "Hosts across nmap scans"
+}
+
+func (m *HostsModule) SetupRoutes(hosts_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.HOST_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.HOST_REPOSITORY)
+	}
+
+	hostRepo, ok := repo.(repositories.HostRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a HostRepository", repositories.HOST_REPOSITORY)
+	}
+
+	m.hostRepo = hostRepo
+
+	search_group := hosts_group.Group("/search")
+	search_group.POST("", m.searchHosts())
+	hosts_group.POST("/aggregate", m.aggregateHosts())
+	hosts_group.POST("/export", m.exportHosts())
+	hosts_group.GET("/:id", m.getHost())
+	hosts_group.GET("/:id/timeline", m.getHostTimeline())
+
+	return nil
+}
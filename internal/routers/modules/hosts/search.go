@@ -0,0 +1,23 @@
+package hosts
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// searchHosts returns a handler for searching hosts, by fields of any of their scans
+func (m *HostsModule) searchHosts() gin.HandlerFunc {
+	return common.Search(m.hostRepo, utils.NmapHostFields, utils.NmapHostRelationFields)
+}
+
+// aggregateHosts returns a handler for counting hosts by group, or by fields of their scans (e.g. "scan_results.port")
+func (m *HostsModule) aggregateHosts() gin.HandlerFunc {
+	return common.Aggregate[models.NmapHost](m.hostRepo, utils.NmapHostFields, utils.NmapHostRelationFields)
+}
+
+// exportHosts returns a handler for exporting hosts
+func (m *HostsModule) exportHosts() gin.HandlerFunc {
+	return common.Export(m.hostRepo, "hosts", utils.NmapHostFields, utils.NmapHostRelationFields)
+}
@@ -0,0 +1,270 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(httpxRepo *postgres_testing.MockHttpxRepository, nmapRepo *postgres_testing.MockNmapRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &HttpxModule{httpxRepo: httpxRepo, nmapRepo: nmapRepo}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			httpx_group := modules_group.Group("/httpx")
+			httpx_group.POST("/search", module.searchHttpxResults())
+			httpx_group.POST("/export", module.exportHttpxResults())
+			httpx_group.POST("/batch", module.insertHttpxResults())
+		}
+	}
+	return r
+}
+
+func TestSearchHttpxResults(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockHttpxRepository{}, &postgres_testing.MockNmapRepository{})
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Search by title",
+			payload:        `{"search": [{"parameter": "title", "operator": "like", "value": "login"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept title searches",
+		},
+		{
+			name:           "Search by tech",
+			payload:        `{"search": [{"parameter": "tech", "operator": "like", "value": "WordPress"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept tech searches",
+		},
+		{
+			name:           "Search by favicon hash",
+			payload:        `{"search": [{"parameter": "favicon_hash", "operator": "in", "values": ["-1234", "116323821"]}]}`,
+			expectedStatus: 200,
+			description:    "Should accept favicon hash searches",
+		},
+		{
+			name:           "Wrong type",
+			payload:        `{"search": [{"parameter": "title", "operator": "eq", "value": 200}]}`,
+			expectedStatus: 400,
+			description:    "Should reject numbers for string fields",
+		},
+		{
+			name:           "Search by network",
+			payload:        `{"search": [{"parameter": "host", "operator": "in_cidr", "value": "10.20.0.0/16"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept CIDR searches on addresses",
+		},
+		{
+			name:           "Search by IPv6 network",
+			payload:        `{"search": [{"parameter": "host", "operator": "not_in_cidr", "value": "2001:db8::/32"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept IPv6 prefixes",
+		},
+		{
+			name:           "Search by IP range",
+			payload:        `{"search": [{"parameter": "host", "operator": "ip_range", "value": "10.0.0.1-10.0.0.254"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept IP ranges",
+		},
+		{
+			name:           "Invalid CIDR",
+			payload:        `{"search": [{"parameter": "host", "operator": "in_cidr", "value": "10.20.0.0/33"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject invalid prefixes",
+		},
+		{
+			name:           "Invalid IP range",
+			payload:        `{"search": [{"parameter": "host", "operator": "ip_range", "value": "10.0.0.254-10.0.0.1"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject ranges ending before they start",
+		},
+		{
+			name:           "Mixed IP range",
+			payload:        `{"search": [{"parameter": "host", "operator": "ip_range", "value": "10.0.0.1-2001:db8::1"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject ranges mixing IPv4 and IPv6",
+		},
+		{
+			name:           "CIDR on a non-address field",
+			payload:        `{"search": [{"parameter": "title", "operator": "in_cidr", "value": "10.20.0.0/16"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject network operators on other fields",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/modules/httpx/search", bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestSearchHttpxResultsQuery(t *testing.T) {
+	var received *models.SearchParams
+	httpxRepo := &postgres_testing.MockHttpxRepository{
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+			received = params
+			return &models.SearchResult[models.HttpxResult]{}, nil
+		},
+	}
+	router := setupRouter(httpxRepo, &postgres_testing.MockNmapRepository{})
+
+	tests := []struct {
+		name           string
+		query          string
+		payload        string
+		expectedStatus int
+		expectedSpecs  int
+		description    string
+	}{
+		{
+			name:           "Httpx fields",
+			query:          `port:443,8443 host:10.0.0.0/8 !title:*login*`,
+			expectedStatus: 200,
+			expectedSpecs:  3,
+			description:    "Should compile the query with httpx fields",
+		},
+		{
+			name:           "With a body",
+			query:          `port:443`,
+			payload:        `{"search": [{"parameter": "title", "operator": "like", "value": "login"}], "per_page": 10}`,
+			expectedStatus: 200,
+			expectedSpecs:  2,
+			description:    "Should add the query to the body's search",
+		},
+		{
+			name:           "Unknown field",
+			query:          `port:443 scan_args:-sV`,
+			expectedStatus: 400,
+			description:    "Should reject fields from other modules",
+		},
+		{
+			name:           "Invalid network",
+			query:          `host:10.0.0.0/33`,
+			expectedStatus: 400,
+			description:    "Should validate compiled specs as JSON ones",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			received = nil
+			query := url.Values{"q": {tc.query}}
+			req, _ := http.NewRequest("POST", "/api/modules/httpx/search?"+query.Encode(), bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+			if tc.expectedSpecs != 0 && assert.NotNil(t, received) {
+				assert.Len(t, received.Search, tc.expectedSpecs, tc.description)
+			}
+		})
+	}
+}
+
+func TestExportHttpxResults(t *testing.T) {
+	httpxRepo := &postgres_testing.MockHttpxRepository{
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+			return &models.SearchResult[models.HttpxResult]{Results: []models.HttpxResult{{URL: "https://10.0.0.1", StatusCode: 200}}}, nil
+		},
+	}
+	router := setupRouter(httpxRepo, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/httpx/export?format=csv", bytes.NewBufferString(`{"parameters": ["url", "status_code"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `attachment; filename="httpx_results.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "url,status_code\nhttps://10.0.0.1,200\n", w.Body.String())
+}
+
+func TestInsertHttpxResults(t *testing.T) {
+	hostID := uuid.New()
+	serviceID := uuid.New()
+
+	var inserted []models.HttpxResult
+	httpxRepo := &postgres_testing.MockHttpxRepository{
+		InsertResultsFn: func(ctx context.Context, results []models.HttpxResult) error {
+			inserted = results
+			return nil
+		},
+	}
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		FindHostByAddressFn: func(ctx context.Context, project, address string) (*models.NmapHost, error) {
+			assert.Equal(t, "10.0.0.1", address)
+			return &models.NmapHost{HostID: hostID, Host: address}, nil
+		},
+		FindLatestScanResultFn: func(ctx context.Context, id string, port uint16) (*models.ScanResult, error) {
+			assert.Equal(t, uint16(443), port)
+			return &models.ScanResult{HostID: hostID, ServiceID: serviceID, Port: port}, nil
+		},
+	}
+	router := setupRouter(httpxRepo, nmapRepo)
+
+	payload := `{"timestamp":"2024-01-01T10:00:00Z","hash":{"body_md5":"d41d8cd98f00b204e9800998ecf8427e","body_mmh3":"-1840324437","body_sha256":"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855","header_md5":"0123"},"port":"443","url":"https://intranet.corp.local","input":"intranet.corp.local","title":"Intranet - Login","scheme":"https","webserver":"nginx/1.18.0","content_type":"text/html","method":"GET","host":"10.0.0.1","path":"/","favicon":"116323821","a":["10.0.0.1"],"tech":["Nginx:1.18.0","WordPress"],"words":120,"lines":30,"status_code":200,"content_length":4096,"failed":false,"tls":{"subject_dn":"CN=intranet.corp.local","subject_cn":"intranet.corp.local","subject_an":["intranet.corp.local","www.corp.local"],"issuer_dn":"CN=Corp CA","fingerprint_hash":{"sha256":"abcd"}}}
+{"timestamp":"2024-01-01T10:00:01Z","url":"http://down.corp.local","input":"down.corp.local","failed":true}
+`
+
+	req, _ := http.NewRequest("POST", "/api/modules/httpx/batch", bytes.NewBufferString(payload))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code)
+	// Failed probes are skipped
+	if assert.Len(t, inserted, 1) {
+		result := inserted[0]
+		assert.Equal(t, &hostID, result.HostID)
+		assert.Equal(t, &serviceID, result.ServiceID)
+		assert.Equal(t, models.IPAddress("10.0.0.1"), result.Host)
+		assert.Equal(t, uint16(443), result.Port)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, "Intranet - Login", result.Title)
+		assert.Equal(t, "nginx/1.18.0", result.WebServer)
+		assert.Equal(t, "Nginx:1.18.0,WordPress", result.Tech)
+		assert.Equal(t, "116323821", result.FaviconHash)
+		assert.Equal(t, "-1840324437", result.BodyMMH3)
+		assert.Equal(t, "CN=intranet.corp.local", result.TLSSubjectDN)
+		assert.Equal(t, []string{"intranet.corp.local", "www.corp.local"}, []string(result.TLSSubjectAN))
+	}
+}
+
+func TestInsertHttpxResultsInvalidJSONL(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockHttpxRepository{}, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/httpx/batch", bytes.NewBufferString(`{"url": "http://a", "port": "http"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,32 @@
+package httpx
+
+import (
+	"fmt"
+
+	internal_httpx "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/httpx"
+	"github.com/gin-gonic/gin"
+)
+
+// insertHttpxResults inserts results from an `httpx -json` output
+// Results are linked to the hosts of the project given with ?project= (none by default)
+func (m *HttpxModule) insertHttpxResults() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		// One JSON document per line
+		results, err := internal_httpx.ParseJSONL(c.Request.Body)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid JSONL format: %v", err)})
+			return
+		}
+
+		count, err := internal_httpx.SaveHttpxResults(c.Request.Context(), results, c.Query("project"), m.httpxRepo, m.nmapRepo)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(201, gin.H{
+			"count":   count,
+			"message": "httpx results inserted successfully",
+		})
+	}
+}
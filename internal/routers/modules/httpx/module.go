@@ -0,0 +1,55 @@
+package httpx
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+type HttpxModule struct {
+	httpxRepo repositories.HttpxRepository
+	nmapRepo  repositories.NmapRepository
+}
+
+func (m *HttpxModule) Name() string {
+	return "httpx"
+}
+
+func (m *HttpxModule) Description() string {
+	return "httpx HTTP metadata"
+}
+
+func (m *HttpxModule) SetupRoutes(httpx_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.HTTPX_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.HTTPX_REPOSITORY)
+	}
+
+	httpxRepo, ok := repo.(repositories.HttpxRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an HttpxRepository", repositories.HTTPX_REPOSITORY)
+	}
+
+	// Results are attached to nmap hosts and services
+	repo = provider.GetRepository(repositories.NMAP_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.NMAP_REPOSITORY)
+	}
+
+	nmapRepo, ok := repo.(repositories.NmapRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an NmapRepository", repositories.NMAP_REPOSITORY)
+	}
+
+	m.httpxRepo = httpxRepo
+	m.nmapRepo = nmapRepo
+
+	search_group := httpx_group.Group("/search")
+	search_group.POST("", m.searchHttpxResults())
+	httpx_group.POST("/aggregate", m.aggregateHttpxResults())
+	httpx_group.POST("/export", m.exportHttpxResults())
+	httpx_group.POST("/batch", m.insertHttpxResults())
+
+	return nil
+}
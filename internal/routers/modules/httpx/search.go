@@ -0,0 +1,23 @@
+package httpx
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// searchHttpxResults returns a handler for searching httpx results
+func (m *HttpxModule) searchHttpxResults() gin.HandlerFunc {
+	return common.Search(m.httpxRepo, utils.HttpxResultFields)
+}
+
+// aggregateHttpxResults returns a handler for counting httpx results by group
+func (m *HttpxModule) aggregateHttpxResults() gin.HandlerFunc {
+	return common.Aggregate[models.HttpxResult](m.httpxRepo, utils.HttpxResultFields)
+}
+
+// exportHttpxResults returns a handler for exporting httpx results
+func (m *HttpxModule) exportHttpxResults() gin.HandlerFunc {
+	return common.Export(m.httpxRepo, "httpx_results", utils.HttpxResultFields)
+}
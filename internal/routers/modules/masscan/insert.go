@@ -0,0 +1,63 @@
+package masscan
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	internal_masscan "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/masscan"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// insertMasscanScan queues masscan outputs (-oX, -oJ or -oL) to be imported by a worker
+// Accepts a raw body, or a multipart upload of files, gzipped files and tar(.gz)/zip archives
+// The format is detected, unless given with ?format=xml|json|list
+// The upload is stored and a job created: its status, progress and the outcome of every file are at /api/jobs/{id}
+// Hosts can be scoped to a project with ?project=, re-uploading the same output returns the existing scan
+// Uploads bigger than MAX_UPLOAD_SIZE are rejected with a 413
+func (m *MasscanModule) insertMasscanScan() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		format := internal_masscan.Format(c.Query("format"))
+		if format != "" && !format.IsValid() {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid format: %s", format)})
+			return
+		}
+
+		job := models.IngestionJob{
+			JobID:   uuid.New(),
+			Tool:    internal_masscan.JOB_TOOL,
+			Format:  string(format),
+			Project: c.Query("project"),
+			Status:  string(models.JOB_STATUS_QUEUED),
+		}
+		job.StoragePath = filepath.Join(m.uploadDir, job.JobID.String())
+
+		if err := utils.SaveUploadedFiles(c, job.StoragePath, m.maxUploadSize); err != nil {
+			os.RemoveAll(job.StoragePath)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(413, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := m.jobRepo.CreateJob(c.Request.Context(), &job); err != nil {
+			os.RemoveAll(job.StoragePath)
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(202, gin.H{
+			"job_id":  job.JobID,
+			"status":  job.Status,
+			"message": "masscan scans queued for import",
+		})
+	}
+}
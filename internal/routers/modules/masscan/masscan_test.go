@@ -0,0 +1,314 @@
+package masscan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	internal_masscan "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/masscan"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/jobs"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const masscanXML = `<?xml version="1.0"?>
+<!-- masscan v1.0 scan -->
+<nmaprun scanner="masscan" start="1700000000" version="1.0-BETA"  xmloutputversion="1.03">
+<scaninfo type="syn" protocol="tcp" />
+<host endtime="1700000001"><address addr="10.0.0.1" addrtype="ipv4"/><ports><port protocol="tcp" portid="80"><state state="open" reason="syn-ack" reason_ttl="64"/></port></ports></host>
+<host endtime="1700000002"><address addr="10.0.0.1" addrtype="ipv4"/><ports><port protocol="tcp" portid="22"><state state="open" reason="syn-ack" reason_ttl="64"/></port></ports></host>
+<host endtime="1700000003"><address addr="10.0.0.1" addrtype="ipv4"/><ports><port protocol="tcp" portid="80"><state state="open" reason="response" reason_ttl="64"/><service name="title" banner="Welcome"></service></port></ports></host>
+<host endtime="1700000004"><address addr="10.0.0.1" addrtype="ipv4"/><ports><port protocol="tcp" portid="80"><state state="open" reason="response" reason_ttl="64"/><service name="http" banner="HTTP/1.1 200 OK"></service></port></ports></host>
+<host endtime="1700000005"><address addr="10.0.0.2" addrtype="ipv4"/><ports><port protocol="tcp" portid="22"><state state="open" reason="response" reason_ttl="64"/><service name="ssh" banner="SSH-2.0-OpenSSH_8.9"></service></port></ports></host>
+<runstats><finished time="1700000010" timestr="2023-11-14 22:13:30" elapsed="10" /><hosts up="2" down="0" total="2" /></runstats>
+</nmaprun>
+`
+
+const masscanJSON = `[
+{   "ip": "10.0.0.1",   "timestamp": "1700000001", "ports": [ {"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64} ] }
+,
+{   "ip": "10.0.0.1",   "timestamp": "1700000002", "ports": [ {"port": 22, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64} ] }
+,
+{   "ip": "10.0.0.1",   "timestamp": "1700000003", "ports": [ {"port": 80, "proto": "tcp", "service": {"name": "title", "banner": "Welcome"} } ] }
+,
+{   "ip": "10.0.0.1",   "timestamp": "1700000004", "ports": [ {"port": 80, "proto": "tcp", "service": {"name": "http", "banner": "HTTP/1.1 200 OK"} } ] }
+,
+{   "ip": "10.0.0.2",   "timestamp": "1700000005", "ports": [ {"port": 22, "proto": "tcp", "service": {"name": "ssh", "banner": "SSH-2.0-OpenSSH_8.9"} } ] }
+]
+`
+
+const masscanList = `#masscan
+open tcp 80 10.0.0.1 1700000001
+open tcp 22 10.0.0.1 1700000002
+banner tcp 80 10.0.0.1 1700000003 title Welcome
+banner tcp 80 10.0.0.1 1700000004 http HTTP/1.1 200 OK
+banner tcp 22 10.0.0.2 1700000005 ssh SSH-2.0-OpenSSH_8.9
+# end
+`
+
+// inserted keeps what the handler sent to the repository
+type inserted struct {
+	scans    []models.NmapScan
+	hosts    []models.NmapHost
+	services []models.Service
+	results  []models.ScanResult
+	scripts  []models.NmapScriptResult
+}
+
+// Enough for every test upload
+const testMaxUploadSize = 1 << 20
+
+func setupRouter(t *testing.T) (*gin.Engine, *inserted) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	data := &inserted{}
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertScanFn: func(ctx context.Context, scan *models.NmapScan) error {
+			data.scans = append(data.scans, *scan)
+			return nil
+		},
+		InsertHostsFn: func(ctx context.Context, hosts []models.NmapHost) error {
+			data.hosts = append(data.hosts, hosts...)
+			return nil
+		},
+		GetOrCreateServiceFn: func(ctx context.Context, service *models.Service) (*models.Service, error) {
+			created := *service
+			created.ServiceID = uuid.New()
+			data.services = append(data.services, created)
+			return &created, nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, results []models.ScanResult) error {
+			data.results = append(data.results, results...)
+			return nil
+		},
+		InsertScriptsFn: func(ctx context.Context, scripts []models.NmapScriptResult) error {
+			data.scripts = append(data.scripts, scripts...)
+			return nil
+		},
+	}
+
+	// Jobs are run as soon as they are queued, in place of the worker
+	jobsByID := make(map[string]models.IngestionJob)
+	jobRepo := &postgres_testing.MockJobRepository{}
+	jobRepo.CreateJobFn = func(ctx context.Context, job *models.IngestionJob) error {
+		jobsByID[job.JobID.String()] = *job
+		claimed := *job
+		claimed.Status = string(models.JOB_STATUS_RUNNING)
+		return internal_masscan.RunImportJob(ctx, &claimed, jobRepo, mockRepo)
+	}
+	jobRepo.FinishJobFn = func(ctx context.Context, job *models.IngestionJob) error {
+		jobsByID[job.JobID.String()] = *job
+		return nil
+	}
+	jobRepo.GetJobFn = func(ctx context.Context, jobID string) (*models.IngestionJob, error) {
+		job, exists := jobsByID[jobID]
+		if !exists {
+			return nil, shiryoku_errors.NotFoundError{Resource: "job", ID: jobID}
+		}
+		return &job, nil
+	}
+
+	module := &MasscanModule{nmapRepo: mockRepo, jobRepo: jobRepo, uploadDir: t.TempDir(), maxUploadSize: testMaxUploadSize}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			masscan_group := modules_group.Group("/masscan")
+			masscan_group.POST("/batch", module.insertMasscanScan())
+		}
+		api_group.GET("/jobs/:id", jobs.GetJob(jobRepo))
+	}
+	return r, data
+}
+
+// importScans uploads masscan outputs, and returns the job that imported them
+func importScans(t *testing.T, router *gin.Engine, req *http.Request) models.IngestionJob {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !assert.Equal(t, 202, w.Code, w.Body.String()) {
+		return models.IngestionJob{}
+	}
+
+	var queued struct {
+		JobID string `json:"job_id"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/jobs/"+queued.JobID, nil))
+	assert.Equal(t, 200, w.Code, w.Body.String())
+
+	var job models.IngestionJob
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	return job
+}
+
+func TestInsertMasscanScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		payload string
+		// Only XML has a start time, otherwise the first record is used
+		start int64
+	}{
+		{name: "XML output", payload: masscanXML, start: 1700000000},
+		{name: "JSON output", payload: masscanJSON, start: 1700000001},
+		{name: "List output", payload: masscanList, start: 1700000001},
+		{name: "Explicit format", query: "?format=list", payload: masscanList, start: 1700000001},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, data := setupRouter(t)
+
+			req, _ := http.NewRequest("POST", "/api/modules/masscan/batch"+tc.query, bytes.NewBufferString(tc.payload))
+			job := importScans(t, router, req)
+			assert.Equal(t, string(models.JOB_STATUS_DONE), job.Status, job.Error)
+			assert.Equal(t, int64(2), job.HostsProcessed)
+			assert.Equal(t, int64(3), job.PortsProcessed)
+
+			if assert.Len(t, data.scans, 1) {
+				assert.Equal(t, "masscan", data.scans[0].Scanner)
+				assert.Equal(t, tc.start, data.scans[0].ScanStart.Unix())
+			}
+
+			// One host per IP, one result per port
+			assert.Len(t, data.hosts, 2)
+			if !assert.Len(t, data.results, 3) {
+				return
+			}
+
+			servicesByID := make(map[uuid.UUID]models.Service)
+			for _, service := range data.services {
+				servicesByID[service.ServiceID] = service
+			}
+
+			// 10.0.0.1:80 -> http, 10.0.0.1:22 -> unknown, 10.0.0.2:22 -> ssh
+			expected := []struct {
+				host    string
+				port    uint16
+				service string
+				scripts []string
+			}{
+				{"10.0.0.1", 80, "http", []string{"http-title", "banner"}},
+				{"10.0.0.1", 22, "", nil},
+				{"10.0.0.2", 22, "ssh", []string{"banner"}},
+			}
+			hostsByID := make(map[uuid.UUID]string)
+			for _, host := range data.hosts {
+				hostsByID[host.HostID] = host.Host
+			}
+
+			for i, exp := range expected {
+				result := data.results[i]
+				assert.Equal(t, exp.host, hostsByID[result.HostID])
+				assert.Equal(t, exp.port, result.Port)
+				assert.Equal(t, "open", result.PortState)
+				assert.Equal(t, exp.service, servicesByID[result.ServiceID].ServiceName)
+				assert.Equal(t, "tcp", servicesByID[result.ServiceID].Protocol)
+
+				var scripts []string
+				for _, script := range data.scripts {
+					if script.ScanResultID != nil && *script.ScanResultID == result.ScanResultID {
+						scripts = append(scripts, script.ScriptID)
+					}
+				}
+				assert.Equal(t, exp.scripts, scripts)
+			}
+		})
+	}
+}
+
+func TestInsertMasscanScanUpload(t *testing.T) {
+	router, data := setupRouter(t)
+
+	// Several outputs at once, compressed or not
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(masscanJSON))
+	gz.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("files", "scan.xml")
+	part.Write([]byte(masscanXML))
+	part, _ = writer.CreateFormFile("files", "scan.json.gz")
+	part.Write(compressed.Bytes())
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/api/modules/masscan/batch", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	job := importScans(t, router, req)
+
+	assert.Equal(t, string(models.JOB_STATUS_DONE), job.Status, job.Error)
+	if assert.Len(t, job.Files, 2) {
+		for _, file := range job.Files {
+			assert.Empty(t, file.Error, file.File)
+			assert.NotEmpty(t, file.ScanID, file.File)
+		}
+	}
+	assert.Len(t, data.scans, 2)
+}
+
+func TestInsertMasscanScanTooLarge(t *testing.T) {
+	router, data := setupRouter(t)
+
+	req, _ := http.NewRequest("POST", "/api/modules/masscan/batch", bytes.NewReader(make([]byte, testMaxUploadSize+1)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 413, w.Code, w.Body.String())
+	assert.Empty(t, data.scans)
+}
+
+func TestInsertMasscanScanInvalidFormat(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	req, _ := http.NewRequest("POST", "/api/modules/masscan/batch?format=csv", bytes.NewBufferString(masscanList))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestInsertMasscanScanErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		payload string
+	}{
+		{name: "Empty body", payload: "  \n"},
+		{name: "Broken list", payload: "open tcp 80\n"},
+		{name: "Broken JSON", payload: "[\n{\"ip\": \n]"},
+		{name: "Wrong explicit format", query: "?format=xml", payload: masscanList},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, data := setupRouter(t)
+
+			// Outputs are parsed by the job: the upload is accepted, its file fails
+			req, _ := http.NewRequest("POST", "/api/modules/masscan/batch"+tc.query, bytes.NewBufferString(tc.payload))
+			job := importScans(t, router, req)
+
+			assert.Equal(t, string(models.JOB_STATUS_FAILED), job.Status)
+			if assert.Len(t, job.Files, 1) {
+				assert.NotEmpty(t, job.Files[0].Error)
+			}
+			assert.Empty(t, data.scans)
+		})
+	}
+}
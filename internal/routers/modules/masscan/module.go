@@ -0,0 +1,59 @@
+package masscan
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/config"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// MasscanModule imports masscan outputs into the nmap tables
+// Searching is done through the nmap module
+type MasscanModule struct {
+	nmapRepo repositories.NmapRepository
+	jobRepo  repositories.JobRepository
+	// Where uploads are kept until imported
+	uploadDir     string
+	maxUploadSize int64
+}
+
+func (m *MasscanModule) Name() string {
+	return "masscan"
+}
+
+func (m *MasscanModule) Description() string {
+	return "Masscan imports (stored as nmap scans)"
+}
+
+func (m *MasscanModule) SetupRoutes(masscan_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.NMAP_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.NMAP_REPOSITORY)
+	}
+
+	nmapRepo, ok := repo.(repositories.NmapRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an NmapRepository", repositories.NMAP_REPOSITORY)
+	}
+
+	// Uploads are imported by a worker, through jobs
+	repo = provider.GetRepository(repositories.JOB_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.JOB_REPOSITORY)
+	}
+
+	jobRepo, ok := repo.(repositories.JobRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a JobRepository", repositories.JOB_REPOSITORY)
+	}
+
+	m.nmapRepo = nmapRepo
+	m.jobRepo = jobRepo
+	m.uploadDir = config.GetUploadDir()
+	m.maxUploadSize = config.GetMaxUploadSize()
+
+	masscan_group.POST("/batch", m.insertMasscanScan())
+
+	return nil
+}
@@ -1,45 +1,54 @@
 package nmap
 
 import (
-	"encoding/xml"
-	"fmt"
-	"io"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
 
 	internal_nmap "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/nmap"
-	"github.com/Ullaakut/nmap/v4"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// InsertNmapScans inserts multiple nmap scans
+// InsertNmapScans queues nmap scans to be imported by a worker
+// Accepts a raw XML body, or a multipart upload of XML files, gzipped files and tar(.gz)/zip archives
+// The upload is stored and a job created: its status, progress and the outcome of every file are at /api/jobs/{id}
+// Hosts can be scoped to a project with ?project=, re-uploading the same XML returns the existing scan
+// Uploads bigger than MAX_UPLOAD_SIZE are rejected with a 413
 func (m *NmapModule) insertNmapScans() func(c *gin.Context) {
 	return func(c *gin.Context) {
-		var nmapResults *nmap.Run
-
-		// Read raw XML body from the request
-		body, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read request body: %v", err)})
-			return
+		job := models.IngestionJob{
+			JobID:   uuid.New(),
+			Tool:    internal_nmap.JOB_TOOL,
+			Project: c.Query("project"),
+			Status:  string(models.JOB_STATUS_QUEUED),
 		}
+		job.StoragePath = filepath.Join(m.uploadDir, job.JobID.String())
 
-		// Unmarshal XML data into nmap.Run struct
-		err = xml.Unmarshal(body, &nmapResults)
-		if err != nil {
-			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid XML format: %v", err)})
+		if err := utils.SaveUploadedFiles(c, job.StoragePath, m.maxUploadSize); err != nil {
+			os.RemoveAll(job.StoragePath)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(413, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(400, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Continue as before, now you have `nmapResults` unmarshalled from XML
-		ids, err := internal_nmap.SaveNmapScans(c.Request.Context(), nmapResults, m.nmapRepo)
-		if err != nil {
+		if err := m.jobRepo.CreateJob(c.Request.Context(), &job); err != nil {
+			os.RemoveAll(job.StoragePath)
 			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(201, gin.H{
-			"ids":     ids,
-			"count":   len(ids),
-			"message": "nmap scans inserted successfully",
+		c.JSON(202, gin.H{
+			"job_id":  job.JobID,
+			"status":  job.Status,
+			"message": "nmap scans queued for import",
 		})
 	}
 }
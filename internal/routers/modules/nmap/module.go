@@ -3,12 +3,20 @@ package nmap
 import (
 	"fmt"
 
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/config"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
 	"github.com/gin-gonic/gin"
 )
 
 type NmapModule struct {
 	nmapRepo repositories.NmapRepository
+	jobRepo  repositories.JobRepository
+	// Rows of deleted or reprocessed scans
+	dashboardRepo postgres.DashboardRepository
+	// Where uploads are kept until imported
+	uploadDir     string
+	maxUploadSize int64
 }
 
 func (m *NmapModule) Name() string {
@@ -30,11 +38,43 @@ func (m *NmapModule) SetupRoutes(nmap_group *gin.RouterGroup, provider repositor
 		return fmt.Errorf("repository %s is not an NmapRepository", repositories.NMAP_REPOSITORY)
 	}
 
+	// Uploads are imported by a worker, through jobs
+	repo = provider.GetRepository(repositories.JOB_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.JOB_REPOSITORY)
+	}
+
+	jobRepo, ok := repo.(repositories.JobRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a JobRepository", repositories.JOB_REPOSITORY)
+	}
+
+	repo = provider.GetRepository(repositories.DASHBOARD_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.DASHBOARD_REPOSITORY)
+	}
+
+	dashboardRepo, ok := repo.(postgres.DashboardRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a DashboardRepository", repositories.DASHBOARD_REPOSITORY)
+	}
+
 	m.nmapRepo = nmapRepo
+	m.jobRepo = jobRepo
+	m.dashboardRepo = dashboardRepo
+	m.uploadDir = config.GetUploadDir()
+	m.maxUploadSize = config.GetMaxUploadSize()
 
 	search_group := nmap_group.Group("/search")
 	search_group.POST("", m.searchNmapScans())
+	nmap_group.POST("/aggregate", m.aggregateNmapScans())
+	nmap_group.POST("/export", m.exportNmapScans())
 	nmap_group.POST("/batch", m.insertNmapScans())
 
+	scans_group := nmap_group.Group("/scans")
+	scans_group.GET("/:id", m.getNmapScan())
+	scans_group.DELETE("/:id", m.deleteNmapScan())
+	scans_group.POST("/:id/reprocess", m.reprocessNmapScan())
+
 	return nil
 }
@@ -1,36 +1,126 @@
 package nmap
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/core/models/widgets"
 	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	internal_common "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/common"
+	internal_nmap "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/nmap"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/jobs"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupRouter(mockRepo *postgres_testing.MockNmapRepository) *gin.Engine {
+// searchTestFields accepts every parameter used below with any JSON type
+// These tests exercise request parsing and validation, not the nmap column names
+var searchTestFields = func() map[string]utils.FieldTypeInfo {
+	fields := make(map[string]utils.FieldTypeInfo)
+	for _, name := range []string{"hostname", "port", "status", "protocol", "a", "b", "c", "d", "e", "f"} {
+		fields[name] = utils.FieldTypeInfo{JSONName: name, GORMName: name, JSONKind: reflect.Interface}
+	}
+	return fields
+}()
+
+// Enough for every test upload
+const testMaxUploadSize = 1 << 20
+
+func setupRouter(t *testing.T, mockRepo *postgres_testing.MockNmapRepository) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(utils.ErrorRecoveryMiddleware())
 
+	// Jobs are run as soon as they are queued, in place of the worker
+	jobsByID := make(map[string]models.IngestionJob)
+	jobRepo := &postgres_testing.MockJobRepository{}
+	jobRepo.CreateJobFn = func(ctx context.Context, job *models.IngestionJob) error {
+		jobsByID[job.JobID.String()] = *job
+		claimed := *job
+		claimed.Status = string(models.JOB_STATUS_RUNNING)
+		return internal_nmap.RunImportJob(ctx, &claimed, jobRepo, mockRepo)
+	}
+	jobRepo.FinishJobFn = func(ctx context.Context, job *models.IngestionJob) error {
+		jobsByID[job.JobID.String()] = *job
+		return nil
+	}
+	jobRepo.GetJobFn = func(ctx context.Context, jobID string) (*models.IngestionJob, error) {
+		job, exists := jobsByID[jobID]
+		if !exists {
+			return nil, shiryoku_errors.NotFoundError{Resource: "job", ID: jobID}
+		}
+		return &job, nil
+	}
+
+	module := &NmapModule{nmapRepo: mockRepo, jobRepo: jobRepo, uploadDir: t.TempDir(), maxUploadSize: testMaxUploadSize}
+
 	api_group := r.Group("/api")
 	{
 		modules_group := api_group.Group("/modules")
 		{
 			nmap_group := modules_group.Group("/nmap")
-			nmap_group.POST("/search", SearchNmapScans(mockRepo))
+			nmap_group.POST("/search", common.Search[models.NmapScan](mockRepo, searchTestFields))
+			nmap_group.POST("/batch", module.insertNmapScans())
 		}
+		api_group.GET("/jobs/:id", jobs.GetJob(jobRepo))
 	}
 	return r
 }
 
+// importScans uploads scans, and returns the job that imported them
+func importScans(t *testing.T, router *gin.Engine, req *http.Request) models.IngestionJob {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !assert.Equal(t, 202, w.Code, w.Body.String()) {
+		return models.IngestionJob{}
+	}
+
+	var queued struct {
+		JobID string `json:"job_id"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &queued))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/jobs/"+queued.JobID, nil))
+	assert.Equal(t, 200, w.Code, w.Body.String())
+
+	var job models.IngestionJob
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	return job
+}
+
+// assertImported checks that every file of a job was imported, and returns their scan IDs
+func assertImported(t *testing.T, job models.IngestionJob) []string {
+	assert.Equal(t, string(models.JOB_STATUS_DONE), job.Status, job.Error)
+
+	var ids []string
+	for _, file := range job.Files {
+		assert.Empty(t, file.Error, file.File)
+		ids = append(ids, file.ScanID)
+	}
+	return ids
+}
+
 // Helper to make requests
 func makeRequest(router *gin.Engine, payload any) *httptest.ResponseRecorder {
 	body, _ := json.Marshal(payload)
@@ -44,11 +134,11 @@ func makeRequest(router *gin.Engine, payload any) *httptest.ResponseRecorder {
 
 func TestSearchNmapScansSuccess(t *testing.T) {
 	mockRepo := &postgres_testing.MockNmapRepository{
-		SearchFn: func(ctx context.Context, params *models.SearchParams) (uint64, []models.NmapScan, error) {
-			return 0, []models.NmapScan{}, nil
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error) {
+			return &models.SearchResult[models.NmapScan]{Results: []models.NmapScan{}}, nil
 		},
 	}
-	router := setupRouter(mockRepo)
+	router := setupRouter(t, mockRepo)
 
 	tests := []struct {
 		name           string
@@ -143,7 +233,7 @@ func TestSearchNmapScansSuccess(t *testing.T) {
 
 func TestSearchNmapScansValidationErrors(t *testing.T) {
 	mockRepo := &postgres_testing.MockNmapRepository{}
-	router := setupRouter(mockRepo)
+	router := setupRouter(t, mockRepo)
 
 	tests := []struct {
 		name           string
@@ -212,7 +302,7 @@ func TestSearchNmapScansValidationErrors(t *testing.T) {
 
 func TestSearchNmapScansEdgeCases(t *testing.T) {
 	mockRepo := &postgres_testing.MockNmapRepository{}
-	router := setupRouter(mockRepo)
+	router := setupRouter(t, mockRepo)
 
 	tests := []struct {
 		name           string
@@ -321,3 +411,1052 @@ func TestSearchNmapScansEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchNmapScansRelations(t *testing.T) {
+	var received *models.SearchParams
+	mockRepo := &postgres_testing.MockNmapRepository{
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.NmapScan], error) {
+			received = params
+			return &models.SearchResult[models.NmapScan]{Results: []models.NmapScan{}}, nil
+		},
+	}
+	// The module's own fields, not searchTestFields
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/modules/nmap/search", (&NmapModule{nmapRepo: mockRepo}).searchNmapScans())
+
+	tests := []struct {
+		name           string
+		payload        string
+		query          string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name: "Scans with an old OpenSSH",
+			payload: `{"search": [
+				{"parameter": "scan_results.port", "operator": "eq", "value": 22},
+				{"parameter": "service.service_product", "operator": "like", "value": "OpenSSH"},
+				{"parameter": "service.service_version", "operator": "lt", "value": "8"}
+			]}`,
+			expectedStatus: 200,
+			description:    "Should accept fields of scan results and services",
+		},
+		{
+			name:           "Hosts and scripts",
+			payload:        `{"search": [{"parameter": "hosts.ip", "operator": "in_cidr", "value": "10.0.0.0/8"}, {"not": {"parameter": "scripts.id", "operator": "eq", "value": "ssl-cert"}}]}`,
+			expectedStatus: 200,
+			description:    "Should accept fields of hosts and scripts",
+		},
+		{
+			name:           "Query string",
+			query:          `scan_results.port:22 product:*OpenSSH* hosts.hostnames:dc01.corp.local`,
+			expectedStatus: 200,
+			description:    "Should accept dotted fields in query strings",
+		},
+		{
+			name:           "Unknown related field",
+			payload:        `{"search": [{"parameter": "hosts.scan_args", "operator": "eq", "value": "-sV"}]}`,
+			expectedStatus: 400,
+			description:    "Should validate fields against the relation's",
+		},
+		{
+			name:           "Relation of a relation",
+			payload:        `{"search": [{"parameter": "hosts.scan_results", "operator": "eq", "value": 22}]}`,
+			expectedStatus: 400,
+			description:    "Should reject nested relations",
+		},
+		{
+			name:           "Wrong related type",
+			payload:        `{"search": [{"parameter": "hosts.os_accuracy", "operator": "eq", "value": "high"}]}`,
+			expectedStatus: 400,
+			description:    "Should check the type of related fields",
+		},
+		{
+			name:           "Sort by a related field",
+			payload:        `{"sort": [{"parameter": "hosts.host", "direction": "asc"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject sorts on relations",
+		},
+		{
+			name:           "Select a related field",
+			payload:        `{"parameters": ["scan_id", "hosts.host"]}`,
+			expectedStatus: 400,
+			description:    "Should reject projections of relations",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			received = nil
+			target := "/api/modules/nmap/search"
+			if tc.query != "" {
+				target += "?" + url.Values{"q": {tc.query}}.Encode()
+			}
+			req, _ := http.NewRequest("POST", target, bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description+": "+w.Body.String())
+			if tc.expectedStatus == 200 {
+				assert.NotNil(t, received, tc.description)
+			}
+		})
+	}
+}
+
+const nmapScriptsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap -sV -sC 10.0.0.1" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host starttime="1700000000" endtime="1700000010"><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.1" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="443"><state state="open" reason="syn-ack"/><service name="http" product="nginx" tunnel="ssl" method="probed" conf="10"/>
+<script id="http-title" output="Intranet &amp; co"><elem key="title">Intranet &amp; co</elem></script>
+<script id="ssl-cert" output="Subject: commonName=intranet.corp.local">
+<table key="subject"><elem key="commonName">intranet.corp.local</elem></table>
+<table key="extensions"><table><elem key="name">X509v3 Subject Alternative Name</elem><elem key="value">DNS:intranet.corp.local</elem></table></table>
+<elem key="sig_algo">sha256WithRSAEncryption</elem>
+</script>
+</port>
+</ports>
+<hostscript>
+<script id="smb-os-discovery" output="OS: Windows Server 2019"><elem key="os">Windows Server 2019 Standard 17763</elem><elem key="fqdn">dc01.corp.local</elem></script>
+</hostscript>
+</host>
+</nmaprun>
+`
+
+func TestInsertNmapScansScripts(t *testing.T) {
+	var hosts []models.NmapHost
+	var results []models.ScanResult
+	var scripts []models.NmapScriptResult
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertHostsFn: func(ctx context.Context, h []models.NmapHost) error {
+			hosts = append(hosts, h...)
+			return nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, r []models.ScanResult) error {
+			results = append(results, r...)
+			return nil
+		},
+		InsertScriptsFn: func(ctx context.Context, s []models.NmapScriptResult) error {
+			scripts = append(scripts, s...)
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBufferString(nmapScriptsXML))
+	assertImported(t, importScans(t, router, req))
+	if !assert.Len(t, hosts, 1) || !assert.Len(t, results, 1) || !assert.Len(t, scripts, 3) {
+		return
+	}
+
+	scriptsByID := make(map[string]models.NmapScriptResult)
+	for _, script := range scripts {
+		assert.Equal(t, hosts[0].HostID, script.HostID)
+		assert.Equal(t, results[0].ScanID, script.ScanID)
+		scriptsByID[script.ScriptID] = script
+	}
+
+	// Port scripts are linked to their scan result
+	title := scriptsByID["http-title"]
+	if assert.NotNil(t, title.ScanResultID) {
+		assert.Equal(t, results[0].ScanResultID, *title.ScanResultID)
+	}
+	assert.Equal(t, "Intranet & co", title.ScriptOutput)
+	assert.Equal(t, map[string]any{"title": "Intranet & co"}, title.ScriptData)
+
+	cert := scriptsByID["ssl-cert"]
+	assert.Equal(t, map[string]any{
+		"subject": map[string]any{"commonName": "intranet.corp.local"},
+		"extensions": []any{
+			map[string]any{"name": "X509v3 Subject Alternative Name", "value": "DNS:intranet.corp.local"},
+		},
+		"sig_algo": "sha256WithRSAEncryption",
+	}, cert.ScriptData)
+
+	// Host scripts only belong to the host
+	smb := scriptsByID["smb-os-discovery"]
+	assert.Nil(t, smb.ScanResultID)
+	assert.Equal(t, "dc01.corp.local", smb.ScriptData["fqdn"])
+}
+
+const nmapHostsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap -O 10.0.0.1" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host starttime="1700000000" endtime="1700000010"><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.1" addrtype="ipv4"/><address addr="00:11:22:33:44:55" addrtype="mac"/>
+<hostnames><hostname name="dc01.corp.local" type="PTR"/></hostnames>
+<ports><port protocol="tcp" portid="445"><state state="open" reason="syn-ack"/><service name="microsoft-ds"/></port></ports>
+<os>
+<osmatch name="Microsoft Windows Server 2019" accuracy="96" line="1"><osclass type="general purpose" vendor="Microsoft" osfamily="Windows" osgen="2019" accuracy="96"/></osmatch>
+<osmatch name="Microsoft Windows 10 1809" accuracy="92" line="2"><osclass type="general purpose" vendor="Microsoft" osfamily="Windows" osgen="10" accuracy="92"/></osmatch>
+</os>
+</host>
+<host starttime="1700000020" endtime="1700000030"><status state="up" reason="syn-ack"/>
+<address addr="10.0.0.1" addrtype="ipv4"/>
+<hostnames><hostname name="ad.corp.local" type="user"/></hostnames>
+<ports><port protocol="tcp" portid="88"><state state="open" reason="syn-ack"/><service name="kerberos-sec"/></port></ports>
+</host>
+</nmaprun>
+`
+
+func TestInsertNmapScansStableHosts(t *testing.T) {
+	var hosts []models.NmapHost
+	var results []models.ScanResult
+	var guesses []models.NmapOSGuess
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertHostsFn: func(ctx context.Context, h []models.NmapHost) error {
+			hosts = append(hosts, h...)
+			return nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, r []models.ScanResult) error {
+			results = append(results, r...)
+			return nil
+		},
+		InsertOSGuessesFn: func(ctx context.Context, g []models.NmapOSGuess) error {
+			guesses = append(guesses, g...)
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	for _, query := range []string{"", "", "?project=acme"} {
+		req, _ := http.NewRequest("POST", "/api/modules/nmap/batch"+query, bytes.NewBufferString(nmapHostsXML))
+		assertImported(t, importScans(t, router, req))
+	}
+
+	// The same IP twice in a scan is a single host
+	if !assert.Len(t, hosts, 3) || !assert.Len(t, results, 6) {
+		return
+	}
+
+	host := hosts[0]
+	assert.Equal(t, models.NewHostID("10.0.0.1", ""), host.HostID)
+	assert.Equal(t, "10.0.0.1", host.Host)
+	assert.Equal(t, []string{"10.0.0.1", "00:11:22:33:44:55"}, []string(host.Addresses))
+	assert.Equal(t, []string{"dc01.corp.local", "ad.corp.local"}, []string(host.Hostnames))
+	assert.Equal(t, int64(1700000000), host.FirstSeen.Unix())
+	assert.Equal(t, int64(1700000030), host.LastSeen.Unix())
+	assert.Equal(t, "Microsoft Windows Server 2019", host.OSName)
+
+	// Importing the scan again gives the same host, unless scoped to another project
+	assert.Equal(t, host.HostID, hosts[1].HostID)
+	assert.Equal(t, models.NewHostID("10.0.0.1", "acme"), hosts[2].HostID)
+	assert.NotEqual(t, host.HostID, hosts[2].HostID)
+	assert.Equal(t, "acme", hosts[2].Project)
+
+	for _, result := range results[:4] {
+		assert.Equal(t, host.HostID, result.HostID)
+	}
+
+	// Every OS match is kept
+	if assert.Len(t, guesses, 6) {
+		assert.Equal(t, host.HostID, guesses[0].HostID)
+		assert.Equal(t, "Microsoft Windows 10 1809", guesses[1].Name)
+		assert.Equal(t, 92, guesses[1].Accuracy)
+		assert.Equal(t, "Windows", guesses[1].Family)
+		assert.Equal(t, int64(1700000010), guesses[1].SeenAt.Unix())
+	}
+}
+
+const nmapDuplicatedHostXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap -sSU --script smb-os-discovery,smb-protocols 10.0.0.1 dc01.corp.local" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host><status state="up" reason="syn-ack"/><address addr="10.0.0.1" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="445"><state state="open" reason="syn-ack"/><service name="microsoft-ds"/><script id="smb-protocols" output="SMBv2"/></port></ports>
+<hostscript><script id="smb-os-discovery" output="Windows Server 2019"/></hostscript>
+</host>
+<host><status state="up" reason="syn-ack"/><address addr="10.0.0.1" addrtype="ipv4"/>
+<hostnames><hostname name="dc01.corp.local" type="user"/></hostnames>
+<ports>
+<port protocol="tcp" portid="445"><state state="open" reason="syn-ack"/><service name="microsoft-ds" product="Windows Server 2019"/><script id="smb-protocols" output="SMBv2"/></port>
+<port protocol="udp" portid="445"><state state="open|filtered" reason="no-response"/></port>
+</ports>
+<hostscript><script id="smb-os-discovery" output="Windows Server 2019"/></hostscript>
+</host>
+</nmaprun>
+`
+
+func TestInsertNmapScansDuplicatedHost(t *testing.T) {
+	var hosts []models.NmapHost
+	var results []models.ScanResult
+	var scripts []models.NmapScriptResult
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertHostsFn: func(ctx context.Context, h []models.NmapHost) error {
+			hosts = append(hosts, h...)
+			return nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, r []models.ScanResult) error {
+			results = append(results, r...)
+			return nil
+		},
+		InsertScriptsFn: func(ctx context.Context, s []models.NmapScriptResult) error {
+			scripts = append(scripts, s...)
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBufferString(nmapDuplicatedHostXML))
+	assertImported(t, importScans(t, router, req))
+
+	// A port reported by both occurrences of the host is a single result (idx_scan_result_unique), with its scripts
+	if !assert.Len(t, hosts, 1) || !assert.Len(t, results, 2) || !assert.Len(t, scripts, 2) {
+		return
+	}
+	assert.Equal(t, uint16(445), results[0].Port)
+	assert.Equal(t, "open", results[0].PortState)
+	assert.Equal(t, "open|filtered", results[1].PortState)
+
+	for _, script := range scripts {
+		assert.Equal(t, hosts[0].HostID, script.HostID)
+		if script.ScanResultID != nil {
+			assert.Equal(t, results[0].ScanResultID, *script.ScanResultID)
+		}
+	}
+	assert.Equal(t, "smb-protocols", scripts[0].ScriptID)
+	assert.Equal(t, "smb-os-discovery", scripts[1].ScriptID)
+}
+
+const nmapDownHostXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap -Pn 10.0.0.1 10.0.0.2" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host><status state="up" reason="syn-ack"/><address addr="10.0.0.1" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/><service name="ssh"/></port></ports>
+</host>
+<host><status state="down" reason="no-response"/><address addr="10.0.0.2" addrtype="ipv4"/></host>
+</nmaprun>
+`
+
+func TestInsertNmapScansScanHosts(t *testing.T) {
+	var hosts []models.NmapHost
+	var results []models.ScanResult
+	linked := make(map[string][]uuid.UUID)
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertHostsFn: func(ctx context.Context, h []models.NmapHost) error {
+			hosts = append(hosts, h...)
+			return nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, r []models.ScanResult) error {
+			results = append(results, r...)
+			return nil
+		},
+		InsertScanHostsFn: func(ctx context.Context, scanID string, hostIDs []uuid.UUID) error {
+			linked[scanID] = append(linked[scanID], hostIDs...)
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBufferString(nmapDownHostXML))
+	ids := assertImported(t, importScans(t, router, req))
+	if !assert.Len(t, ids, 1) || !assert.Len(t, hosts, 2) || !assert.Len(t, results, 1) {
+		return
+	}
+
+	// Hosts without ports are linked to the scan too, to be listed with it
+	assert.ElementsMatch(t, []uuid.UUID{hosts[0].HostID, hosts[1].HostID}, linked[ids[0]])
+	assert.Equal(t, "down", hosts[1].HostStatus)
+}
+
+func TestInsertNmapScansFixture(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var hosts []models.NmapHost
+	var results []models.ScanResult
+	var scans []models.NmapScan
+	// Services deduplicated by signature, like GetOrCreateService does in DB
+	services := make(map[models.Service]models.Service)
+	servicesByID := make(map[uuid.UUID]models.Service)
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertScanFn: func(ctx context.Context, scan *models.NmapScan) error {
+			scans = append(scans, *scan)
+			return nil
+		},
+		InsertHostsFn: func(ctx context.Context, h []models.NmapHost) error {
+			hosts = append(hosts, h...)
+			return nil
+		},
+		GetOrCreateServiceFn: func(ctx context.Context, service *models.Service) (*models.Service, error) {
+			created, exists := services[*service]
+			if !exists {
+				created = *service
+				created.ServiceID = uuid.New()
+				services[*service] = created
+				servicesByID[created.ServiceID] = created
+			}
+			return &created, nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, r []models.ScanResult) error {
+			results = append(results, r...)
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	// Imported twice: services are reused, results are not
+	for range 2 {
+		req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBuffer(fixture))
+		assertImported(t, importScans(t, router, req))
+	}
+
+	if !assert.Len(t, scans, 2) {
+		return
+	}
+	assert.Equal(t, "nmap", scans[0].Scanner)
+	assert.Equal(t, "7.94", scans[0].NmapVersion)
+	assert.Equal(t, int64(1700000000), scans[0].ScanStart.Unix())
+
+	if !assert.Len(t, hosts, 4) {
+		return
+	}
+	hostsByID := make(map[uuid.UUID]string)
+	for _, host := range hosts {
+		hostsByID[host.HostID] = host.Host
+	}
+
+	type row struct {
+		host, protocol                string
+		port                          uint16
+		state                         string
+		name, product, version, extra string
+		tunnel                        string
+	}
+	const openssh = "8.9p1 Ubuntu 3ubuntu0.6"
+	expected := []row{
+		{"10.0.0.1", "tcp", 22, "open", "ssh", "OpenSSH", openssh, "Ubuntu Linux; protocol 2.0", ""},
+		{"10.0.0.1", "tcp", 80, "open", "http", "nginx", "1.18.0", "Ubuntu", ""},
+		{"10.0.0.1", "tcp", 443, "open", "http", "nginx", "1.18.0", "Ubuntu", "ssl"},
+		// Not fingerprinted
+		{"10.0.0.1", "tcp", 9999, "filtered", "", "", "", "", ""},
+		{"10.0.0.1", "udp", 53, "open|filtered", "domain", "", "", "", ""},
+		{"10.0.0.2", "tcp", 22, "open", "ssh", "OpenSSH", openssh, "Ubuntu Linux; protocol 2.0", ""},
+		{"10.0.0.2", "tcp", 80, "closed", "http", "", "", "", ""},
+		{"10.0.0.2", "tcp", 443, "closed", "https", "", "", "", ""},
+		{"10.0.0.2", "tcp", 9999, "open", "abyss", "", "", "", ""},
+		{"10.0.0.2", "udp", 53, "open", "domain", "ISC BIND", "9.18.18-0ubuntu0.22.04.1", "Ubuntu Linux", ""},
+	}
+
+	if !assert.Len(t, results, 2*len(expected)) {
+		return
+	}
+	for i, result := range results {
+		exp := expected[i%len(expected)]
+		service, exists := servicesByID[result.ServiceID]
+		if !assert.True(t, exists, "port %d/%s of %s has no service", exp.port, exp.protocol, exp.host) {
+			continue
+		}
+
+		assert.Equal(t, scans[i/len(expected)].ScanID, result.ScanID)
+		assert.Equal(t, exp, row{
+			host:     hostsByID[result.HostID],
+			protocol: service.Protocol,
+			port:     result.Port,
+			state:    result.PortState,
+			name:     service.ServiceName,
+			product:  service.ServiceProduct,
+			version:  service.ServiceVersion,
+			extra:    service.ServiceExtraInfo,
+			tunnel:   service.ServiceTunnel,
+		})
+	}
+
+	// Both SSH servers share a service, as do both imports
+	assert.Equal(t, results[0].ServiceID, results[5].ServiceID)
+	assert.Equal(t, results[0].ServiceID, results[10].ServiceID)
+	assert.NotEqual(t, results[1].ServiceID, results[2].ServiceID)
+	assert.Len(t, services, 9)
+}
+
+func TestInsertNmapScansReupload(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Scans by project and content hash, like the unique index in DB
+	imported := make(map[string]models.NmapScan)
+	insertedHosts := 0
+	mockRepo := &postgres_testing.MockNmapRepository{
+		FindScanByHashFn: func(ctx context.Context, project, contentHash string) (*models.NmapScan, error) {
+			if scan, exists := imported[project+"|"+contentHash]; exists {
+				return &scan, nil
+			}
+			return nil, shiryoku_errors.NotFoundError{Resource: "scan", ID: contentHash}
+		},
+		// The hash is known before the file is streamed
+		InsertScanFn: func(ctx context.Context, scan *models.NmapScan) error {
+			assert.Equal(t, internal_common.ContentHash(fixture), scan.ContentHash)
+			imported[scan.Project+"|"+scan.ContentHash] = *scan
+			return nil
+		},
+		InsertHostsFn: func(ctx context.Context, hosts []models.NmapHost) error {
+			insertedHosts++
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	upload := func(query string) []string {
+		req, _ := http.NewRequest("POST", "/api/modules/nmap/batch"+query, bytes.NewBuffer(fixture))
+		return assertImported(t, importScans(t, router, req))
+	}
+
+	first := upload("")
+	assert.Equal(t, first, upload(""), "A re-upload should return the existing scan")
+	assert.Len(t, imported, 1)
+	assert.Equal(t, 1, insertedHosts, "A re-upload shouldn't be imported again")
+
+	// Another project gets its own scan
+	assert.NotEqual(t, first, upload("?project=acme"))
+	assert.Len(t, imported, 2)
+}
+
+func TestInsertNmapScansConcurrentReupload(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Another upload of the same file commits once this one checked for it, before it inserts its scan
+	concurrent := models.NmapScan{ScanID: uuid.New(), ContentHash: internal_common.ContentHash(fixture)}
+	committed := false
+	mockRepo := &postgres_testing.MockNmapRepository{
+		FindScanByHashFn: func(ctx context.Context, project, contentHash string) (*models.NmapScan, error) {
+			if committed {
+				return &concurrent, nil
+			}
+			return nil, shiryoku_errors.NotFoundError{Resource: "scan", ID: contentHash}
+		},
+		InsertScanFn: func(ctx context.Context, scan *models.NmapScan) error {
+			committed = true
+			return shiryoku_errors.AlreadyExistsError{Resource: "scan", ID: scan.ContentHash}
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBuffer(fixture))
+	ids := assertImported(t, importScans(t, router, req))
+	assert.Equal(t, []string{concurrent.ScanID.String()}, ids, "The scan of the concurrent upload should be returned")
+}
+
+func TestInsertNmapScansTransaction(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	inTransaction := false
+	rolledBack := false
+	inserted := []string{}
+	mockRepo := &postgres_testing.MockNmapRepository{}
+	mockRepo.WithTransactionFn = func(ctx context.Context, fn func(repo repositories.NmapRepository) error) error {
+		inTransaction = true
+		defer func() { inTransaction = false }()
+
+		err := fn(mockRepo)
+		rolledBack = err != nil
+		return err
+	}
+	mockRepo.InsertHostsFn = func(ctx context.Context, hosts []models.NmapHost) error {
+		assert.True(t, inTransaction, "hosts should be inserted in the transaction")
+		inserted = append(inserted, "hosts")
+		return nil
+	}
+	mockRepo.InsertScanFn = func(ctx context.Context, scan *models.NmapScan) error {
+		assert.True(t, inTransaction, "the scan should be inserted in the transaction")
+		inserted = append(inserted, "scan")
+		return nil
+	}
+	mockRepo.InsertScanResultsFn = func(ctx context.Context, results []models.ScanResult) error {
+		return errors.New("connection reset")
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBuffer(fixture))
+	job := importScans(t, router, req)
+
+	assert.Equal(t, string(models.JOB_STATUS_FAILED), job.Status)
+	if assert.Len(t, job.Files, 1) {
+		assert.Contains(t, job.Files[0].Error, "connection reset")
+	}
+	// The scan comes first, so that hosts can be streamed into it
+	assert.Equal(t, []string{"scan", "hosts"}, inserted)
+	assert.True(t, rolledBack, "A failure should roll back the whole import")
+}
+
+func TestInsertNmapScansTooLarge(t *testing.T) {
+	router := setupRouter(t, &postgres_testing.MockNmapRepository{})
+
+	for name, upload := range map[string]func() (*bytes.Buffer, string){
+		"Raw body": func() (*bytes.Buffer, string) {
+			return bytes.NewBuffer(make([]byte, testMaxUploadSize+1)), "application/xml"
+		},
+		"Multipart": func() (*bytes.Buffer, string) {
+			files := map[string][]byte{"huge.xml": make([]byte, testMaxUploadSize)}
+			return multipartUpload(t, files, "huge.xml")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			body, contentType := upload()
+			req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 413, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestInsertNmapScansRolledBackProgress(t *testing.T) {
+	// Two batches of hosts, the second one failing
+	var output strings.Builder
+	output.WriteString(`<?xml version="1.0" encoding="UTF-8"?><nmaprun scanner="nmap" start="1700000000" version="7.94">`)
+	for i := 0; i < 600; i++ {
+		fmt.Fprintf(&output, `<host><status state="up"/><address addr="10.0.%d.%d" addrtype="ipv4"/>`, i/256, i%256)
+		output.WriteString(`<ports><port protocol="tcp" portid="22"><state state="open"/><service name="ssh"/></port></ports></host>`)
+	}
+	output.WriteString(`</nmaprun>`)
+
+	batches := 0
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertScanResultsFn: func(ctx context.Context, results []models.ScanResult) error {
+			batches++
+			if batches > 1 {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", strings.NewReader(output.String()))
+	job := importScans(t, router, req)
+
+	assert.Equal(t, string(models.JOB_STATUS_FAILED), job.Status)
+	assert.Equal(t, 2, batches)
+	// The first batch was rolled back with the file
+	assert.Zero(t, job.HostsProcessed)
+	assert.Zero(t, job.PortsProcessed)
+}
+
+func gzipped(t *testing.T, content []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func tarball(t *testing.T, files map[string][]byte, names ...string) []byte {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	assert.NoError(t, writer.WriteHeader(&tar.Header{Name: "sweep/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, name := range names {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[name]))}))
+		_, err := writer.Write(files[name])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func zipball(t *testing.T, files map[string][]byte, names ...string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range names {
+		entry, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = entry.Write(files[name])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func multipartUpload(t *testing.T, files map[string][]byte, names ...string) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, name := range names {
+		part, err := writer.CreateFormFile("files", name)
+		assert.NoError(t, err)
+		_, err = part.Write(files[name])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return &buf, writer.FormDataContentType()
+}
+
+func TestInsertNmapScansUploads(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := map[string][]byte{
+		"sweep/scan.xml":       fixture,
+		"sweep/hosts.xml":      []byte(nmapHostsXML),
+		"sweep/broken.xml":     []byte("<nmaprun><host>"),
+		"sweep/._scan.xml":     []byte("resource fork"),
+		"__MACOSX/sweep/a.xml": []byte("resource fork"),
+	}
+	files["scan.xml"] = fixture
+	files["scan.xml.gz"] = gzipped(t, fixture)
+	files["sweep.tar.gz"] = gzipped(t, tarball(t, files, "sweep/scan.xml", "sweep/hosts.xml", "sweep/broken.xml", "sweep/._scan.xml"))
+	files["sweep.tar"] = tarball(t, files, "sweep/scan.xml")
+	files["sweep.zip"] = zipball(t, files, "sweep/scan.xml", "sweep/hosts.xml", "__MACOSX/sweep/a.xml")
+	files["truncated.gz"] = files["scan.xml.gz"][:20]
+
+	type file struct {
+		File   string `json:"file"`
+		Failed bool
+	}
+	tests := []struct {
+		name           string
+		uploads        []string
+		expectedStatus models.JobStatus
+		expectedFiles  []file
+	}{
+		{
+			name:           "Multiple files",
+			uploads:        []string{"scan.xml", "scan.xml.gz"},
+			expectedStatus: models.JOB_STATUS_DONE,
+			expectedFiles:  []file{{File: "scan.xml"}, {File: "scan.xml"}},
+		},
+		{
+			name:    "Tar archives",
+			uploads: []string{"sweep.tar.gz", "sweep.tar"},
+			// Done, the failed file is listed
+			expectedStatus: models.JOB_STATUS_DONE,
+			expectedFiles: []file{
+				{File: "sweep.tar.gz/sweep/scan.xml"},
+				{File: "sweep.tar.gz/sweep/hosts.xml"},
+				{File: "sweep.tar.gz/sweep/broken.xml", Failed: true},
+				{File: "sweep.tar/sweep/scan.xml"},
+			},
+		},
+		{
+			name:           "Zip archive",
+			uploads:        []string{"sweep.zip"},
+			expectedStatus: models.JOB_STATUS_DONE,
+			expectedFiles:  []file{{File: "sweep.zip/sweep/scan.xml"}, {File: "sweep.zip/sweep/hosts.xml"}},
+		},
+		{
+			name:           "Broken files only",
+			uploads:        []string{"truncated.gz", "sweep/broken.xml"},
+			expectedStatus: models.JOB_STATUS_FAILED,
+			// Decompression fails while the file is read
+			expectedFiles: []file{{File: "truncated", Failed: true}, {File: "broken.xml", Failed: true}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupRouter(t, &postgres_testing.MockNmapRepository{})
+
+			body, contentType := multipartUpload(t, files, tc.uploads...)
+			req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", body)
+			req.Header.Set("Content-Type", contentType)
+			job := importScans(t, router, req)
+
+			assert.Equal(t, string(tc.expectedStatus), job.Status, job.Error)
+
+			var files []file
+			for _, result := range job.Files {
+				files = append(files, file{File: result.File, Failed: result.Error != ""})
+				if result.Error == "" {
+					assert.NotEmpty(t, result.ScanID)
+				}
+			}
+			assert.Equal(t, tc.expectedFiles, files)
+		})
+	}
+}
+
+func TestInsertNmapScansGzippedBody(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	router := setupRouter(t, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBuffer(gzipped(t, fixture)))
+	assertImported(t, importScans(t, router, req))
+
+	req, _ = http.NewRequest("POST", "/api/modules/nmap/batch", bytes.NewBufferString("not xml"))
+	job := importScans(t, router, req)
+	assert.Equal(t, string(models.JOB_STATUS_FAILED), job.Status)
+	assert.Equal(t, "no scan could be imported", job.Error)
+}
+
+func TestInsertNmapScansBatches(t *testing.T) {
+	// Enough hosts for several batches, all running the same service
+	const hostCount = 1200
+	var xmlBuf strings.Builder
+	xmlBuf.WriteString(`<?xml version="1.0"?><nmaprun scanner="nmap" args="nmap -sV 10.0.0.0/16" start="1700000000" version="7.94">`)
+	for i := range hostCount {
+		fmt.Fprintf(&xmlBuf, `<host><status state="up"/><address addr="10.0.%d.%d" addrtype="ipv4"/><ports><port protocol="tcp" portid="22"><state state="open"/><service name="ssh" product="OpenSSH" version="8.9p1"/></port></ports></host>`, i/256, i%256)
+	}
+	xmlBuf.WriteString(`</nmaprun>`)
+
+	var hostBatches, resultBatches []int
+	services := 0
+	mockRepo := &postgres_testing.MockNmapRepository{
+		InsertHostsFn: func(ctx context.Context, hosts []models.NmapHost) error {
+			hostBatches = append(hostBatches, len(hosts))
+			return nil
+		},
+		GetOrCreateServiceFn: func(ctx context.Context, service *models.Service) (*models.Service, error) {
+			services++
+			created := *service
+			created.ServiceID = uuid.New()
+			return &created, nil
+		},
+		InsertScanResultsFn: func(ctx context.Context, results []models.ScanResult) error {
+			resultBatches = append(resultBatches, len(results))
+			for _, result := range results {
+				assert.NotEqual(t, uuid.Nil, result.ServiceID)
+			}
+			return nil
+		},
+	}
+	router := setupRouter(t, mockRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", strings.NewReader(xmlBuf.String()))
+	assertImported(t, importScans(t, router, req))
+	assert.Equal(t, []int{500, 500, 200}, hostBatches)
+	assert.Equal(t, []int{500, 500, 200}, resultBatches)
+	// Services are only looked up once per import
+	assert.Equal(t, 1, services)
+}
+
+func TestInsertNmapScansJob(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/scan.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	router := setupRouter(t, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch?project=acme", bytes.NewBuffer(fixture))
+	job := importScans(t, router, req)
+	assertImported(t, job)
+
+	assert.Equal(t, internal_nmap.JOB_TOOL, job.Tool)
+	assert.Equal(t, "acme", job.Project)
+	// 2 hosts, 10 ports
+	assert.Equal(t, int64(2), job.HostsProcessed)
+	assert.Equal(t, int64(10), job.PortsProcessed)
+	assert.NotNil(t, job.FinishedAt)
+	if assert.Len(t, job.Files, 1) {
+		assert.Equal(t, utils.RAW_BODY_FILENAME, job.Files[0].File)
+	}
+}
+
+func TestInsertNmapScansJobErrors(t *testing.T) {
+	router := setupRouter(t, &postgres_testing.MockNmapRepository{})
+
+	// Nothing to import
+	body, contentType := multipartUpload(t, nil)
+	req, _ := http.NewRequest("POST", "/api/modules/nmap/batch", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code, w.Body.String())
+
+	tests := []struct {
+		name           string
+		jobID          string
+		expectedStatus int
+	}{
+		{name: "Unknown job", jobID: uuid.NewString(), expectedStatus: 404},
+		{name: "Invalid job ID", jobID: "42", expectedStatus: 400},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/jobs/"+tc.jobID, nil))
+			assert.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func setupScanRouter(nmapRepo *postgres_testing.MockNmapRepository, dashboardRepo *postgres_testing.MockDashboardRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &NmapModule{nmapRepo: nmapRepo, dashboardRepo: dashboardRepo}
+
+	scans_group := r.Group("/api/modules/nmap/scans")
+	scans_group.GET("/:id", module.getNmapScan())
+	scans_group.DELETE("/:id", module.deleteNmapScan())
+	scans_group.POST("/:id/reprocess", module.reprocessNmapScan())
+	return r
+}
+
+// scanRepo is a scan of two hosts: 10.0.0.2 with 443 and 22 open, 10.0.0.1 with 80 open
+func scanRepo() (*postgres_testing.MockNmapRepository, models.NmapScan) {
+	ssh := models.Service{ServiceID: uuid.New(), ServiceName: "ssh", Protocol: "tcp"}
+	web := models.Service{ServiceID: uuid.New(), ServiceName: "http", Protocol: "tcp"}
+	first := models.NmapHost{HostID: uuid.New(), Host: "10.0.0.1"}
+	second := models.NmapHost{HostID: uuid.New(), Host: "10.0.0.2"}
+
+	scan := models.NmapScan{ScanID: uuid.New(), Scanner: "nmap"}
+	scan.ScanResults = []models.ScanResult{
+		{ScanResultID: uuid.New(), ScanID: scan.ScanID, HostID: second.HostID, ServiceID: web.ServiceID, Port: 443, PortState: "open"},
+		{ScanResultID: uuid.New(), ScanID: scan.ScanID, HostID: second.HostID, ServiceID: ssh.ServiceID, Port: 22, PortState: "open",
+			Scripts: []models.NmapScriptResult{{ScriptID: "ssh-hostkey", ScriptOutput: "2048 aa:bb"}}},
+		{ScanResultID: uuid.New(), ScanID: scan.ScanID, HostID: first.HostID, ServiceID: web.ServiceID, Port: 80, PortState: "open"},
+	}
+
+	repo := &postgres_testing.MockNmapRepository{
+		GetScanFn: func(ctx context.Context, scanID string) (*models.NmapScan, error) {
+			if scanID != scan.ScanID.String() {
+				return nil, shiryoku_errors.NotFoundError{Resource: "scan", ID: scanID}
+			}
+			copied := scan
+			return &copied, nil
+		},
+		GetHostsFn: func(ctx context.Context, scanID string) ([]models.NmapHost, error) {
+			return []models.NmapHost{second, first}, nil
+		},
+		GetScanServicesFn: func(ctx context.Context, scanID string) ([]models.Service, error) {
+			return []models.Service{ssh, web}, nil
+		},
+		GetScanResultsFn: func(ctx context.Context, scanID, hostID string) ([]models.ScanResult, error) {
+			var results []models.ScanResult
+			for _, result := range scan.ScanResults {
+				if result.HostID.String() == hostID {
+					results = append(results, result)
+				}
+			}
+			return results, nil
+		},
+	}
+	return repo, scan
+}
+
+func TestGetNmapScan(t *testing.T) {
+	nmapRepo, scan := scanRepo()
+	router := setupScanRouter(nmapRepo, &postgres_testing.MockDashboardRepository{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/modules/nmap/scans/"+scan.ScanID.String(), nil))
+	assert.Equal(t, 200, w.Code, w.Body.String())
+
+	var raw map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &raw))
+	assert.NotContains(t, raw, "scan_results", "Ports should be listed by host only")
+
+	var detail models.NmapScanDetail
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, scan.ScanID, detail.ScanID)
+	if !assert.Len(t, detail.Hosts, 2) {
+		return
+	}
+
+	assert.Equal(t, "10.0.0.1", detail.Hosts[0].Host)
+	if assert.Len(t, detail.Hosts[0].Ports, 1) {
+		assert.Equal(t, uint16(80), detail.Hosts[0].Ports[0].Port)
+		assert.Equal(t, "http", detail.Hosts[0].Ports[0].Service.ServiceName)
+	}
+
+	assert.Equal(t, "10.0.0.2", detail.Hosts[1].Host)
+	if assert.Len(t, detail.Hosts[1].Ports, 2) {
+		assert.Equal(t, uint16(22), detail.Hosts[1].Ports[0].Port)
+		assert.Equal(t, "ssh", detail.Hosts[1].Ports[0].Service.ServiceName)
+		assert.Len(t, detail.Hosts[1].Ports[0].Scripts, 1)
+		assert.Equal(t, uint16(443), detail.Hosts[1].Ports[1].Port)
+	}
+}
+
+func TestDeleteNmapScan(t *testing.T) {
+	scanID := uuid.NewString()
+	var deleted string
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		DeleteScanFn: func(ctx context.Context, id string) error {
+			if id != scanID {
+				return shiryoku_errors.NotFoundError{Resource: "scan", ID: id}
+			}
+			deleted = id
+			return nil
+		},
+	}
+	// Dashboard rows are deleted with the scan, in the same transaction
+	dashboardRepo := &postgres_testing.MockDashboardRepository{
+		DeleteDashboardScansFn: func(ctx context.Context, id string) error {
+			t.Error("Dashboard rows shouldn't be deleted apart from the scan")
+			return nil
+		},
+	}
+	router := setupScanRouter(nmapRepo, dashboardRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/modules/nmap/scans/"+scanID, nil))
+	assert.Equal(t, 204, w.Code, w.Body.String())
+	assert.Equal(t, scanID, deleted)
+
+	tests := []struct {
+		name           string
+		scanID         string
+		expectedStatus int
+	}{
+		{name: "Unknown scan", scanID: uuid.NewString(), expectedStatus: 404},
+		{name: "Invalid scan ID", scanID: "42", expectedStatus: 400},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/modules/nmap/scans/"+tc.scanID, nil))
+			assert.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestReprocessNmapScan(t *testing.T) {
+	nmapRepo, scan := scanRepo()
+
+	var calls []string
+	var rows []widgets.WidgetDashboardScan
+	dashboardRepo := &postgres_testing.MockDashboardRepository{
+		DeleteDashboardScansFn: func(ctx context.Context, scanID string) error {
+			calls = append(calls, "delete "+scanID)
+			return nil
+		},
+		CreateDashboardScansFn: func(ctx context.Context, created []widgets.WidgetDashboardScan) error {
+			calls = append(calls, "create")
+			rows = created
+			return nil
+		},
+		TruncateDashboardFn: func(ctx context.Context) error {
+			t.Error("Reprocessing a scan shouldn't truncate the dashboard")
+			return nil
+		},
+	}
+	router := setupScanRouter(nmapRepo, dashboardRepo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/modules/nmap/scans/"+scan.ScanID.String()+"/reprocess", nil))
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	assert.JSONEq(t, fmt.Sprintf(`{"scan_id": %q, "dashboard_rows": 2}`, scan.ScanID), w.Body.String())
+
+	assert.Equal(t, []string{"delete " + scan.ScanID.String(), "create"}, calls, "Rows of the scan should be replaced")
+	ports := make(map[string][]int)
+	for _, row := range rows {
+		assert.Equal(t, scan.ScanID.String(), row.ScanID)
+		ports[row.Host] = row.Ports
+	}
+	assert.Equal(t, map[string][]int{"10.0.0.1": {80}, "10.0.0.2": {443, 22}}, ports)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/modules/nmap/scans/"+uuid.NewString()+"/reprocess", nil))
+	assert.Equal(t, 404, w.Code, w.Body.String())
+}
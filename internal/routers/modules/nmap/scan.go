@@ -0,0 +1,78 @@
+package nmap
+
+import (
+	"errors"
+
+	internal_nmap "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/nmap"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// getNmapScan returns a scan with its hosts, and their ports, services and scripts
+func (m *NmapModule) getNmapScan() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scanID := c.Param("id")
+		if _, err := uuid.Parse(scanID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid scan ID"})
+			return
+		}
+
+		detail, err := internal_nmap.GetScanDetail(c.Request.Context(), m.nmapRepo, scanID)
+		if !respondScanError(c, err) {
+			return
+		}
+
+		c.JSON(200, detail)
+	}
+}
+
+// deleteNmapScan deletes a scan, with the hosts and services only it had
+func (m *NmapModule) deleteNmapScan() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scanID := c.Param("id")
+		if _, err := uuid.Parse(scanID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid scan ID"})
+			return
+		}
+
+		err := internal_nmap.DeleteScan(c.Request.Context(), m.nmapRepo, scanID)
+		if !respondScanError(c, err) {
+			return
+		}
+
+		c.Status(204)
+	}
+}
+
+// reprocessNmapScan rebuilds the dashboard rows of a scan
+func (m *NmapModule) reprocessNmapScan() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scanID := c.Param("id")
+		if _, err := uuid.Parse(scanID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid scan ID"})
+			return
+		}
+
+		rows, err := internal_nmap.ReprocessScan(c.Request.Context(), m.nmapRepo, m.dashboardRepo, scanID)
+		if !respondScanError(c, err) {
+			return
+		}
+
+		c.JSON(200, gin.H{"scan_id": scanID, "dashboard_rows": rows})
+	}
+}
+
+// respondScanError answers 404 for unknown scans, 500 for other errors
+// ok is false if there is an error
+func respondScanError(c *gin.Context, err error) bool {
+	if errors.As(err, &shiryoku_errors.NotFoundError{}) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
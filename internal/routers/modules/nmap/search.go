@@ -3,10 +3,21 @@ package nmap
 import (
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
 	"github.com/gin-gonic/gin"
 )
 
 // SearchNmapScans returns a handler for searching nmap scans
 func (m *NmapModule) searchNmapScans() gin.HandlerFunc {
-	return common.Search(m.nmapRepo, utils.NmapScanFields)
+	return common.Search(m.nmapRepo, utils.NmapScanFields, utils.NmapScanRelationFields)
+}
+
+// aggregateNmapScans returns a handler for counting nmap scans by group
+func (m *NmapModule) aggregateNmapScans() gin.HandlerFunc {
+	return common.Aggregate[models.NmapScan](m.nmapRepo, utils.NmapScanFields, utils.NmapScanRelationFields)
+}
+
+// exportNmapScans returns a handler for exporting nmap scans
+func (m *NmapModule) exportNmapScans() gin.HandlerFunc {
+	return common.Export(m.nmapRepo, "nmap_scans", utils.NmapScanFields, utils.NmapScanRelationFields)
 }
@@ -0,0 +1,32 @@
+package nuclei
+
+import (
+	"fmt"
+
+	internal_nuclei "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/nuclei"
+	"github.com/gin-gonic/gin"
+)
+
+// insertNucleiFindings inserts findings from a `nuclei -jsonl` output
+// Findings are linked to the hosts of the project given with ?project= (none by default)
+func (m *NucleiModule) insertNucleiFindings() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		// One JSON document per line
+		results, err := internal_nuclei.ParseJSONL(c.Request.Body)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid JSONL format: %v", err)})
+			return
+		}
+
+		count, err := internal_nuclei.SaveNucleiFindings(c.Request.Context(), results, c.Query("project"), m.nucleiRepo, m.nmapRepo)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(201, gin.H{
+			"count":   count,
+			"message": "nuclei findings inserted successfully",
+		})
+	}
+}
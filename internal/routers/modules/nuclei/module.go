@@ -0,0 +1,55 @@
+package nuclei
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+type NucleiModule struct {
+	nucleiRepo repositories.NucleiRepository
+	nmapRepo   repositories.NmapRepository
+}
+
+func (m *NucleiModule) Name() string {
+	return "nuclei"
+}
+
+func (m *NucleiModule) Description() string {
+	return "Nuclei findings"
+}
+
+func (m *NucleiModule) SetupRoutes(nuclei_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.NUCLEI_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.NUCLEI_REPOSITORY)
+	}
+
+	nucleiRepo, ok := repo.(repositories.NucleiRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a NucleiRepository", repositories.NUCLEI_REPOSITORY)
+	}
+
+	// Findings are linked to nmap hosts and services
+	repo = provider.GetRepository(repositories.NMAP_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.NMAP_REPOSITORY)
+	}
+
+	nmapRepo, ok := repo.(repositories.NmapRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an NmapRepository", repositories.NMAP_REPOSITORY)
+	}
+
+	m.nucleiRepo = nucleiRepo
+	m.nmapRepo = nmapRepo
+
+	search_group := nuclei_group.Group("/search")
+	search_group.POST("", m.searchNucleiFindings())
+	nuclei_group.POST("/aggregate", m.aggregateNucleiFindings())
+	nuclei_group.POST("/export", m.exportNucleiFindings())
+	nuclei_group.POST("/batch", m.insertNucleiFindings())
+
+	return nil
+}
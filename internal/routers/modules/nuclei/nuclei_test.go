@@ -0,0 +1,259 @@
+package nuclei
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(nucleiRepo *postgres_testing.MockNucleiRepository, nmapRepo *postgres_testing.MockNmapRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &NucleiModule{nucleiRepo: nucleiRepo, nmapRepo: nmapRepo}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			nuclei_group := modules_group.Group("/nuclei")
+			nuclei_group.POST("/search", module.searchNucleiFindings())
+			nuclei_group.POST("/aggregate", module.aggregateNucleiFindings())
+			nuclei_group.POST("/batch", module.insertNucleiFindings())
+		}
+	}
+	return r
+}
+
+func TestSearchNucleiFindings(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockNucleiRepository{}, &postgres_testing.MockNmapRepository{})
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Search by severity",
+			payload:        `{"search": [{"parameter": "severity", "operator": "in", "values": ["high", "critical"]}]}`,
+			expectedStatus: 200,
+			description:    "Should accept known nuclei fields",
+		},
+		{
+			name:           "Search by template",
+			payload:        `{"search": [{"parameter": "template_id", "operator": "like", "value": "cve-2021"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept like operator on template ids",
+		},
+		{
+			name:           "Unknown field",
+			payload:        `{"search": [{"parameter": "scan_args", "operator": "eq", "value": "-sV"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject fields from other modules",
+		},
+		{
+			name:           "Search by network",
+			payload:        `{"search": [{"parameter": "ip", "operator": "in_cidr", "value": "10.20.0.0/16"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept CIDR searches on addresses",
+		},
+		{
+			name:           "CIDR on hosts",
+			payload:        `{"search": [{"parameter": "host", "operator": "in_cidr", "value": "10.20.0.0/16"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject network operators on hostnames",
+		},
+		{
+			name:           "Search by tag",
+			payload:        `{"search": [{"parameter": "tags", "operator": "contains", "value": "cve"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept contains on arrays",
+		},
+		{
+			name:           "Search by any CVE",
+			payload:        `{"search": [{"parameter": "cve_ids", "operator": "contains_any", "values": ["CVE-2021-44228", "CVE-2021-45046"]}]}`,
+			expectedStatus: 200,
+			description:    "Should accept contains_any on arrays",
+		},
+		{
+			name:           "Search by all tags",
+			payload:        `{"search": [{"parameter": "tags", "operator": "contains_all", "values": ["cve", "rce"]}]}`,
+			expectedStatus: 200,
+			description:    "Should accept contains_all on arrays",
+		},
+		{
+			name:           "Search by extracted result pattern",
+			payload:        `{"search": [{"parameter": "extracted_results", "operator": "any_like", "value": ".corp.local"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept any_like on text arrays",
+		},
+		{
+			name:           "Contains on a scalar field",
+			payload:        `{"search": [{"parameter": "severity", "operator": "contains", "value": "high"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject array operators on other fields",
+		},
+		{
+			name:           "Wrong element type",
+			payload:        `{"search": [{"parameter": "tags", "operator": "contains_any", "values": ["cve", 42]}]}`,
+			expectedStatus: 400,
+			description:    "Should check values against the array elements",
+		},
+		{
+			name:           "Empty values",
+			payload:        `{"search": [{"parameter": "tags", "operator": "contains_all", "values": []}]}`,
+			expectedStatus: 400,
+			description:    "Should reject array operators without values",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/modules/nuclei/search", bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestAggregateNucleiFindings(t *testing.T) {
+	var received *models.AggregationParams
+	nucleiRepo := &postgres_testing.MockNucleiRepository{
+		AggregateFn: func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+			received = params
+			return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+		},
+	}
+	router := setupRouter(nucleiRepo, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/nuclei/aggregate", bytes.NewBufferString(`{"group_by": ["template_id"], "limit": 20}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, []string{"template_id"}, received.GroupBy)
+	}
+}
+
+func TestInsertNucleiFindings(t *testing.T) {
+	hostID := uuid.New()
+	serviceID := uuid.New()
+
+	var inserted []models.NucleiFinding
+	nucleiRepo := &postgres_testing.MockNucleiRepository{
+		InsertFindingsFn: func(ctx context.Context, findings []models.NucleiFinding) error {
+			inserted = findings
+			return nil
+		},
+	}
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		FindHostByAddressFn: func(ctx context.Context, project, address string) (*models.NmapHost, error) {
+			if address == "10.0.0.1" {
+				return &models.NmapHost{HostID: hostID, Host: address}, nil
+			}
+			return (&postgres_testing.MockNmapRepository{}).FindHostByAddress(ctx, project, address)
+		},
+		FindLatestScanResultFn: func(ctx context.Context, id string, port uint16) (*models.ScanResult, error) {
+			assert.Equal(t, hostID.String(), id)
+			assert.Equal(t, uint16(8443), port)
+			return &models.ScanResult{HostID: hostID, ServiceID: serviceID, Port: port}, nil
+		},
+	}
+	router := setupRouter(nucleiRepo, nmapRepo)
+
+	payload := `{"template-id":"CVE-2021-41773","info":{"name":"Apache 2.4.49 - Path Traversal","tags":["cve","apache"],"severity":"critical","classification":{"cve-id":["cve-2021-41773"],"cwe-id":["cwe-22"],"cvss-score":7.5}},"type":"http","host":"https://10.0.0.1:8443","port":"8443","matched-at":"https://10.0.0.1:8443/cgi-bin/.%2e/etc/passwd","ip":"10.0.0.1","timestamp":"2024-01-01T10:00:00Z","matcher-name":"passwd"}
+
+{"template-id":"tech-detect","info":{"name":"Wappalyzer","tags":"tech,discovery","severity":"info"},"type":"http","host":"https://example.com","matched-at":"https://example.com/","extracted-results":["nginx"],"ip":"192.0.2.1","timestamp":"2024-01-01T10:00:01Z"}
+`
+
+	req, _ := http.NewRequest("POST", "/api/modules/nuclei/batch", bytes.NewBufferString(payload))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code)
+	if assert.Len(t, inserted, 2) {
+		// Linked to nmap data
+		assert.Equal(t, "critical", inserted[0].Severity)
+		assert.Equal(t, uint16(8443), inserted[0].Port)
+		assert.Equal(t, []string{"cve-2021-41773"}, []string(inserted[0].CVEIDs))
+		assert.Equal(t, []string{"cwe-22"}, []string(inserted[0].CWEIDs))
+		assert.Equal(t, "passwd", inserted[0].MatcherName)
+		assert.Equal(t, &hostID, inserted[0].HostID)
+		assert.Equal(t, &serviceID, inserted[0].ServiceID)
+
+		// Never scanned: kept without links, port deduced from the URL
+		assert.Equal(t, []string{"tech", "discovery"}, []string(inserted[1].Tags))
+		assert.Equal(t, uint16(443), inserted[1].Port)
+		assert.Equal(t, []string{"nginx"}, []string(inserted[1].ExtractedResults))
+		assert.Nil(t, inserted[1].HostID)
+		assert.Nil(t, inserted[1].ServiceID)
+	}
+}
+
+func TestInsertNucleiFindingsProject(t *testing.T) {
+	// Hosts of each project, as scoped by models.NewHostID
+	hosts := map[string]uuid.UUID{"acme|10.0.0.1": uuid.New(), "|10.0.0.1": uuid.New()}
+
+	var inserted []models.NucleiFinding
+	nucleiRepo := &postgres_testing.MockNucleiRepository{
+		InsertFindingsFn: func(ctx context.Context, findings []models.NucleiFinding) error {
+			inserted = findings
+			return nil
+		},
+	}
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		FindHostByAddressFn: func(ctx context.Context, project, address string) (*models.NmapHost, error) {
+			if hostID, exists := hosts[project+"|"+address]; exists {
+				return &models.NmapHost{HostID: hostID, Host: address, Project: project}, nil
+			}
+			return (&postgres_testing.MockNmapRepository{}).FindHostByAddress(ctx, project, address)
+		},
+	}
+	router := setupRouter(nucleiRepo, nmapRepo)
+
+	payload := `{"template-id":"tech-detect","info":{"name":"Wappalyzer","severity":"info"},"type":"http","host":"https://10.0.0.1","matched-at":"https://10.0.0.1/","ip":"10.0.0.1","timestamp":"2024-01-01T10:00:01Z"}`
+	for _, project := range []string{"", "acme", "other"} {
+		req, _ := http.NewRequest("POST", "/api/modules/nuclei/batch?project="+project, bytes.NewBufferString(payload))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 201, w.Code)
+		if !assert.Len(t, inserted, 1) {
+			continue
+		}
+		// Only linked to the host of the same project
+		if hostID, exists := hosts[project+"|10.0.0.1"]; exists {
+			assert.Equal(t, &hostID, inserted[0].HostID, project)
+		} else {
+			assert.Nil(t, inserted[0].HostID, project)
+		}
+	}
+}
+
+func TestInsertNucleiFindingsInvalidJSONL(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockNucleiRepository{}, &postgres_testing.MockNmapRepository{})
+
+	req, _ := http.NewRequest("POST", "/api/modules/nuclei/batch", bytes.NewBufferString("{\"template-id\":\"a\"}\n{not json"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), "line 2")
+}
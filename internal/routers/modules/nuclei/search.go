@@ -0,0 +1,23 @@
+package nuclei
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// searchNucleiFindings returns a handler for searching nuclei findings
+func (m *NucleiModule) searchNucleiFindings() gin.HandlerFunc {
+	return common.Search(m.nucleiRepo, utils.NucleiFindingFields)
+}
+
+// aggregateNucleiFindings returns a handler for counting nuclei findings by group
+func (m *NucleiModule) aggregateNucleiFindings() gin.HandlerFunc {
+	return common.Aggregate[models.NucleiFinding](m.nucleiRepo, utils.NucleiFindingFields)
+}
+
+// exportNucleiFindings returns a handler for exporting nuclei findings
+func (m *NucleiModule) exportNucleiFindings() gin.HandlerFunc {
+	return common.Export(m.nucleiRepo, "nuclei_findings", utils.NucleiFindingFields)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+type ServicesModule struct {
+	serviceRepo repositories.ServiceRepository
+}
+
+func (m *ServicesModule) Name() string {
+	return "services"
+}
+
+func (m *ServicesModule) Description() string {
+	return "Services inventory across nmap scans"
+}
+
+func (m *ServicesModule) SetupRoutes(services_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.SERVICE_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.SERVICE_REPOSITORY)
+	}
+
+	serviceRepo, ok := repo.(repositories.ServiceRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a ServiceRepository", repositories.SERVICE_REPOSITORY)
+	}
+
+	m.serviceRepo = serviceRepo
+
+	search_group := services_group.Group("/search")
+	search_group.POST("", m.searchServices())
+	services_group.POST("/aggregate", m.aggregateServices())
+	services_group.POST("/export", m.exportServices())
+	services_group.GET("/versions", m.getServiceVersions())
+	services_group.GET("/:id", m.getService())
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// searchServices returns a handler for searching services, by fields of the hosts and scan results they were found with
+func (m *ServicesModule) searchServices() gin.HandlerFunc {
+	return common.Search(m.serviceRepo, utils.ServiceFields, utils.ServiceRelationFields)
+}
+
+// aggregateServices returns a handler for counting services by group, fields of their hosts and scan results included
+func (m *ServicesModule) aggregateServices() gin.HandlerFunc {
+	return common.Aggregate[models.Service](m.serviceRepo, utils.ServiceFields, utils.ServiceRelationFields)
+}
+
+// exportServices returns a handler for exporting services
+func (m *ServicesModule) exportServices() gin.HandlerFunc {
+	return common.Export(m.serviceRepo, "services", utils.ServiceFields, utils.ServiceRelationFields)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+
+	internal_services "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/services"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// getService returns a service, its first and last seen dates, and the hosts and ports currently exposing it
+func (m *ServicesModule) getService() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serviceID := c.Param("id")
+		if _, err := uuid.Parse(serviceID); err != nil {
+			c.JSON(400, gin.H{"error": "invalid service ID"})
+			return
+		}
+
+		detail, err := internal_services.GetServiceDetail(c.Request.Context(), m.serviceRepo, serviceID)
+		if errors.As(err, &shiryoku_errors.NotFoundError{}) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, detail)
+	}
+}
+
+// getServiceVersions returns the versions of a product (?product=OpenSSH, every product without), with their host counts
+func (m *ServicesModule) getServiceVersions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		product := c.Query("product")
+
+		versions, err := internal_services.GetServiceVersions(c.Request.Context(), m.serviceRepo, product)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"product": product, "results": versions})
+	}
+}
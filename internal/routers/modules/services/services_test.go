@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(serviceRepo *postgres_testing.MockServiceRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &ServicesModule{serviceRepo: serviceRepo}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			services_group := modules_group.Group("/services")
+			services_group.POST("/search", module.searchServices())
+			services_group.POST("/aggregate", module.aggregateServices())
+			services_group.GET("/versions", module.getServiceVersions())
+			services_group.GET("/:id", module.getService())
+		}
+	}
+	return r
+}
+
+func get(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSearchServices(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockServiceRepository{})
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Search by product",
+			payload:        `{"search": [{"parameter": "service_product", "operator": "like", "value": "OpenSSH"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept service fields",
+		},
+		{
+			name:           "Search by host network",
+			payload:        `{"search": [{"parameter": "hosts.ip", "operator": "in_cidr", "value": "10.0.0.0/8"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept fields of the hosts of services",
+		},
+		{
+			name:           "Search by port",
+			payload:        `{"search": [{"parameter": "scan_results.port", "operator": "eq", "value": 22}]}`,
+			expectedStatus: 200,
+			description:    "Should accept fields of the scan results of services",
+		},
+		{
+			name:           "Unknown field",
+			payload:        `{"search": [{"parameter": "os_guesses.name", "operator": "eq", "value": "Linux"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject fields of other relations",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/modules/services/search", bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestAggregateServices(t *testing.T) {
+	var received *models.AggregationParams
+	router := setupRouter(&postgres_testing.MockServiceRepository{
+		AggregateFn: func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+			received = params
+			return &models.AggregationResult{Buckets: []models.AggregationBucket{}}, nil
+		},
+	})
+
+	// Fields of hosts and scan results are accepted, as on search and export
+	payload := `{"search": [{"parameter": "hosts.ip", "operator": "in_cidr", "value": "10.0.0.0/8"}], "group_by": ["service_product", "scan_results.port"]}`
+	req, _ := http.NewRequest("POST", "/api/modules/services/aggregate", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	if assert.NotNil(t, received) {
+		assert.Equal(t, []string{"service_product", "scan_results.port"}, received.GroupBy)
+	}
+}
+
+func TestGetService(t *testing.T) {
+	serviceID := uuid.New()
+	firstSeen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastSeen := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	hostID := uuid.New()
+
+	router := setupRouter(&postgres_testing.MockServiceRepository{
+		GetServiceFn: func(ctx context.Context, id string) (*models.ServiceDetail, error) {
+			return &models.ServiceDetail{
+				Service:   models.Service{ServiceID: serviceID, ServiceName: "ssh", ServiceProduct: "OpenSSH", ServiceVersion: "8.9p1", Protocol: "tcp"},
+				FirstSeen: &firstSeen,
+				LastSeen:  &lastSeen,
+			}, nil
+		},
+		GetServiceExposuresFn: func(ctx context.Context, id string) ([]models.ServiceExposure, error) {
+			assert.Equal(t, serviceID.String(), id)
+			return []models.ServiceExposure{
+				{HostID: hostID, Host: "10.0.0.1", Port: 22, ScanStart: lastSeen},
+				{HostID: hostID, Host: "10.0.0.1", Port: 2222, ScanStart: lastSeen},
+			}, nil
+		},
+	})
+
+	w := get(router, "/api/modules/services/"+serviceID.String())
+	assert.Equal(t, 200, w.Code, w.Body.String())
+
+	var detail models.ServiceDetail
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, "OpenSSH", detail.ServiceProduct)
+	if assert.NotNil(t, detail.FirstSeen) && assert.NotNil(t, detail.LastSeen) {
+		assert.True(t, firstSeen.Equal(*detail.FirstSeen))
+		assert.True(t, lastSeen.Equal(*detail.LastSeen))
+	}
+	if assert.Len(t, detail.Exposures, 2) {
+		assert.Equal(t, uint16(22), detail.Exposures[0].Port)
+		assert.Equal(t, "10.0.0.1", detail.Exposures[1].Host)
+	}
+}
+
+func TestGetServiceErrors(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockServiceRepository{})
+
+	assert.Equal(t, 400, get(router, "/api/modules/services/not-a-uuid").Code)
+	assert.Equal(t, 404, get(router, "/api/modules/services/"+uuid.NewString()).Code)
+}
+
+func TestGetServiceVersions(t *testing.T) {
+	var received string
+	router := setupRouter(&postgres_testing.MockServiceRepository{
+		GetServiceVersionsFn: func(ctx context.Context, product string) ([]models.ServiceVersion, error) {
+			received = product
+			return []models.ServiceVersion{
+				{ServiceProduct: "OpenSSH", ServiceVersion: "8.9p1", Hosts: 12},
+				{ServiceProduct: "OpenSSH", ServiceVersion: "7.4", Hosts: 3},
+				{ServiceProduct: "OpenSSH", ServiceVersion: "6.6.1p1", Hosts: 0},
+			}, nil
+		},
+	})
+
+	w := get(router, "/api/modules/services/versions?product=OpenSSH")
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, "OpenSSH", received)
+
+	var response struct {
+		Product string                  `json:"product"`
+		Results []models.ServiceVersion `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "OpenSSH", response.Product)
+	if assert.Len(t, response.Results, 3) {
+		assert.Equal(t, int64(12), response.Results[0].Hosts)
+		assert.Equal(t, int64(0), response.Results[2].Hosts, "Versions no longer exposed should be kept")
+	}
+
+	// Every product
+	w = get(router, "/api/modules/services/versions")
+	assert.Equal(t, 200, w.Code, w.Body.String())
+	assert.Equal(t, "", received)
+}
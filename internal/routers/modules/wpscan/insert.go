@@ -0,0 +1,32 @@
+package wpscan
+
+import (
+	"fmt"
+
+	internal_wpscan "github.com/Robin-Van-de-Merghel/Shiryoku/internal/logic/modules/wpscan"
+	"github.com/gin-gonic/gin"
+)
+
+// insertWPScanReports inserts `wpscan --format json` reports
+// Reports are linked to the hosts of the project given with ?project= (none by default)
+func (m *WPScanModule) insertWPScanReports() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		reports, err := internal_wpscan.ParseJSON(c.Request.Body)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid JSON format: %v", err)})
+			return
+		}
+
+		ids, err := internal_wpscan.SaveWPScanReports(c.Request.Context(), reports, c.Query("project"), m.wpscanRepo, m.nmapRepo)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(201, gin.H{
+			"ids":     ids,
+			"count":   len(ids),
+			"message": "wpscan reports inserted successfully",
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package wpscan
+
+import (
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+type WPScanModule struct {
+	wpscanRepo repositories.WPScanRepository
+	nmapRepo   repositories.NmapRepository
+}
+
+func (m *WPScanModule) Name() string {
+	return "wpscan"
+}
+
+func (m *WPScanModule) Description() string {
+	return "WordPress components and vulnerabilities from wpscan"
+}
+
+func (m *WPScanModule) SetupRoutes(wpscan_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	repo := provider.GetRepository(repositories.WPSCAN_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.WPSCAN_REPOSITORY)
+	}
+
+	wpscanRepo, ok := repo.(repositories.WPScanRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not a WPScanRepository", repositories.WPSCAN_REPOSITORY)
+	}
+
+	// Scans are attached to nmap hosts and services
+	repo = provider.GetRepository(repositories.NMAP_REPOSITORY)
+	if repo == nil {
+		return fmt.Errorf("couldn't import repository %s from provider", repositories.NMAP_REPOSITORY)
+	}
+
+	nmapRepo, ok := repo.(repositories.NmapRepository)
+	if !ok {
+		return fmt.Errorf("repository %s is not an NmapRepository", repositories.NMAP_REPOSITORY)
+	}
+
+	m.wpscanRepo = wpscanRepo
+	m.nmapRepo = nmapRepo
+
+	search_group := wpscan_group.Group("/search")
+	search_group.POST("", m.searchWPScanScans())
+	wpscan_group.POST("/aggregate", m.aggregateWPScanScans())
+	wpscan_group.POST("/components/search", m.searchWPScanComponents())
+	wpscan_group.POST("/findings/search", m.searchWPScanFindings())
+	wpscan_group.POST("/vulnerabilities/search", m.searchWPScanVulnerabilities())
+	wpscan_group.POST("/components/aggregate", m.aggregateWPScanComponents())
+	wpscan_group.POST("/findings/aggregate", m.aggregateWPScanFindings())
+	wpscan_group.POST("/vulnerabilities/aggregate", m.aggregateWPScanVulnerabilities())
+	wpscan_group.POST("/export", m.exportWPScanScans())
+	wpscan_group.POST("/components/export", m.exportWPScanComponents())
+	wpscan_group.POST("/findings/export", m.exportWPScanFindings())
+	wpscan_group.POST("/vulnerabilities/export", m.exportWPScanVulnerabilities())
+	wpscan_group.POST("/batch", m.insertWPScanReports())
+
+	return nil
+}
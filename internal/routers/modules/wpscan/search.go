@@ -0,0 +1,69 @@
+package wpscan
+
+import (
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// searchWPScanScans returns a handler for searching wpscan scans
+func (m *WPScanModule) searchWPScanScans() gin.HandlerFunc {
+	return common.Search(m.wpscanRepo, utils.WPScanScanFields)
+}
+
+// searchWPScanComponents returns a handler for searching plugins, themes and WordPress versions
+func (m *WPScanModule) searchWPScanComponents() gin.HandlerFunc {
+	return common.Search(repositories.SearchFunc[models.WPScanComponent](m.wpscanRepo.SearchComponents), utils.WPScanComponentFields)
+}
+
+// searchWPScanFindings returns a handler for searching interesting findings
+func (m *WPScanModule) searchWPScanFindings() gin.HandlerFunc {
+	return common.Search(repositories.SearchFunc[models.WPScanFinding](m.wpscanRepo.SearchFindings), utils.WPScanFindingFields)
+}
+
+// searchWPScanVulnerabilities returns a handler for searching vulnerable components
+func (m *WPScanModule) searchWPScanVulnerabilities() gin.HandlerFunc {
+	return common.Search(repositories.SearchFunc[models.WPScanVulnerability](m.wpscanRepo.SearchVulnerabilities), utils.WPScanVulnerabilityFields)
+}
+
+// aggregateWPScanScans returns a handler for counting wpscan scans by group
+func (m *WPScanModule) aggregateWPScanScans() gin.HandlerFunc {
+	return common.Aggregate[models.WPScanScan](m.wpscanRepo, utils.WPScanScanFields)
+}
+
+// aggregateWPScanComponents returns a handler for counting plugins, themes and WordPress versions by group
+func (m *WPScanModule) aggregateWPScanComponents() gin.HandlerFunc {
+	return common.Aggregate[models.WPScanComponent](repositories.AggregateFunc[models.WPScanComponent](m.wpscanRepo.AggregateComponents), utils.WPScanComponentFields)
+}
+
+// aggregateWPScanFindings returns a handler for counting interesting findings by group
+func (m *WPScanModule) aggregateWPScanFindings() gin.HandlerFunc {
+	return common.Aggregate[models.WPScanFinding](repositories.AggregateFunc[models.WPScanFinding](m.wpscanRepo.AggregateFindings), utils.WPScanFindingFields)
+}
+
+// aggregateWPScanVulnerabilities returns a handler for counting vulnerable components by group
+func (m *WPScanModule) aggregateWPScanVulnerabilities() gin.HandlerFunc {
+	return common.Aggregate[models.WPScanVulnerability](repositories.AggregateFunc[models.WPScanVulnerability](m.wpscanRepo.AggregateVulnerabilities), utils.WPScanVulnerabilityFields)
+}
+
+// exportWPScanScans returns a handler for exporting wpscan scans
+func (m *WPScanModule) exportWPScanScans() gin.HandlerFunc {
+	return common.Export(m.wpscanRepo, "wpscan_scans", utils.WPScanScanFields)
+}
+
+// exportWPScanComponents returns a handler for exporting plugins, themes and WordPress versions
+func (m *WPScanModule) exportWPScanComponents() gin.HandlerFunc {
+	return common.Export(repositories.SearchFunc[models.WPScanComponent](m.wpscanRepo.SearchComponents), "wpscan_components", utils.WPScanComponentFields)
+}
+
+// exportWPScanFindings returns a handler for exporting interesting findings
+func (m *WPScanModule) exportWPScanFindings() gin.HandlerFunc {
+	return common.Export(repositories.SearchFunc[models.WPScanFinding](m.wpscanRepo.SearchFindings), "wpscan_findings", utils.WPScanFindingFields)
+}
+
+// exportWPScanVulnerabilities returns a handler for exporting vulnerable components
+func (m *WPScanModule) exportWPScanVulnerabilities() gin.HandlerFunc {
+	return common.Export(repositories.SearchFunc[models.WPScanVulnerability](m.wpscanRepo.SearchVulnerabilities), "wpscan_vulnerabilities", utils.WPScanVulnerabilityFields)
+}
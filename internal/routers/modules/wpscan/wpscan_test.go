@@ -0,0 +1,238 @@
+package wpscan
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const wpscanReport = `{
+  "banner": {"description": "WordPress Security Scanner by the WPScan Team", "version": "3.8.25"},
+  "start_time": 1700000000,
+  "target_url": "https://blog.corp.local/",
+  "target_ip": "10.0.0.1",
+  "effective_url": "https://blog.corp.local/",
+  "interesting_findings": [
+    {"url": "https://blog.corp.local/", "to_s": "Headers", "type": "headers", "found_by": "Headers (Passive Detection)", "confidence": 100, "confirmed_by": {}, "references": {}, "interesting_entries": ["Server: nginx/1.18.0"]},
+    {"url": "https://blog.corp.local/xmlrpc.php", "to_s": "XML-RPC seems to be enabled: https://blog.corp.local/xmlrpc.php", "type": "xmlrpc", "found_by": "Direct Access (Aggressive Detection)", "confidence": 100, "confirmed_by": {}, "references": {"url": ["http://codex.wordpress.org/XML-RPC_Pingback_API"]}, "interesting_entries": []}
+  ],
+  "version": {
+    "number": "5.8.1", "release_date": "2021-09-09", "status": "insecure", "found_by": "Rss Generator (Passive Detection)", "confidence": 100, "interesting_entries": [],
+    "vulnerabilities": [
+      {"title": "WordPress < 5.8.3 - SQL Injection via WP_Query", "fixed_in": "5.8.3", "references": {"cve": ["2022-21661"], "url": ["https://hackerone.com/reports/1378209"], "wpvulndb": ["7f768bcf-ed33-4b22-b432-d1e7f95c1317"]}}
+    ]
+  },
+  "main_theme": {
+    "slug": "twentytwentyone", "location": "https://blog.corp.local/wp-content/themes/twentytwentyone/", "latest_version": "2.1", "outdated": true,
+    "version": {"number": "1.4", "confidence": 80, "interesting_entries": []},
+    "vulnerabilities": []
+  },
+  "plugins": {
+    "wp-file-manager": {
+      "slug": "wp-file-manager", "location": "https://blog.corp.local/wp-content/plugins/wp-file-manager/", "latest_version": "7.2.1", "outdated": true,
+      "version": {"number": "6.0", "confidence": 100, "interesting_entries": []},
+      "vulnerabilities": [
+        {"title": "File Manager 6.0-6.8 - Unauthenticated Remote Code Execution", "fixed_in": "6.9", "references": {"cve": ["2020-25213"], "wpvulndb": ["e528ae38-72f0-49ff-9878-922eff59ace9"]}}
+      ]
+    },
+    "akismet": {
+      "slug": "akismet", "location": "https://blog.corp.local/wp-content/plugins/akismet/", "latest_version": "5.3", "outdated": false,
+      "version": false,
+      "vulnerabilities": []
+    }
+  }
+}
+`
+
+func setupRouter(wpscanRepo *postgres_testing.MockWPScanRepository, nmapRepo *postgres_testing.MockNmapRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	module := &WPScanModule{wpscanRepo: wpscanRepo, nmapRepo: nmapRepo}
+
+	api_group := r.Group("/api")
+	{
+		modules_group := api_group.Group("/modules")
+		{
+			wpscan_group := modules_group.Group("/wpscan")
+			wpscan_group.POST("/search", module.searchWPScanScans())
+			wpscan_group.POST("/components/search", module.searchWPScanComponents())
+			wpscan_group.POST("/findings/search", module.searchWPScanFindings())
+			wpscan_group.POST("/vulnerabilities/search", module.searchWPScanVulnerabilities())
+			wpscan_group.POST("/batch", module.insertWPScanReports())
+		}
+	}
+	return r
+}
+
+func TestSearchWPScan(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockWPScanRepository{}, &postgres_testing.MockNmapRepository{})
+
+	tests := []struct {
+		name           string
+		path           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Search scans by WordPress version",
+			path:           "/search",
+			payload:        `{"search": [{"parameter": "wordpress_version", "operator": "eq", "value": "5.8.1"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept WordPress version searches",
+		},
+		{
+			name:           "Search vulnerable plugin versions",
+			path:           "/vulnerabilities/search",
+			payload:        `{"search": [{"parameter": "component_slug", "operator": "eq", "value": "wp-file-manager"}, {"parameter": "component_version", "operator": "in", "values": ["6.0", "6.8"]}]}`,
+			expectedStatus: 200,
+			description:    "Should accept vulnerable component searches",
+		},
+		{
+			name:           "Search components by slug",
+			path:           "/components/search",
+			payload:        `{"search": [{"parameter": "slug", "operator": "like", "value": "file-manager"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept component searches",
+		},
+		{
+			name:           "Search findings by type",
+			path:           "/findings/search",
+			payload:        `{"search": [{"parameter": "type", "operator": "eq", "value": "xmlrpc"}]}`,
+			expectedStatus: 200,
+			description:    "Should accept finding searches",
+		},
+		{
+			name:           "Unknown component field",
+			path:           "/components/search",
+			payload:        `{"search": [{"parameter": "title", "operator": "eq", "value": "RCE"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject vulnerability fields on components",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/modules/wpscan"+tc.path, bytes.NewBufferString(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestInsertWPScanReports(t *testing.T) {
+	hostID := uuid.New()
+	serviceID := uuid.New()
+
+	var inserted []models.WPScanScan
+	wpscanRepo := &postgres_testing.MockWPScanRepository{
+		InsertScanFn: func(ctx context.Context, scan *models.WPScanScan) error {
+			inserted = append(inserted, *scan)
+			return nil
+		},
+	}
+	nmapRepo := &postgres_testing.MockNmapRepository{
+		FindHostByAddressFn: func(ctx context.Context, project, address string) (*models.NmapHost, error) {
+			assert.Equal(t, "10.0.0.1", address)
+			return &models.NmapHost{HostID: hostID, Host: address}, nil
+		},
+		FindLatestScanResultFn: func(ctx context.Context, id string, port uint16) (*models.ScanResult, error) {
+			assert.Equal(t, uint16(443), port)
+			return &models.ScanResult{HostID: hostID, ServiceID: serviceID, Port: port}, nil
+		},
+	}
+	router := setupRouter(wpscanRepo, nmapRepo)
+
+	req, _ := http.NewRequest("POST", "/api/modules/wpscan/batch", bytes.NewBufferString(wpscanReport))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code, w.Body.String())
+	if !assert.Len(t, inserted, 1) {
+		return
+	}
+
+	scan := inserted[0]
+	assert.Equal(t, &hostID, scan.HostID)
+	assert.Equal(t, &serviceID, scan.ServiceID)
+	assert.Equal(t, uint16(443), scan.Port)
+	assert.Equal(t, int64(1700000000), scan.ScanStart.Unix())
+	assert.Equal(t, "5.8.1", scan.WordPressVersion)
+	assert.Equal(t, "insecure", scan.WordPressStatus)
+	assert.Equal(t, "twentytwentyone", scan.MainTheme)
+	assert.Len(t, scan.Findings, 2)
+
+	// WordPress, the main theme, then plugins sorted by slug
+	expectedComponents := []struct {
+		componentType models.WPScanComponentType
+		slug          string
+		version       string
+	}{
+		{models.WPSCAN_COMPONENT_WORDPRESS, "wordpress", "5.8.1"},
+		{models.WPSCAN_COMPONENT_THEME, "twentytwentyone", "1.4"},
+		{models.WPSCAN_COMPONENT_PLUGIN, "akismet", ""},
+		{models.WPSCAN_COMPONENT_PLUGIN, "wp-file-manager", "6.0"},
+	}
+	if assert.Len(t, scan.Components, len(expectedComponents)) {
+		for i, exp := range expectedComponents {
+			component := scan.Components[i]
+			assert.Equal(t, string(exp.componentType), component.Type)
+			assert.Equal(t, exp.slug, component.Slug)
+			assert.Equal(t, exp.version, component.Version)
+			assert.Equal(t, &hostID, component.HostID)
+		}
+	}
+
+	if assert.Len(t, scan.Vulnerabilities, 2) {
+		wordpress := scan.Vulnerabilities[0]
+		assert.Equal(t, "wordpress", wordpress.ComponentSlug)
+		assert.Equal(t, []string{"CVE-2022-21661"}, []string(wordpress.CVEIDs))
+		assert.Equal(t, scan.Components[0].ComponentID, wordpress.ComponentID)
+
+		plugin := scan.Vulnerabilities[1]
+		assert.Equal(t, "wp-file-manager", plugin.ComponentSlug)
+		assert.Equal(t, "6.0", plugin.ComponentVersion)
+		assert.Equal(t, "6.9", plugin.FixedIn)
+		assert.Equal(t, []string{"CVE-2020-25213"}, []string(plugin.CVEIDs))
+		assert.Equal(t, []string{"e528ae38-72f0-49ff-9878-922eff59ace9"}, plugin.References["wpvulndb"])
+		assert.Equal(t, &hostID, plugin.HostID)
+	}
+}
+
+func TestInsertWPScanReportsInvalidJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "Empty body", payload: ""},
+		{name: "Broken JSON", payload: `{"target_url": `},
+		{name: "Not a wpscan report", payload: `{"url": "https://blog.corp.local/"}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupRouter(&postgres_testing.MockWPScanRepository{}, &postgres_testing.MockNmapRepository{})
+
+			req, _ := http.NewRequest("POST", "/api/modules/wpscan/batch", bytes.NewBufferString(tc.payload))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, 400, w.Code)
+		})
+	}
+}
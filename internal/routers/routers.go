@@ -2,7 +2,16 @@ package routers
 
 import (
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/jobs"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/ffuf"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/hosts"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/httpx"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/masscan"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/nmap"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/nuclei"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/services"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/modules/wpscan"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/searches"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/status"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/widgets/dashboard"
@@ -18,13 +27,21 @@ func SetupRoutes(router *gin.Engine, provider repositories.RepositoryProvider) e
 	// For docker-compose status
 	dashboardRepo := provider.GetRepository("dashboard").(postgres.DashboardRepository)
 	nmapRepo := provider.GetRepository("nmap").(repositories.NmapRepository)
+	jobRepo := provider.GetRepository(repositories.JOB_REPOSITORY).(repositories.JobRepository)
 	router.GET("/ping", status.Ping(
 		dashboardRepo.ReadyCheck(),
 		nmapRepo.ReadyCheck(),
+		jobRepo.ReadyCheck(),
 	))
 
 	// API generic group
 	api_group := router.Group("/api")
+	// Status of uploads imported in the background
+	api_group.GET("/jobs/:id", jobs.GetJob(jobRepo))
+	// Named searches of modules and widgets
+	if err := (&searches.SavedSearches{}).SetupRoutes(api_group.Group("/searches"), provider); err != nil {
+		return err
+	}
 	{
 		// Modules group
 		modules_group := api_group.Group("/modules")
@@ -57,6 +74,13 @@ func SetupRoutes(router *gin.Engine, provider repositories.RepositoryProvider) e
 func getDefaultModules() []config.APIModule {
 	return []config.Module{
 		&nmap.NmapModule{},
+		&nuclei.NucleiModule{},
+		&masscan.MasscanModule{},
+		&httpx.HttpxModule{},
+		&ffuf.FfufModule{},
+		&wpscan.WPScanModule{},
+		&hosts.HostsModule{},
+		&services.ServicesModule{},
 	}
 }
 
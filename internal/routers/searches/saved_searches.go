@@ -0,0 +1,218 @@
+package searches
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// createSavedSearch saves a search of a target, with the variables of its placeholders
+func (s *SavedSearches) createSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		saved, ok := s.bindSavedSearch(c)
+		if !ok {
+			return
+		}
+		saved.SavedSearchID = uuid.New()
+
+		if err := s.savedSearchRepo.CreateSavedSearch(c.Request.Context(), saved); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(201, saved)
+	}
+}
+
+// listSavedSearches returns the saved searches, of a target only with ?target=
+func (s *SavedSearches) listSavedSearches() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		target := c.Query("target")
+		if _, exists := s.targets[target]; target != "" && !exists {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("unknown target: %s", target)})
+			return
+		}
+
+		searches, err := s.savedSearchRepo.ListSavedSearches(c.Request.Context(), target)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, gin.H{"results": searches})
+	}
+}
+
+func (s *SavedSearches) getSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		saved, ok := s.findSavedSearch(c)
+		if !ok {
+			return
+		}
+
+		c.JSON(200, saved)
+	}
+}
+
+// updateSavedSearch replaces a saved search
+func (s *SavedSearches) updateSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		searchID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid saved search ID"})
+			return
+		}
+
+		saved, ok := s.bindSavedSearch(c)
+		if !ok {
+			return
+		}
+		saved.SavedSearchID = searchID
+
+		if !respondSavedSearchError(c, s.savedSearchRepo.UpdateSavedSearch(c.Request.Context(), saved)) {
+			return
+		}
+
+		updated, ok := s.findSavedSearch(c)
+		if !ok {
+			return
+		}
+		c.JSON(200, updated)
+	}
+}
+
+func (s *SavedSearches) deleteSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := uuid.Parse(c.Param("id")); err != nil {
+			c.JSON(400, gin.H{"error": "invalid saved search ID"})
+			return
+		}
+
+		if !respondSavedSearchError(c, s.savedSearchRepo.DeleteSavedSearch(c.Request.Context(), c.Param("id"))) {
+			return
+		}
+
+		c.Status(204)
+	}
+}
+
+// runSavedSearch runs a saved search as the search route of its target does
+// The body is optional: values of the variables, and the page to return
+func (s *SavedSearches) runSavedSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		saved, ok := s.findSavedSearch(c)
+		if !ok {
+			return
+		}
+		target, exists := s.targets[saved.Target]
+		if !exists {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("unknown target: %s", saved.Target)})
+			return
+		}
+
+		var run models.SavedSearchRun
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&run); err != nil {
+				utils.ParseJSONError(c, err)
+				return
+			}
+		}
+
+		params, err := utils.RenderSavedSearch(saved, run.Variables, target.fields...)
+		var queryErr *utils.QueryError
+		if errors.As(err, &queryErr) {
+			utils.RespondQueryError(c, queryErr)
+			return
+		}
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		params.Page = run.Page
+		params.Cursor = run.Cursor
+		if run.PerPage != 0 {
+			params.PerPage = run.PerPage
+		}
+		params.SetDefaults()
+
+		if err := utils.ValidateSearchParamTypesPrecomputed(params, target.fields...); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		target.search(c, params)
+	}
+}
+
+// bindSavedSearch binds and validates a saved search: its target, variables and, if it can without values, its search
+// Invalid saved searches are answered, and ok is false
+func (s *SavedSearches) bindSavedSearch(c *gin.Context) (*models.SavedSearch, bool) {
+	var saved models.SavedSearch
+	if err := c.ShouldBindJSON(&saved); err != nil {
+		utils.ParseJSONError(c, err)
+		return nil, false
+	}
+
+	if !utils.ValidateAndRespond(c, &saved, utils.SavedSearchSchema) {
+		return nil, false
+	}
+
+	target, exists := s.targets[saved.Target]
+	if !exists {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("unknown target: %s", saved.Target)})
+		return nil, false
+	}
+
+	// Given at run time
+	saved.Search.Page = 0
+	saved.Search.Cursor = ""
+
+	err := utils.ValidateSavedSearch(&saved, target.fields...)
+	var queryErr *utils.QueryError
+	if errors.As(err, &queryErr) {
+		utils.RespondQueryError(c, queryErr)
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return &saved, true
+}
+
+// findSavedSearch returns the saved search of the ID of the route
+// Unknown saved searches are answered, and ok is false
+func (s *SavedSearches) findSavedSearch(c *gin.Context) (*models.SavedSearch, bool) {
+	searchID := c.Param("id")
+	if _, err := uuid.Parse(searchID); err != nil {
+		c.JSON(400, gin.H{"error": "invalid saved search ID"})
+		return nil, false
+	}
+
+	saved, err := s.savedSearchRepo.GetSavedSearch(c.Request.Context(), searchID)
+	if !respondSavedSearchError(c, err) {
+		return nil, false
+	}
+	return saved, true
+}
+
+// respondSavedSearchError answers 404 for unknown saved searches, 500 for other errors
+// ok is false if there is an error
+func respondSavedSearchError(c *gin.Context, err error) bool {
+	if errors.As(err, &shiryoku_errors.NotFoundError{}) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
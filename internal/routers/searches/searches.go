@@ -0,0 +1,122 @@
+package searches
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/common"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// SavedSearches are named searches of modules and widgets, run by their ID
+type SavedSearches struct {
+	savedSearchRepo repositories.SavedSearchRepository
+	// Searchable entities, by name (e.g. "nmap")
+	targets map[string]searchTarget
+}
+
+// searchTarget runs the searches of an entity, as its search route does
+type searchTarget struct {
+	fields []map[string]utils.FieldTypeInfo
+	search func(c *gin.Context, params *models.SearchParams)
+}
+
+func newSearchTarget[T any](repo repositories.SearchableRepository[T], fields ...map[string]utils.FieldTypeInfo) searchTarget {
+	return searchTarget{
+		fields: fields,
+		search: func(c *gin.Context, params *models.SearchParams) {
+			common.SearchAndRespond(c, repo, params)
+		},
+	}
+}
+
+func (s *SavedSearches) SetupRoutes(searches_group *gin.RouterGroup, provider repositories.RepositoryProvider) error {
+	savedSearchRepo, err := getRepository[repositories.SavedSearchRepository](provider, repositories.SAVED_SEARCH_REPOSITORY)
+	if err != nil {
+		return err
+	}
+	targets, err := defaultTargets(provider)
+	if err != nil {
+		return err
+	}
+
+	s.savedSearchRepo = savedSearchRepo
+	s.targets = targets
+
+	searches_group.POST("", s.createSavedSearch())
+	searches_group.GET("", s.listSavedSearches())
+	searches_group.GET("/:id", s.getSavedSearch())
+	searches_group.PUT("/:id", s.updateSavedSearch())
+	searches_group.DELETE("/:id", s.deleteSavedSearch())
+	searches_group.POST("/:id/run", s.runSavedSearch())
+
+	return nil
+}
+
+// defaultTargets returns the searches of modules and widgets, with the fields their search routes allow
+func defaultTargets(provider repositories.RepositoryProvider) (map[string]searchTarget, error) {
+	nmapRepo, err := getRepository[repositories.NmapRepository](provider, repositories.NMAP_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	nucleiRepo, err := getRepository[repositories.NucleiRepository](provider, repositories.NUCLEI_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	httpxRepo, err := getRepository[repositories.HttpxRepository](provider, repositories.HTTPX_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	ffufRepo, err := getRepository[repositories.FfufRepository](provider, repositories.FFUF_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	wpscanRepo, err := getRepository[repositories.WPScanRepository](provider, repositories.WPSCAN_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	hostRepo, err := getRepository[repositories.HostRepository](provider, repositories.HOST_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	serviceRepo, err := getRepository[repositories.ServiceRepository](provider, repositories.SERVICE_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+	dashboardRepo, err := getRepository[postgres.DashboardRepository](provider, repositories.DASHBOARD_REPOSITORY)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]searchTarget{
+		"nmap":                   newSearchTarget(nmapRepo, utils.NmapScanFields, utils.NmapScanRelationFields),
+		"nuclei":                 newSearchTarget(nucleiRepo, utils.NucleiFindingFields),
+		"httpx":                  newSearchTarget(httpxRepo, utils.HttpxResultFields),
+		"ffuf":                   newSearchTarget(ffufRepo, utils.FfufResultFields),
+		"wpscan":                 newSearchTarget(wpscanRepo, utils.WPScanScanFields),
+		"wpscan_components":      newSearchTarget(repositories.SearchFunc[models.WPScanComponent](wpscanRepo.SearchComponents), utils.WPScanComponentFields),
+		"wpscan_findings":        newSearchTarget(repositories.SearchFunc[models.WPScanFinding](wpscanRepo.SearchFindings), utils.WPScanFindingFields),
+		"wpscan_vulnerabilities": newSearchTarget(repositories.SearchFunc[models.WPScanVulnerability](wpscanRepo.SearchVulnerabilities), utils.WPScanVulnerabilityFields),
+		"hosts":                  newSearchTarget(hostRepo, utils.NmapHostFields, utils.NmapHostRelationFields),
+		"services":               newSearchTarget(serviceRepo, utils.ServiceFields, utils.ServiceRelationFields),
+		"dashboard":              newSearchTarget(dashboardRepo, utils.WidgetDashboardScanFields),
+	}, nil
+}
+
+func getRepository[R any](provider repositories.RepositoryProvider, name string) (R, error) {
+	var typed R
+	repo := provider.GetRepository(name)
+	if repo == nil {
+		return typed, fmt.Errorf("couldn't import repository %s from provider", name)
+	}
+
+	typed, ok := repo.(R)
+	if !ok {
+		return typed, fmt.Errorf("repository %s is not a %s", name, reflect.TypeFor[R]().Name())
+	}
+	return typed, nil
+}
@@ -0,0 +1,308 @@
+package searches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	postgres_testing "github.com/Robin-Van-de-Merghel/Shiryoku/internal/db/postgres/testing"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/internal/routers/utils"
+	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(savedSearchRepo *postgres_testing.MockSavedSearchRepository, httpxRepo *postgres_testing.MockHttpxRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(utils.ErrorRecoveryMiddleware())
+
+	savedSearches := &SavedSearches{
+		savedSearchRepo: savedSearchRepo,
+		targets: map[string]searchTarget{
+			"httpx": newSearchTarget(httpxRepo, utils.HttpxResultFields),
+		},
+	}
+
+	searches_group := r.Group("/api/searches")
+	searches_group.POST("", savedSearches.createSavedSearch())
+	searches_group.GET("", savedSearches.listSavedSearches())
+	searches_group.GET("/:id", savedSearches.getSavedSearch())
+	searches_group.PUT("/:id", savedSearches.updateSavedSearch())
+	searches_group.DELETE("/:id", savedSearches.deleteSavedSearch())
+	searches_group.POST("/:id/run", savedSearches.runSavedSearch())
+	return r
+}
+
+func request(router *gin.Engine, method, path, payload string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateSavedSearch(t *testing.T) {
+	var created *models.SavedSearch
+	savedSearchRepo := &postgres_testing.MockSavedSearchRepository{
+		CreateSavedSearchFn: func(ctx context.Context, search *models.SavedSearch) error {
+			created = search
+			return nil
+		},
+	}
+	router := setupRouter(savedSearchRepo, &postgres_testing.MockHttpxRepository{})
+
+	payload := `{
+		"name": "Login pages",
+		"description": "Login pages found since a date",
+		"target": "httpx",
+		"search": {"search": [{"or": [{"parameter": "title", "operator": "like", "value": "login"}, {"parameter": "port", "operator": "in", "values": [8443, "{{port}}"]}]}], "page": 3},
+		"query": "timestamp:>={{since}}",
+		"variables": [{"name": "since"}, {"name": "port", "default": "443"}]
+	}`
+	w := request(router, "POST", "/api/searches", payload)
+
+	assert.Equal(t, 201, w.Code)
+	if !assert.NotNil(t, created) {
+		return
+	}
+	assert.NotEqual(t, uuid.Nil, created.SavedSearchID)
+	assert.Equal(t, "httpx", created.Target)
+	// The page is given at run time
+	assert.Equal(t, uint64(0), created.Search.Page)
+
+	// Specs are stored as they are given
+	var saved models.SavedSearch
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+	assert.Equal(t, *created, saved)
+	if assert.Len(t, saved.Search.Search, 1) && assert.NotNil(t, saved.Search.Search[0].Group) {
+		assert.Equal(t, models.OpOr, saved.Search.Search[0].Group.Operator)
+		assert.Equal(t, []any{float64(8443), "{{port}}"}, saved.Search.Search[0].Group.Specs[1].Vector.Values)
+	}
+}
+
+func TestCreateSavedSearchErrors(t *testing.T) {
+	router := setupRouter(&postgres_testing.MockSavedSearchRepository{}, &postgres_testing.MockHttpxRepository{})
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		description    string
+	}{
+		{
+			name:           "Missing name",
+			payload:        `{"target": "httpx", "query": "port:443"}`,
+			expectedStatus: 422,
+			description:    "Should require a name",
+		},
+		{
+			name:           "Unknown target",
+			payload:        `{"name": "RDP", "target": "shodan", "query": "port:3389"}`,
+			expectedStatus: 400,
+			description:    "Should reject targets that can't be searched",
+		},
+		{
+			name:           "Undeclared variable",
+			payload:        `{"name": "Recent", "target": "httpx", "query": "timestamp:>={{since}}"}`,
+			expectedStatus: 400,
+			description:    "Should reject placeholders without variables",
+		},
+		{
+			name:           "Invalid variable name",
+			payload:        `{"name": "Recent", "target": "httpx", "variables": [{"name": "a-b"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject variables that can't be placeholders",
+		},
+		{
+			name:           "Duplicate variable",
+			payload:        `{"name": "Recent", "target": "httpx", "variables": [{"name": "since"}, {"name": "since"}]}`,
+			expectedStatus: 400,
+			description:    "Should reject variables declared twice",
+		},
+		{
+			name:           "Unknown field",
+			payload:        `{"name": "Scans", "target": "httpx", "search": {"search": [{"parameter": "scan_args", "operator": "eq", "value": "-sV"}]}}`,
+			expectedStatus: 400,
+			description:    "Should check searches that don't need values",
+		},
+		{
+			name:           "Invalid default",
+			payload:        `{"name": "Port", "target": "httpx", "query": "port:{{port}}", "variables": [{"name": "port", "default": "https"}]}`,
+			expectedStatus: 400,
+			description:    "Should check searches with their defaults",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := request(router, "POST", "/api/searches", tc.payload)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, tc.description)
+		})
+	}
+}
+
+func TestListSavedSearches(t *testing.T) {
+	var target string
+	savedSearchRepo := &postgres_testing.MockSavedSearchRepository{
+		ListSavedSearchesFn: func(ctx context.Context, t string) ([]models.SavedSearch, error) {
+			target = t
+			return []models.SavedSearch{{SavedSearchID: uuid.New(), Name: "Login pages", Target: t}}, nil
+		},
+	}
+	router := setupRouter(savedSearchRepo, &postgres_testing.MockHttpxRepository{})
+
+	w := request(router, "GET", "/api/searches?target=httpx", "")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "httpx", target)
+
+	var result struct {
+		Results []models.SavedSearch `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	if assert.Len(t, result.Results, 1) {
+		assert.Equal(t, "Login pages", result.Results[0].Name)
+	}
+
+	w = request(router, "GET", "/api/searches?target=shodan", "")
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestUpdateSavedSearch(t *testing.T) {
+	searchID := uuid.New()
+	stored := &models.SavedSearch{SavedSearchID: searchID, Name: "RDP", Target: "httpx", Query: "port:3389"}
+	savedSearchRepo := &postgres_testing.MockSavedSearchRepository{
+		GetSavedSearchFn: func(ctx context.Context, id string) (*models.SavedSearch, error) {
+			return stored, nil
+		},
+		UpdateSavedSearchFn: func(ctx context.Context, search *models.SavedSearch) error {
+			stored = search
+			return nil
+		},
+	}
+	router := setupRouter(savedSearchRepo, &postgres_testing.MockHttpxRepository{})
+
+	w := request(router, "PUT", "/api/searches/"+searchID.String(), `{"name": "RDP and VNC", "target": "httpx", "query": "port:3389,5900"}`)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, searchID, stored.SavedSearchID)
+	assert.Equal(t, "RDP and VNC", stored.Name)
+	assert.Equal(t, "port:3389,5900", stored.Query)
+}
+
+func TestSavedSearchNotFound(t *testing.T) {
+	// Saved searches are unknown by default
+	router := setupRouter(&postgres_testing.MockSavedSearchRepository{
+		DeleteSavedSearchFn: func(ctx context.Context, searchID string) error {
+			return shiryoku_errors.NotFoundError{Resource: "saved search", ID: searchID}
+		},
+	}, &postgres_testing.MockHttpxRepository{})
+	searchID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		payload        string
+		expectedStatus int
+	}{
+		{"Get", "GET", "/api/searches/" + searchID, "", 404},
+		{"Delete", "DELETE", "/api/searches/" + searchID, "", 404},
+		{"Run", "POST", "/api/searches/" + searchID + "/run", "", 404},
+		{"Invalid ID", "GET", "/api/searches/rdp", "", 400},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := request(router, tc.method, tc.path, tc.payload)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRunSavedSearch(t *testing.T) {
+	searchID := uuid.New()
+	savedSearchRepo := &postgres_testing.MockSavedSearchRepository{
+		GetSavedSearchFn: func(ctx context.Context, id string) (*models.SavedSearch, error) {
+			defaultPort := "443"
+			return &models.SavedSearch{
+				SavedSearchID: searchID,
+				Name:          "Recent logins",
+				Target:        "httpx",
+				Search: models.SearchParams{
+					Parameters: []string{"url", "title"},
+					Search: []models.SearchSpec{
+						{Scalar: &models.ScalarSearchSpec{Parameter: "port", Operator: models.OpEq, Value: "{{port}}"}},
+						{Scalar: &models.ScalarSearchSpec{Parameter: "title", Operator: models.OpLike, Value: "{{title}}"}},
+					},
+					PerPage: 20,
+				},
+				Query: "timestamp:>={{since}}",
+				Variables: []models.SearchVariable{
+					{Name: "since"},
+					{Name: "port", Default: &defaultPort},
+					{Name: "title"},
+				},
+			}, nil
+		},
+	}
+	var received *models.SearchParams
+	httpxRepo := &postgres_testing.MockHttpxRepository{
+		SearchFn: func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.HttpxResult], error) {
+			received = params
+			return &models.SearchResult[models.HttpxResult]{Total: 1, Count: models.CountExact, Results: []models.HttpxResult{
+				{URL: "https://10.0.0.1/login", Title: "Intranet - Login"},
+			}}, nil
+		},
+	}
+	router := setupRouter(savedSearchRepo, httpxRepo)
+
+	w := request(router, "POST", "/api/searches/"+searchID.String()+"/run", `{"variables": {"since": "now-7d", "title": "Login page"}, "page": 2}`)
+
+	assert.Equal(t, 200, w.Code)
+	if !assert.NotNil(t, received) {
+		return
+	}
+	assert.Equal(t, uint64(2), received.Page)
+	assert.Equal(t, uint64(20), received.PerPage)
+	if assert.Len(t, received.Search, 3) {
+		// Typed after the field
+		assert.Equal(t, float64(443), received.Search[0].Scalar.Value)
+		assert.Equal(t, "Login page", received.Search[1].Scalar.Value)
+
+		// timestamp >= 7 days ago
+		since, err := time.Parse(time.RFC3339, received.Search[2].Group.Specs[0].Scalar.Value.(string))
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), since, time.Minute)
+	}
+
+	// Only the saved parameters
+	var result models.SearchResult[map[string]any]
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, []map[string]any{{"url": "https://10.0.0.1/login", "title": "Intranet - Login"}}, result.Results)
+
+	// Variables must be known, and given when they don't have a default
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"Missing variable", `{"variables": {"since": "now"}}`},
+		{"Unknown variable", `{"variables": {"since": "now", "title": "Login", "host": "10.0.0.1"}}`},
+		{"Invalid value", `{"variables": {"since": "now", "title": "Login", "port": "https"}}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := request(router, "POST", "/api/searches/"+searchID.String()+"/run", tc.payload)
+
+			assert.Equal(t, 400, w.Code)
+		})
+	}
+}
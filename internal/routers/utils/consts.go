@@ -8,10 +8,44 @@ import (
 // Generate schema from struct
 // Useful for data validation
 var SearchSchema = GenerateSchema(models.SearchParams{})
+var AggregationSchema = GenerateSchema(models.AggregationParams{})
+var SavedSearchSchema = GenerateSchema(models.SavedSearch{})
 
 // Precompute field map
 // Use for Search's parameters: verify that search: {"parameter": "azazazazaza"} exists
 var NmapScanFields = buildFieldTypeMap(models.NmapScan{})
 var NmapHostFields = buildFieldTypeMap(models.NmapHost{})
 var NmapScriptResultFields = buildFieldTypeMap(models.NmapScriptResult{})
+var ScanResultFields = buildFieldTypeMap(models.ScanResult{})
+var ServiceFields = buildFieldTypeMap(models.Service{})
+var NmapOSGuessFields = buildFieldTypeMap(models.NmapOSGuess{})
+var NucleiFindingFields = buildFieldTypeMap(models.NucleiFinding{})
+var HttpxResultFields = buildFieldTypeMap(models.HttpxResult{})
+var FfufResultFields = buildFieldTypeMap(models.FfufResult{})
+var WPScanScanFields = buildFieldTypeMap(models.WPScanScan{})
+var WPScanComponentFields = buildFieldTypeMap(models.WPScanComponent{})
+var WPScanFindingFields = buildFieldTypeMap(models.WPScanFinding{})
+var WPScanVulnerabilityFields = buildFieldTypeMap(models.WPScanVulnerability{})
 var WidgetDashboardScanFields = buildFieldTypeMap(widgets.WidgetDashboardScan{})
+
+// Fields of the relations of a scan, with dotted parameters: {"parameter": "scan_results.port", ...}
+var NmapScanRelationFields = mergeFieldTypeMaps(
+	relatedFieldTypeMap("hosts", NmapHostFields),
+	relatedFieldTypeMap("scan_results", ScanResultFields),
+	relatedFieldTypeMap("service", ServiceFields),
+	relatedFieldTypeMap("scripts", NmapScriptResultFields),
+)
+
+// Fields of the relations of a host, in any of its scans: {"parameter": "scan_results.port", ...}
+var NmapHostRelationFields = mergeFieldTypeMaps(
+	relatedFieldTypeMap("scan_results", ScanResultFields),
+	relatedFieldTypeMap("service", ServiceFields),
+	relatedFieldTypeMap("scripts", NmapScriptResultFields),
+	relatedFieldTypeMap("os_guesses", NmapOSGuessFields),
+)
+
+// Fields of the relations of a service, in any scan: {"parameter": "hosts.host", ...}
+var ServiceRelationFields = mergeFieldTypeMaps(
+	relatedFieldTypeMap("scan_results", ScanResultFields),
+	relatedFieldTypeMap("hosts", NmapHostFields),
+)
@@ -0,0 +1,523 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Query strings, e.g. ?q=port:443 service:https host:10.0.0.0/8 !os:*windows* product:"OpenSSH 7"
+//
+// Terms are separated by spaces and must all match. A term is a field and a value (field:value), negated by a leading ! or -
+// Values are either:
+//   - a single value (eq), or a list of values separated by commas (in)
+//   - a range, both ends included (port:1..1024)
+//   - a comparison (port:>1024, port:<=1024)
+//   - a regex between slashes (title:/^admin/)
+//   - a value with * wildcards, matching anywhere, case insensitive (like)
+//   - a network (in_cidr) or IP range (ip_range), for addresses
+//
+// Quotes keep spaces, commas and dots in values ("OpenSSH 7"), \" and \\ being escaped
+// Array fields are matched element-wise: contains, contains_any for lists and any_like for wildcards
+//
+// Fields the module doesn't have stand for the field of a single relation with that name (port for scan_results.port)
+
+// Field names usual to IVRE users, when the module doesn't have a field of that name
+// They also stand for the field of a relation (e.g. product for service.service_product on scans)
+var queryAliases = map[string]string{
+	"service": "service_name",
+	"product": "service_product",
+	"version": "service_version",
+	"os":      "os_name",
+}
+
+// Fields matching values anywhere, case insensitive, as if they had wildcards (os:windows)
+var querySubstringFields = map[string]bool{
+	"os":      true,
+	"product": true,
+	"version": true,
+}
+
+// Fields searched by their IP address column when the value is an address, a network or an IP range (host:10.0.0.0/8)
+var queryAddressFields = map[string]string{
+	"host": "ip",
+}
+
+// QueryError is a parse error of a query string, at a position (1-based, in characters)
+type QueryError struct {
+	Position int
+	Message  string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Position, e.Message)
+}
+
+// ParseQueryAndRespond parses the q query parameter, if any, and returns 400 if invalid
+// Call this before validating search parameters
+func ParseQueryAndRespond(c *gin.Context, allowedMaps ...map[string]FieldTypeInfo) ([]models.SearchSpec, bool) {
+	query := c.Query("q")
+	if query == "" {
+		return nil, true
+	}
+
+	specs, err := ParseQuery(query, allowedMaps...)
+	if err != nil {
+		RespondQueryError(c, err)
+		return nil, false
+	}
+	return specs, true
+}
+
+// RespondQueryError answers 400 with the position of the error in the query
+func RespondQueryError(c *gin.Context, err *QueryError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code":     http.StatusBadRequest,
+		"message":  "Invalid query",
+		"error":    err.Error(),
+		"position": err.Position,
+	})
+}
+
+// ParseQuery compiles a query string into search specs, typed after the fields of the allowed maps
+func ParseQuery(query string, allowedMaps ...map[string]FieldTypeInfo) ([]models.SearchSpec, *QueryError) {
+	p := &queryParser{input: []rune(query), allowedMaps: allowedMaps}
+
+	var specs []models.SearchSpec
+	for {
+		p.skipSpaces()
+		if p.done() {
+			return specs, nil
+		}
+		spec, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+}
+
+type queryParser struct {
+	input       []rune
+	pos         int
+	allowedMaps []map[string]FieldTypeInfo
+	// Whether the field of the current term matches substrings
+	substring bool
+}
+
+// A value as typed, before being converted to the type of its field
+type queryItem struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+func (p *queryParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *queryParser) peek() rune {
+	return p.input[p.pos]
+}
+
+func (p *queryParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(p.input[p.pos:]), prefix)
+}
+
+func (p *queryParser) skipSpaces() {
+	for !p.done() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *queryParser) errorAt(pos int, format string, args ...any) *QueryError {
+	return &QueryError{Position: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+// term := ["!" | "-"] field ":" value
+func (p *queryParser) parseTerm() (models.SearchSpec, *QueryError) {
+	negated := p.peek() == '!' || p.peek() == '-'
+	if negated {
+		p.pos++
+	}
+
+	nameStart := p.pos
+	// Dotted for relations (scan_results.port)
+	for !p.done() && (unicode.IsLetter(p.peek()) || unicode.IsDigit(p.peek()) || p.peek() == '_' || p.peek() == '.') {
+		p.pos++
+	}
+	name := string(p.input[nameStart:p.pos])
+	if name == "" {
+		return models.SearchSpec{}, p.errorAt(nameStart, "expected a field name")
+	}
+	if p.done() || p.peek() != ':' {
+		return models.SearchSpec{}, p.errorAt(p.pos, "expected ':' after field %q", name)
+	}
+	p.pos++
+
+	parameter, field, ok := p.lookupField(name)
+	if !ok {
+		return models.SearchSpec{}, p.errorAt(nameStart, "unknown field %q", name)
+	}
+	parameter, field = p.addressField(parameter, field)
+	p.substring = querySubstringFields[name]
+
+	spec, err := p.parseValue(parameter, field)
+	if err != nil {
+		return models.SearchSpec{}, err
+	}
+	if negated {
+		spec = notSpec(spec)
+	}
+	return spec, nil
+}
+
+// lookupField resolves a field name: a field of the module, an IVRE alias, or the field of a single relation
+func (p *queryParser) lookupField(name string) (string, FieldTypeInfo, bool) {
+	if field, ok := lookupField(name, p.allowedMaps); ok {
+		return name, field, true
+	}
+	alias, aliased := queryAliases[name]
+	if aliased {
+		if field, ok := lookupField(alias, p.allowedMaps); ok {
+			return alias, field, true
+		}
+	}
+
+	// Or the field of a single relation, e.g. scan_results.port for port on scans
+	if parameter, field, ok := p.lookupRelatedField(name); ok {
+		return parameter, field, true
+	}
+	if aliased {
+		return p.lookupRelatedField(alias)
+	}
+	return "", FieldTypeInfo{}, false
+}
+
+// lookupRelatedField returns the only field of a relation with this name, if any
+func (p *queryParser) lookupRelatedField(name string) (string, FieldTypeInfo, bool) {
+	var related []string
+	for _, fields := range p.allowedMaps {
+		for parameter := range fields {
+			if strings.HasSuffix(parameter, "."+name) && !slices.Contains(related, parameter) {
+				related = append(related, parameter)
+			}
+		}
+	}
+	if len(related) != 1 {
+		return "", FieldTypeInfo{}, false
+	}
+	field, _ := lookupField(related[0], p.allowedMaps)
+	return related[0], field, true
+}
+
+// addressField returns the IP address column next to a text field (hosts.ip for hosts.host),
+// when the value of the term is an address, a network or an IP range. Other values keep the text field
+func (p *queryParser) addressField(parameter string, field FieldTypeInfo) (string, FieldTypeInfo) {
+	if field.GoType == ipAddressType {
+		return parameter, field
+	}
+	prefix, name := "", parameter
+	if i := strings.LastIndex(parameter, "."); i != -1 {
+		prefix, name = parameter[:i+1], parameter[i+1:]
+	}
+	column, ok := queryAddressFields[name]
+	if !ok {
+		return parameter, field
+	}
+	address, ok := lookupField(prefix+column, p.allowedMaps)
+	if !ok || address.GoType != ipAddressType {
+		return parameter, field
+	}
+
+	// Peek at the (first) value
+	start := p.pos
+	item, err := p.parseItem()
+	p.pos = start
+	if err != nil || !isAddressValue(item.text) {
+		return parameter, field
+	}
+	return prefix + column, address
+}
+
+// isAddressValue tells whether a value is an IP address, a CIDR or an IP range
+func isAddressValue(value string) bool {
+	if _, err := netip.ParseAddr(value); err == nil {
+		return true
+	}
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return true
+	}
+	_, _, err := models.ParseIPRange(value)
+	return err == nil
+}
+
+// value := comparison item | "/" regex "/" | item ".." item | item ("," item)*
+func (p *queryParser) parseValue(parameter string, field FieldTypeInfo) (models.SearchSpec, *QueryError) {
+	if !p.done() && p.peek() == '/' {
+		return p.parseRegex(parameter, field)
+	}
+
+	comparison := ""
+	for _, operator := range []string{">=", "<=", ">", "<"} {
+		if p.hasPrefix(operator) {
+			comparison = operator
+			p.pos += len(operator)
+			break
+		}
+	}
+
+	first, err := p.parseItem()
+	if err != nil {
+		return models.SearchSpec{}, err
+	}
+	items := []queryItem{first}
+
+	switch {
+	case comparison != "":
+	case p.hasPrefix(".."):
+		p.pos += len("..")
+		last, err := p.parseItem()
+		if err != nil {
+			return models.SearchSpec{}, err
+		}
+		if err := p.expectEndOfTerm(); err != nil {
+			return models.SearchSpec{}, err
+		}
+		return p.compileRange(parameter, field, first, last)
+	default:
+		for !p.done() && p.peek() == ',' {
+			p.pos++
+			item, err := p.parseItem()
+			if err != nil {
+				return models.SearchSpec{}, err
+			}
+			items = append(items, item)
+		}
+	}
+
+	if err := p.expectEndOfTerm(); err != nil {
+		return models.SearchSpec{}, err
+	}
+	if comparison != "" {
+		return p.compileComparison(parameter, field, comparison, first)
+	}
+	if len(items) > 1 {
+		return p.compileList(parameter, field, items)
+	}
+	return p.compileItem(parameter, field, first)
+}
+
+// item := '"' quoted '"' | bare
+// Bare items end at spaces, commas and ranges
+func (p *queryParser) parseItem() (queryItem, *QueryError) {
+	start := p.pos
+	if !p.done() && p.peek() == '"' {
+		p.pos++
+		var text strings.Builder
+		for {
+			if p.done() {
+				return queryItem{}, p.errorAt(start, "unterminated quote")
+			}
+			r := p.peek()
+			p.pos++
+			if r == '"' {
+				return queryItem{text: text.String(), quoted: true, pos: start}, nil
+			}
+			if r == '\\' && !p.done() && (p.peek() == '"' || p.peek() == '\\') {
+				r = p.peek()
+				p.pos++
+			}
+			text.WriteRune(r)
+		}
+	}
+
+	for !p.done() && !unicode.IsSpace(p.peek()) && p.peek() != ',' && !p.hasPrefix("..") {
+		p.pos++
+	}
+	if p.pos == start {
+		return queryItem{}, p.errorAt(start, "expected a value")
+	}
+	return queryItem{text: string(p.input[start:p.pos]), pos: start}, nil
+}
+
+func (p *queryParser) parseRegex(parameter string, field FieldTypeInfo) (models.SearchSpec, *QueryError) {
+	start := p.pos
+	p.pos++
+
+	var pattern strings.Builder
+	for {
+		if p.done() {
+			return models.SearchSpec{}, p.errorAt(start, "unterminated regex")
+		}
+		r := p.peek()
+		p.pos++
+		if r == '/' {
+			break
+		}
+		// Escaped slashes are part of the regex
+		if r == '\\' && !p.done() && p.peek() == '/' {
+			r = p.peek()
+			p.pos++
+		}
+		pattern.WriteRune(r)
+	}
+
+	if err := p.expectEndOfTerm(); err != nil {
+		return models.SearchSpec{}, err
+	}
+	if field.JSONKind != reflect.String && field.JSONKind != reflect.Interface {
+		return models.SearchSpec{}, p.errorAt(start, "regexes only apply to text fields, not %s", parameter)
+	}
+	return scalarSpec(parameter, models.OpRegex, pattern.String()), nil
+}
+
+func (p *queryParser) expectEndOfTerm() *QueryError {
+	if !p.done() && !unicode.IsSpace(p.peek()) {
+		return p.errorAt(p.pos, "unexpected %q", p.peek())
+	}
+	return nil
+}
+
+func (p *queryParser) compileItem(parameter string, field FieldTypeInfo, item queryItem) (models.SearchSpec, *QueryError) {
+	wildcard := strings.Contains(item.text, "*")
+
+	if field.ElemKind != reflect.Invalid {
+		if wildcard {
+			return scalarSpec(parameter, models.OpAnyLike, strings.ReplaceAll(item.text, "*", "")), nil
+		}
+		value, err := p.convert(item, field.ElemKind)
+		if err != nil {
+			return models.SearchSpec{}, err
+		}
+		return scalarSpec(parameter, models.OpContains, value), nil
+	}
+
+	if field.GoType == ipAddressType {
+		switch {
+		case strings.Contains(item.text, "/"):
+			return scalarSpec(parameter, models.OpInCIDR, item.text), nil
+		case strings.Contains(item.text, "-"):
+			return scalarSpec(parameter, models.OpIPRange, item.text), nil
+		}
+	}
+
+	if wildcard || (p.substring && field.JSONKind == reflect.String) {
+		return scalarSpec(parameter, models.OpLike, strings.ReplaceAll(item.text, "*", "")), nil
+	}
+	value, err := p.convert(item, field.JSONKind)
+	if err != nil {
+		return models.SearchSpec{}, err
+	}
+	return scalarSpec(parameter, models.OpEq, value), nil
+}
+
+func (p *queryParser) compileList(parameter string, field FieldTypeInfo, items []queryItem) (models.SearchSpec, *QueryError) {
+	kind, operator := field.JSONKind, models.OpIn
+	if field.ElemKind != reflect.Invalid {
+		kind, operator = field.ElemKind, models.OpContainsAny
+	}
+
+	values := make([]any, 0, len(items))
+	for _, item := range items {
+		value, err := p.convert(item, kind)
+		if err != nil {
+			return models.SearchSpec{}, err
+		}
+		values = append(values, value)
+	}
+	return models.SearchSpec{Vector: &models.VectorSearchSpec{Parameter: parameter, Operator: operator, Values: values}}, nil
+}
+
+// Both ends included: neither lower than the first, nor greater than the last
+func (p *queryParser) compileRange(parameter string, field FieldTypeInfo, first, last queryItem) (models.SearchSpec, *QueryError) {
+	if field.GoType == ipAddressType {
+		return scalarSpec(parameter, models.OpIPRange, first.text+"-"+last.text), nil
+	}
+
+	low, err := p.convert(first, field.JSONKind)
+	if err != nil {
+		return models.SearchSpec{}, err
+	}
+	high, err := p.convert(last, field.JSONKind)
+	if err != nil {
+		return models.SearchSpec{}, err
+	}
+	return models.SearchSpec{Group: &models.GroupSearchSpec{
+		Operator: models.OpAnd,
+		Specs: []models.SearchSpec{
+			notSpec(scalarSpec(parameter, models.OpLt, low)),
+			notSpec(scalarSpec(parameter, models.OpGt, high)),
+		},
+	}}, nil
+}
+
+func (p *queryParser) compileComparison(parameter string, field FieldTypeInfo, comparison string, item queryItem) (models.SearchSpec, *QueryError) {
+	value, err := p.convert(item, field.JSONKind)
+	if err != nil {
+		return models.SearchSpec{}, err
+	}
+
+	switch comparison {
+	case ">":
+		return scalarSpec(parameter, models.OpGt, value), nil
+	case "<":
+		return scalarSpec(parameter, models.OpLt, value), nil
+	case ">=":
+		return notSpec(scalarSpec(parameter, models.OpLt, value)), nil
+	default:
+		return notSpec(scalarSpec(parameter, models.OpGt, value)), nil
+	}
+}
+
+// Converts an item to the JSON type of its field, as if it was given in a JSON search
+func (p *queryParser) convert(item queryItem, kind reflect.Kind) (any, *QueryError) {
+	value, err := convertText(item.text, item.quoted, kind)
+	if err != nil {
+		return nil, p.errorAt(item.pos, "%s", err)
+	}
+	return value, nil
+}
+
+// convertText converts text to a JSON kind, untyped fields (interface) being numbers unless quoted
+func convertText(text string, quoted bool, kind reflect.Kind) (any, error) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", text)
+		}
+		return value, nil
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", text)
+		}
+		return value, nil
+	case reflect.Interface:
+		if value, err := strconv.ParseFloat(text, 64); err == nil && !quoted {
+			return value, nil
+		}
+		return text, nil
+	default:
+		return text, nil
+	}
+}
+
+func scalarSpec(parameter string, operator models.ScalarOperator, value any) models.SearchSpec {
+	return models.SearchSpec{Scalar: &models.ScalarSearchSpec{Parameter: parameter, Operator: operator, Value: value}}
+}
+
+func notSpec(spec models.SearchSpec) models.SearchSpec {
+	return models.SearchSpec{Group: &models.GroupSearchSpec{Operator: models.OpNot, Specs: []models.SearchSpec{spec}}}
+}
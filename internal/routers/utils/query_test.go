@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func scalar(parameter string, operator models.ScalarOperator, value any) models.SearchSpec {
+	return scalarSpec(parameter, operator, value)
+}
+
+func vector(parameter string, operator models.VectorOperator, values ...any) models.SearchSpec {
+	return models.SearchSpec{Vector: &models.VectorSearchSpec{Parameter: parameter, Operator: operator, Values: values}}
+}
+
+func TestParseQuery(t *testing.T) {
+	httpx := []map[string]FieldTypeInfo{HttpxResultFields}
+	nuclei := []map[string]FieldTypeInfo{NucleiFindingFields}
+	scans := []map[string]FieldTypeInfo{NmapScanFields, NmapScanRelationFields}
+	hosts := []map[string]FieldTypeInfo{NmapHostFields, NmapHostRelationFields}
+
+	tests := []struct {
+		name        string
+		query       string
+		allowedMaps []map[string]FieldTypeInfo
+		expected    []models.SearchSpec
+	}{
+		{
+			name:        "Empty",
+			query:       "   ",
+			allowedMaps: httpx,
+			expected:    nil,
+		},
+		{
+			name:        "Value",
+			query:       `port:443 web_server:"Apache httpd"`,
+			allowedMaps: httpx,
+			expected: []models.SearchSpec{
+				scalar("port", models.OpEq, 443.0),
+				scalar("web_server", models.OpEq, "Apache httpd"),
+			},
+		},
+		{
+			name:        "List",
+			query:       "port:443,8443",
+			allowedMaps: httpx,
+			expected:    []models.SearchSpec{vector("port", models.OpIn, 443.0, 8443.0)},
+		},
+		{
+			name:        "Range",
+			query:       "status_code:200..299",
+			allowedMaps: httpx,
+			expected: []models.SearchSpec{{Group: &models.GroupSearchSpec{Operator: models.OpAnd, Specs: []models.SearchSpec{
+				notSpec(scalar("status_code", models.OpLt, 200.0)),
+				notSpec(scalar("status_code", models.OpGt, 299.0)),
+			}}}},
+		},
+		{
+			name:        "Comparisons",
+			query:       "port:>1024 port:<=2048",
+			allowedMaps: httpx,
+			expected: []models.SearchSpec{
+				scalar("port", models.OpGt, 1024.0),
+				notSpec(scalar("port", models.OpGt, 2048.0)),
+			},
+		},
+		{
+			name:        "Negated wildcard",
+			query:       "!title:*login* -title:/^admin\\//",
+			allowedMaps: httpx,
+			expected: []models.SearchSpec{
+				notSpec(scalar("title", models.OpLike, "login")),
+				notSpec(scalar("title", models.OpRegex, "^admin/")),
+			},
+		},
+		{
+			name:        "Arrays",
+			query:       "tls_subject_an:intranet.corp.local tls_subject_an:*corp*",
+			allowedMaps: httpx,
+			expected: []models.SearchSpec{
+				scalar("tls_subject_an", models.OpContains, "intranet.corp.local"),
+				scalar("tls_subject_an", models.OpAnyLike, "corp"),
+			},
+		},
+		{
+			name:        "Networks",
+			query:       "host:10.0.0.0/8 host:10.0.0.1-10.0.0.9 host:10.1.0.1..10.1.0.9",
+			allowedMaps: httpx,
+			expected: []models.SearchSpec{
+				scalar("host", models.OpInCIDR, "10.0.0.0/8"),
+				scalar("host", models.OpIPRange, "10.0.0.1-10.0.0.9"),
+				scalar("host", models.OpIPRange, "10.1.0.1-10.1.0.9"),
+			},
+		},
+		{
+			name:        "Field of a single relation",
+			query:       "port:443 service:https",
+			allowedMaps: scans,
+			expected: []models.SearchSpec{
+				scalar("scan_results.port", models.OpEq, 443.0),
+				scalar("service.service_name", models.OpEq, "https"),
+			},
+		},
+		{
+			name:        "Related host by network",
+			query:       "host:10.0.0.0/8 host:10.0.0.1 host:router",
+			allowedMaps: scans,
+			expected: []models.SearchSpec{
+				scalar("hosts.ip", models.OpInCIDR, "10.0.0.0/8"),
+				scalar("hosts.ip", models.OpEq, "10.0.0.1"),
+				scalar("hosts.host", models.OpEq, "router"),
+			},
+		},
+		{
+			name:        "Host by network",
+			query:       "host:10.0.0.0/8 host:10.0.0.1,10.0.0.2 port:22",
+			allowedMaps: hosts,
+			expected: []models.SearchSpec{
+				scalar("ip", models.OpInCIDR, "10.0.0.0/8"),
+				vector("ip", models.OpIn, "10.0.0.1", "10.0.0.2"),
+				scalar("scan_results.port", models.OpEq, 22.0),
+			},
+		},
+		{
+			name:        "Host next to its IP",
+			query:       "host:10.0.0.1..10.0.0.9 host:*.corp.local",
+			allowedMaps: nuclei,
+			expected: []models.SearchSpec{
+				scalar("ip", models.OpIPRange, "10.0.0.1-10.0.0.9"),
+				scalar("host", models.OpLike, ".corp.local"),
+			},
+		},
+		{
+			name:        "Substrings",
+			query:       `os:windows product:"OpenSSH 7" version:8,9`,
+			allowedMaps: hosts,
+			expected: []models.SearchSpec{
+				scalar("os_name", models.OpLike, "windows"),
+				scalar("service.service_product", models.OpLike, "OpenSSH 7"),
+				vector("service.service_version", models.OpIn, "8", "9"),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			specs, err := ParseQuery(tc.query, tc.allowedMaps...)
+			if assert.Nil(t, err) {
+				assert.Equal(t, tc.expected, specs)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		allowedMaps      []map[string]FieldTypeInfo
+		expectedPosition int
+	}{
+		{name: "Unknown field", query: "port:443 scan_args:-sV", allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 10},
+		{name: "Field of several relations", query: "host_id:x", allowedMaps: []map[string]FieldTypeInfo{NmapScanRelationFields}, expectedPosition: 1},
+		{name: "Missing field", query: ":443", allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 1},
+		{name: "Missing colon", query: "port:443 title", allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 15},
+		{name: "Missing value", query: "port: title:x", allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 6},
+		{name: "Unterminated quote", query: `title:"Intranet`, allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 7},
+		{name: "Unterminated regex", query: `title:/^admin`, allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 7},
+		{name: "Regex on numbers", query: `port:/44/`, allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 6},
+		{name: "Wrong type", query: "status_code:2xx", allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 13},
+		{name: "List of ranges", query: "port:80..90,100", allowedMaps: []map[string]FieldTypeInfo{HttpxResultFields}, expectedPosition: 12},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseQuery(tc.query, tc.allowedMaps...)
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tc.expectedPosition, err.Position, err.Message)
+			}
+		})
+	}
+}
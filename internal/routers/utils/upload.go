@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Name of the file sent as a raw request body
+const RAW_BODY_FILENAME = "body"
+
+// SaveUploadedFiles stores the files of a multipart upload (whatever their field), or the raw body as a single file
+// Files are streamed to dir, each in its own numbered directory (cf common.ForEachStoredFile) to keep their name and order
+// Compressed files and archives are stored as they are, to be expanded when imported
+// Bodies of more than maxSize bytes fail with an *http.MaxBytesError
+func SaveUploadedFiles(c *gin.Context, dir string, maxSize int64) error {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return saveFile(dir, 0, RAW_BODY_FILENAME, c.Request.Body)
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return fmt.Errorf("invalid multipart upload: %w", err)
+	}
+
+	count := 0
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid multipart upload: %w", err)
+		}
+
+		// Only files are kept, not the other form fields
+		if part.FileName() != "" {
+			if err := saveFile(dir, count, part.FileName(), part); err != nil {
+				return err
+			}
+			count++
+		}
+		part.Close()
+	}
+
+	if count == 0 {
+		return errors.New("no file uploaded")
+	}
+	return nil
+}
+
+func saveFile(dir string, index int, name string, r io.Reader) error {
+	fileDir := filepath.Join(dir, fmt.Sprintf("%06d", index))
+	if err := os.MkdirAll(fileDir, 0o700); err != nil {
+		return fmt.Errorf("failed to store %s: %w", name, err)
+	}
+
+	// Only the base name, clients can send paths
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == string(filepath.Separator) {
+		name = RAW_BODY_FILENAME
+	}
+	file, err := os.Create(filepath.Join(fileDir, name))
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return fmt.Errorf("failed to store %s: %w", name, err)
+	}
+	return nil
+}
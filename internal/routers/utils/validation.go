@@ -3,9 +3,13 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/netip"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	shiryoku_errors "github.com/Robin-Van-de-Merghel/Shiryoku/pkg/errors"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
@@ -80,7 +84,8 @@ type FieldTypeInfo struct {
 	JSONName string
 	GORMName string
 	GoType   reflect.Type
-	JSONKind reflect.Kind // expected JSON kind (string, float64, bool)
+	JSONKind reflect.Kind // expected JSON kind (string, float64, bool), slice for arrays
+	ElemKind reflect.Kind // for arrays (e.g. pq.StringArray), expected JSON kind of their elements
 }
 
 // buildFieldTypeMap returns JSON kind info for a struct
@@ -117,9 +122,11 @@ func buildFieldTypeMap(s any) map[string]FieldTypeInfo {
 			}
 		}
 
-		kind := f.Type.Kind()
-		if kind == reflect.Int || kind == reflect.Int64 || kind == reflect.Float32 || kind == reflect.Float64 {
-			kind = reflect.Float64 // JSON numbers are float64
+		kind := jsonKind(f.Type)
+		// Byte slices aren't arrays, but bytea
+		var elemKind reflect.Kind
+		if kind == reflect.Slice && f.Type.Elem().Kind() != reflect.Uint8 {
+			elemKind = jsonKind(f.Type.Elem())
 		}
 
 		fields[jsonTag] = FieldTypeInfo{
@@ -127,51 +134,206 @@ func buildFieldTypeMap(s any) map[string]FieldTypeInfo {
 			GORMName: column,
 			GoType:   f.Type,
 			JSONKind: kind,
+			ElemKind: elemKind,
 		}
 	}
 
 	return fields
 }
 
+// JSON kind of a Go type
+func jsonKind(t reflect.Type) reflect.Kind {
+	kind := t.Kind()
+	if kind == reflect.Int || kind == reflect.Int64 || kind == reflect.Float32 || kind == reflect.Float64 {
+		kind = reflect.Float64 // JSON numbers are float64
+	}
+	return kind
+}
+
 func ValidateSearchParamTypesPrecomputed(params *models.SearchParams, allowedMaps ...map[string]FieldTypeInfo) error {
 	for _, spec := range params.Search {
-		// Scalar
-		if spec.Scalar != nil {
-			var found FieldTypeInfo
-			var ok bool
-			for _, m := range allowedMaps {
-				if info, exists := m[spec.Scalar.Parameter]; exists {
-					found = info
-					ok = true
-					break
-				}
+		if err := validateSearchSpec(&spec, allowedMaps); err != nil {
+			return err
+		}
+	}
+
+	// Selected columns
+	for _, parameter := range params.Parameters {
+		found, ok := lookupField(parameter, allowedMaps)
+		if !ok {
+			return fmt.Errorf("invalid parameter: %s", parameter)
+		}
+		// Relations (e.g. hosts of a scan) aren't columns
+		if found.ElemKind == reflect.Struct || strings.Contains(parameter, ".") {
+			return fmt.Errorf("parameter %s can't be selected", parameter)
+		}
+	}
+	for _, sort := range params.Sort {
+		found, ok := lookupField(sort.Parameter, allowedMaps)
+		if !ok {
+			return fmt.Errorf("invalid sort parameter: %s", sort.Parameter)
+		}
+		// A scan has many hosts: there isn't one to sort by
+		if found.ElemKind == reflect.Struct || strings.Contains(sort.Parameter, ".") {
+			return fmt.Errorf("can't sort by related field %s", sort.Parameter)
+		}
+		// Directions are case insensitive, and ascending by default
+		if direction := models.SortDirection(strings.ToLower(string(sort.Direction))); direction != "" && !direction.IsValid() {
+			return fmt.Errorf("invalid sort direction: %s", sort.Direction)
+		}
+	}
+	if params.Distinct {
+		if len(params.Parameters) == 0 {
+			return fmt.Errorf("distinct needs parameters")
+		}
+		// SELECT DISTINCT can only be ordered by selected columns
+		for _, sort := range params.Sort {
+			if !slices.Contains(params.Parameters, sort.Parameter) {
+				return fmt.Errorf("sort parameter %s must be in parameters with distinct", sort.Parameter)
 			}
-			if !ok {
-				return fmt.Errorf("invalid search parameter: %s", spec.Scalar.Parameter)
+		}
+	}
+
+	if params.Count != "" && !params.Count.IsValid() {
+		return fmt.Errorf("invalid count: %s", params.Count)
+	}
+	if params.Cursor != "" {
+		return validateCursor(params)
+	}
+	return nil
+}
+
+// validateCursor checks that the cursor comes from the same sort (the primary key being added after it)
+func validateCursor(params *models.SearchParams) error {
+	if params.Distinct {
+		return fmt.Errorf("cursors can't be used with distinct")
+	}
+	if params.Page > 1 {
+		return fmt.Errorf("cursors replace page")
+	}
+
+	cursor, err := models.DecodeCursor(params.Cursor)
+	if err != nil {
+		return err
+	}
+	if len(cursor.Keys) < len(params.Sort) {
+		return fmt.Errorf("cursor doesn't match the sort")
+	}
+	for i, sort := range params.Sort {
+		if cursor.Keys[i] != sort.Parameter {
+			return fmt.Errorf("cursor doesn't match the sort")
+		}
+	}
+	return nil
+}
+
+// ValidateAggregationParams checks the search specs like ValidateSearchParamTypesPrecomputed,
+// and that buckets are made of columns (and time fields for an interval)
+func ValidateAggregationParams(params *models.AggregationParams, allowedMaps ...map[string]FieldTypeInfo) error {
+	for _, spec := range params.Search {
+		if err := validateSearchSpec(&spec, allowedMaps); err != nil {
+			return err
+		}
+	}
+
+	if params.Interval != "" && !params.Interval.IsValid() {
+		return fmt.Errorf("invalid interval: %s", params.Interval)
+	}
+
+	bucketed := false
+	for _, parameter := range params.GroupBy {
+		found, ok := lookupField(parameter, allowedMaps)
+		if !ok {
+			return fmt.Errorf("invalid group_by parameter: %s", parameter)
+		}
+		// Neither relations nor arrays
+		if found.ElemKind != reflect.Invalid {
+			return fmt.Errorf("can't group by %s", parameter)
+		}
+		if found.GoType == timeType || found.GoType == reflect.PointerTo(timeType) {
+			bucketed = true
+		}
+	}
+	if params.Interval != "" && !bucketed {
+		return fmt.Errorf("interval %s needs a time field in group_by", params.Interval)
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// relatedFieldTypeMap prefixes the fields of a relation with its name (e.g. "hosts.host"), but not its own relations
+func relatedFieldTypeMap(relation string, fields map[string]FieldTypeInfo) map[string]FieldTypeInfo {
+	related := make(map[string]FieldTypeInfo, len(fields))
+	for name, info := range fields {
+		if info.ElemKind == reflect.Struct {
+			continue
+		}
+		info.JSONName = relation + "." + name
+		related[info.JSONName] = info
+	}
+	return related
+}
+
+// mergeFieldTypeMaps returns the fields of all the maps, the last ones winning on duplicated names
+func mergeFieldTypeMaps(fieldMaps ...map[string]FieldTypeInfo) map[string]FieldTypeInfo {
+	merged := make(map[string]FieldTypeInfo)
+	for _, fields := range fieldMaps {
+		maps.Copy(merged, fields)
+	}
+	return merged
+}
+
+// lookupField returns the first field of the allowed maps with this name
+func lookupField(parameter string, allowedMaps []map[string]FieldTypeInfo) (FieldTypeInfo, bool) {
+	for _, m := range allowedMaps {
+		if info, exists := m[parameter]; exists {
+			return info, true
+		}
+	}
+	return FieldTypeInfo{}, false
+}
+
+// validateSearchSpec checks a spec, and every spec nested in its group
+func validateSearchSpec(spec *models.SearchSpec, allowedMaps []map[string]FieldTypeInfo) error {
+	// Scalar
+	if spec.Scalar != nil {
+		found, ok := lookupField(spec.Scalar.Parameter, allowedMaps)
+		if !ok {
+			return fmt.Errorf("invalid search parameter: %s", spec.Scalar.Parameter)
+		}
+		if spec.Scalar.Operator.IsArray() {
+			if err := validateArraySearch(spec.Scalar.Parameter, string(spec.Scalar.Operator), []any{spec.Scalar.Value}, found); err != nil {
+				return err
 			}
-			if !isJSONValueCompatible(spec.Scalar.Value, found.JSONKind) {
-				return fmt.Errorf("value for %s must be %s", spec.Scalar.Parameter, found.JSONKind)
+		} else if !isJSONValueCompatible(spec.Scalar.Value, found.JSONKind) {
+			return fmt.Errorf("value for %s must be %s", spec.Scalar.Parameter, found.JSONKind)
+		}
+		if spec.Scalar.Operator.IsNetwork() {
+			if err := validateNetworkSearch(spec.Scalar, found); err != nil {
+				return err
 			}
 		}
+	}
 
-		// Vector
-		if spec.Vector != nil {
-			var found FieldTypeInfo
-			var ok bool
-			for _, m := range allowedMaps {
-				if info, exists := m[spec.Vector.Parameter]; exists {
-					found = info
-					ok = true
-					break
-				}
-			}
-			if !ok {
-				return fmt.Errorf("invalid search parameter: %s", spec.Vector.Parameter)
+	// Vector
+	if spec.Vector != nil {
+		found, ok := lookupField(spec.Vector.Parameter, allowedMaps)
+		if !ok {
+			return fmt.Errorf("invalid search parameter: %s", spec.Vector.Parameter)
+		}
+		values, ok := spec.Vector.Values.([]any)
+		if !ok {
+			return fmt.Errorf("values for %s must be array", spec.Vector.Parameter)
+		}
+		if spec.Vector.Operator.IsArray() {
+			if len(values) == 0 {
+				return fmt.Errorf("values for %s can't be empty", spec.Vector.Parameter)
 			}
-			values, ok := spec.Vector.Values.([]any)
-			if !ok {
-				return fmt.Errorf("values for %s must be array", spec.Vector.Parameter)
+			if err := validateArraySearch(spec.Vector.Parameter, string(spec.Vector.Operator), values, found); err != nil {
+				return err
 			}
+		} else {
 			for _, v := range values {
 				if !isJSONValueCompatible(v, found.JSONKind) {
 					return fmt.Errorf("value in %s must be %s", spec.Vector.Parameter, found.JSONKind)
@@ -179,6 +341,58 @@ func ValidateSearchParamTypesPrecomputed(params *models.SearchParams, allowedMap
 			}
 		}
 	}
+
+	// Group
+	if spec.Group != nil {
+		for i := range spec.Group.Specs {
+			if err := validateSearchSpec(&spec.Group.Specs[i], allowedMaps); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Array operators need an array field, and values of the type of its elements
+// any_like only matches text
+func validateArraySearch(parameter, operator string, values []any, field FieldTypeInfo) error {
+	switch field.ElemKind {
+	case reflect.String, reflect.Float64, reflect.Bool:
+	default:
+		return fmt.Errorf("operator %s only applies to arrays, not %s", operator, parameter)
+	}
+	if models.ScalarOperator(operator) == models.OpAnyLike && field.ElemKind != reflect.String {
+		return fmt.Errorf("operator %s only applies to text arrays, not %s", operator, parameter)
+	}
+
+	for _, v := range values {
+		if v == nil || !isJSONValueCompatible(v, field.ElemKind) {
+			return fmt.Errorf("value in %s must be %s", parameter, field.ElemKind)
+		}
+	}
+	return nil
+}
+
+var ipAddressType = reflect.TypeOf(models.IPAddress(""))
+
+// Network operators need an IP address field, and a valid CIDR (IPv4 or IPv6) or range
+func validateNetworkSearch(spec *models.ScalarSearchSpec, field FieldTypeInfo) error {
+	if field.GoType != ipAddressType {
+		return fmt.Errorf("operator %s only applies to IP addresses, not %s", spec.Operator, spec.Parameter)
+	}
+	value, ok := spec.Value.(string)
+	if !ok {
+		return fmt.Errorf("value for %s must be string", spec.Parameter)
+	}
+
+	if spec.Operator == models.OpIPRange {
+		_, _, err := models.ParseIPRange(value)
+		return err
+	}
+	if _, err := netip.ParsePrefix(value); err != nil {
+		return fmt.Errorf("invalid CIDR for %s: %w", spec.Parameter, err)
+	}
 	return nil
 }
 
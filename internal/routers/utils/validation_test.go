@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSearchParamsSort(t *testing.T) {
+	scripts := []map[string]FieldTypeInfo{NmapScriptResultFields}
+	scans := []map[string]FieldTypeInfo{NmapScanFields, NmapScanRelationFields}
+
+	tests := []struct {
+		name        string
+		sort        models.SortSpec
+		allowedMaps []map[string]FieldTypeInfo
+		valid       bool
+	}{
+		{name: "JSON name", sort: models.SortSpec{Parameter: "id", Direction: models.DirDESC}, allowedMaps: scripts, valid: true},
+		{name: "Default direction", sort: models.SortSpec{Parameter: "output"}, allowedMaps: scripts, valid: true},
+		{name: "Upper case direction", sort: models.SortSpec{Parameter: "scan_start", Direction: "DESC"}, allowedMaps: scans, valid: true},
+		{name: "Column instead of JSON name", sort: models.SortSpec{Parameter: "script_id", Direction: models.DirASC}, allowedMaps: scripts},
+		{name: "Unknown parameter", sort: models.SortSpec{Parameter: `id" desc; --`, Direction: models.DirASC}, allowedMaps: scripts},
+		{name: "Related field", sort: models.SortSpec{Parameter: "hosts.host", Direction: models.DirASC}, allowedMaps: scans},
+		{name: "Relation", sort: models.SortSpec{Parameter: "scan_results", Direction: models.DirASC}, allowedMaps: []map[string]FieldTypeInfo{NmapHostFields}},
+		{name: "Invalid direction", sort: models.SortSpec{Parameter: "id", Direction: "asc, (SELECT 1)"}, allowedMaps: scripts},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSearchParamTypesPrecomputed(&models.SearchParams{Sort: []models.SortSpec{tc.sort}}, tc.allowedMaps...)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+)
+
+// Variables of saved searches, e.g. first_seen:>={{since}}, filled in at run time
+//
+// A value of a search spec which is a whole placeholder ("{{port}}") takes the type of its field
+// Elsewhere (e.g. "*{{domain}}*", and in query strings), placeholders are replaced by the text of the value
+// Relative times (now, now-24h, now-7d, now-2w) are resolved when the search is run
+
+var (
+	placeholderPattern  = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	relativeTimePattern = regexp.MustCompile(`^now(?:-([0-9]+)([smhdw]))?$`)
+)
+
+var relativeTimeUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ValidateSavedSearch checks the variables of a saved search, and its search when every variable has a default
+// Searches with required variables are only fully checked when run
+func ValidateSavedSearch(saved *models.SavedSearch, allowedMaps ...map[string]FieldTypeInfo) error {
+	declared := make([]string, 0, len(saved.Variables))
+	required := false
+	for _, variable := range saved.Variables {
+		if !variableNamePattern.MatchString(variable.Name) {
+			return fmt.Errorf("invalid variable name: %q", variable.Name)
+		}
+		if slices.Contains(declared, variable.Name) {
+			return fmt.Errorf("duplicate variable: %s", variable.Name)
+		}
+		declared = append(declared, variable.Name)
+		required = required || variable.Default == nil
+	}
+
+	for _, name := range savedSearchPlaceholders(saved) {
+		if !slices.Contains(declared, name) {
+			return fmt.Errorf("undeclared variable: %s", name)
+		}
+	}
+
+	if required {
+		return nil
+	}
+	params, err := RenderSavedSearch(saved, nil, allowedMaps...)
+	if err != nil {
+		return err
+	}
+	return ValidateSearchParamTypesPrecomputed(params, allowedMaps...)
+}
+
+// RenderSavedSearch fills in the variables of a saved search, and returns its search with the specs of its query
+// Variables without a value take their default. Query errors are *QueryError
+func RenderSavedSearch(saved *models.SavedSearch, values map[string]string, allowedMaps ...map[string]FieldTypeInfo) (*models.SearchParams, error) {
+	resolved, err := resolveVariables(saved.Variables, values)
+	if err != nil {
+		return nil, err
+	}
+
+	params := saved.Search
+	params.Search = make([]models.SearchSpec, 0, len(saved.Search.Search))
+	for _, spec := range saved.Search.Search {
+		rendered, err := renderSpec(spec, resolved, allowedMaps)
+		if err != nil {
+			return nil, err
+		}
+		params.Search = append(params.Search, rendered)
+	}
+
+	if saved.Query != "" {
+		query, err := renderQuery(saved.Query, resolved)
+		if err != nil {
+			return nil, err
+		}
+		specs, queryErr := ParseQuery(query, allowedMaps...)
+		if queryErr != nil {
+			return nil, queryErr
+		}
+		params.Search = append(params.Search, specs...)
+	}
+
+	return &params, nil
+}
+
+// resolveVariables returns the value of every variable, relative times being resolved
+func resolveVariables(variables []models.SearchVariable, values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(variables))
+	for _, variable := range variables {
+		value, given := values[variable.Name]
+		if !given {
+			if variable.Default == nil {
+				return nil, fmt.Errorf("missing variable: %s", variable.Name)
+			}
+			value = *variable.Default
+		}
+		resolved[variable.Name] = resolveRelativeTime(value)
+	}
+
+	for name := range values {
+		if _, declared := resolved[name]; !declared {
+			return nil, fmt.Errorf("unknown variable: %s", name)
+		}
+	}
+	return resolved, nil
+}
+
+// resolveRelativeTime returns the time (RFC 3339) of now, now-24h, now-7d..., other values as they are
+func resolveRelativeTime(value string) string {
+	match := relativeTimePattern.FindStringSubmatch(value)
+	if match == nil {
+		return value
+	}
+
+	now := time.Now().UTC()
+	if match[1] != "" {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			return value
+		}
+		now = now.Add(-time.Duration(amount) * relativeTimeUnits[match[2]])
+	}
+	return now.Format(time.RFC3339)
+}
+
+func renderSpec(spec models.SearchSpec, values map[string]string, allowedMaps []map[string]FieldTypeInfo) (models.SearchSpec, error) {
+	switch {
+	case spec.Scalar != nil:
+		scalar := *spec.Scalar
+		value, err := renderValue(scalar.Value, placeholderKind(scalar.Parameter, scalar.Operator.IsArray(), allowedMaps), values)
+		if err != nil {
+			return models.SearchSpec{}, fmt.Errorf("value for %s: %w", scalar.Parameter, err)
+		}
+		scalar.Value = value
+		return models.SearchSpec{Scalar: &scalar}, nil
+
+	case spec.Vector != nil:
+		vector := *spec.Vector
+		if list, ok := vector.Values.([]any); ok {
+			kind := placeholderKind(vector.Parameter, vector.Operator.IsArray(), allowedMaps)
+			rendered := make([]any, 0, len(list))
+			for _, item := range list {
+				value, err := renderValue(item, kind, values)
+				if err != nil {
+					return models.SearchSpec{}, fmt.Errorf("value in %s: %w", vector.Parameter, err)
+				}
+				rendered = append(rendered, value)
+			}
+			vector.Values = rendered
+		}
+		return models.SearchSpec{Vector: &vector}, nil
+
+	case spec.Group != nil:
+		group := &models.GroupSearchSpec{Operator: spec.Group.Operator, Specs: make([]models.SearchSpec, 0, len(spec.Group.Specs))}
+		for _, nested := range spec.Group.Specs {
+			rendered, err := renderSpec(nested, values, allowedMaps)
+			if err != nil {
+				return models.SearchSpec{}, err
+			}
+			group.Specs = append(group.Specs, rendered)
+		}
+		return models.SearchSpec{Group: group}, nil
+
+	default:
+		return spec, nil
+	}
+}
+
+// placeholderKind returns the JSON kind of the values of a field, its elements' for array operators
+func placeholderKind(parameter string, array bool, allowedMaps []map[string]FieldTypeInfo) reflect.Kind {
+	field, ok := lookupField(parameter, allowedMaps)
+	if !ok {
+		return reflect.Interface
+	}
+	if array {
+		return field.ElemKind
+	}
+	return field.JSONKind
+}
+
+func renderValue(value any, kind reflect.Kind, values map[string]string) (any, error) {
+	text, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+
+	// A whole placeholder takes the type of its field
+	if match := placeholderPattern.FindStringSubmatch(text); match != nil && match[0] == text {
+		resolved, declared := values[match[1]]
+		if !declared {
+			return nil, fmt.Errorf("undeclared variable: %s", match[1])
+		}
+		return convertText(resolved, false, kind)
+	}
+
+	var err error
+	rendered := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		resolved, declared := values[name]
+		if !declared {
+			err = fmt.Errorf("undeclared variable: %s", name)
+		}
+		return resolved
+	})
+	return rendered, err
+}
+
+// renderQuery replaces the placeholders of a query string
+// Values with spaces or quotes are quoted, unless the placeholder already is (title:"{{title}}")
+func renderQuery(query string, values map[string]string) (string, error) {
+	var rendered strings.Builder
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(query, -1) {
+		name := query[match[2]:match[3]]
+		value, declared := values[name]
+		if !declared {
+			return "", fmt.Errorf("undeclared variable: %s", name)
+		}
+
+		rendered.WriteString(query[last:match[0]])
+		quoted := match[0] > 0 && query[match[0]-1] == '"'
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+		switch {
+		case quoted:
+			rendered.WriteString(escaped)
+		case strings.ContainsAny(value, " \t\n\"\\"):
+			rendered.WriteString(`"` + escaped + `"`)
+		default:
+			rendered.WriteString(value)
+		}
+		last = match[1]
+	}
+	rendered.WriteString(query[last:])
+	return rendered.String(), nil
+}
+
+// savedSearchPlaceholders returns the names of the placeholders of a saved search
+func savedSearchPlaceholders(saved *models.SavedSearch) []string {
+	var names []string
+	collect := func(text string) {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			names = append(names, match[1])
+		}
+	}
+
+	collect(saved.Query)
+	var walk func(specs []models.SearchSpec)
+	walk = func(specs []models.SearchSpec) {
+		for _, spec := range specs {
+			switch {
+			case spec.Scalar != nil:
+				if text, ok := spec.Scalar.Value.(string); ok {
+					collect(text)
+				}
+			case spec.Vector != nil:
+				list, _ := spec.Vector.Values.([]any)
+				for _, item := range list {
+					if text, ok := item.(string); ok {
+						collect(text)
+					}
+				}
+			case spec.Group != nil:
+				walk(spec.Group.Specs)
+			}
+		}
+	}
+	walk(saved.Search.Search)
+	return names
+}
@@ -10,3 +10,7 @@ import (
 func (w *DashboardWidget) getDashboardData() gin.HandlerFunc {
 	return common.Search[widgets.WidgetDashboardScan](w.dasboardRepo, utils.WidgetDashboardScanFields)
 }
+
+func (w *DashboardWidget) aggregateDashboardData() gin.HandlerFunc {
+	return common.Aggregate[widgets.WidgetDashboardScan](w.dasboardRepo, utils.WidgetDashboardScanFields)
+}
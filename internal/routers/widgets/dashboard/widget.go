@@ -34,6 +34,7 @@ func (w *DashboardWidget) SetupRoutes(dashboard_group *gin.RouterGroup, provider
 	w.dasboardRepo = nmapRepo
 
 	dashboard_group.POST("/search", w.getDashboardData())
+	dashboard_group.POST("/aggregate", w.aggregateDashboardData())
 
 	return nil
 }
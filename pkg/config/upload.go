@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Default maximum size of an upload, as sent (compressed files and archives aren't expanded yet)
+const DEFAULT_MAX_UPLOAD_SIZE = 1 << 30
+
+// GetUploadDir returns where uploads are kept until a worker imports them
+// The API and the workers must share it (e.g. a docker volume)
+func GetUploadDir() string {
+	return GetEnv("UPLOAD_DIR", filepath.Join(os.TempDir(), "shiryoku-uploads"))
+}
+
+// GetMaxUploadSize returns the maximum size in bytes of the body of an upload request
+func GetMaxUploadSize() int64 {
+	if value := os.Getenv("MAX_UPLOAD_SIZE"); value != "" {
+		if size, err := strconv.ParseInt(value, 10, 64); err == nil && size > 0 {
+			return size
+		}
+	}
+	return DEFAULT_MAX_UPLOAD_SIZE
+}
@@ -15,6 +15,8 @@ type WorkerConfig struct {
 	DBConfig DBConfig
 	// Refresh frequency in seconds
 	Frequency time.Duration
+	// How often queued ingestion jobs are looked for
+	JobFrequency time.Duration
 	// Log level
 	LogLevel LogLevelT
 }
@@ -33,6 +35,18 @@ func NewWorkerConfig() (*WorkerConfig, error) {
 		return nil, fmt.Errorf("freqStr must be positive")
 	}
 
+	// Default job frequency: 2 seconds
+	jobFrequency := 2
+	if freqStr := os.Getenv("JOB_POLL_FREQUENCY"); freqStr != "" {
+		if f, err := strconv.Atoi(freqStr); err == nil {
+			jobFrequency = f
+		}
+	}
+
+	if jobFrequency <= 0 {
+		return nil, fmt.Errorf("JOB_POLL_FREQUENCY must be positive")
+	}
+
 	// Default log level: DEBUG
 	logLevel := LOG_LEVEL_DEBUG
 	if levelStr := os.Getenv("LOG_LEVEL"); levelStr != "" {
@@ -53,7 +67,8 @@ func NewWorkerConfig() (*WorkerConfig, error) {
 			Password: GetEnv("DB_PASSWORD", "shiryoku"),
 			Database: GetEnv("DB_NAME", "shiryoku"),
 		},
-		Frequency: time.Duration(frequency) * time.Second,
-		LogLevel:  LogLevelT(logLevel),
+		Frequency:    time.Duration(frequency) * time.Second,
+		JobFrequency: time.Duration(jobFrequency) * time.Second,
+		LogLevel:     LogLevelT(logLevel),
 	}, nil
 }
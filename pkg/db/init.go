@@ -15,6 +15,14 @@ func InitDB(dsn string) (*repositories.DefaultRepositoryProvider, error) {
 
 	provider := repositories.NewRepositoryProvider()
 	provider.RegisterRepository(repositories.NMAP_REPOSITORY, postgres.NewNmapRepository(db))
+	provider.RegisterRepository(repositories.HOST_REPOSITORY, postgres.NewHostRepository(db))
+	provider.RegisterRepository(repositories.SERVICE_REPOSITORY, postgres.NewServiceRepository(db))
+	provider.RegisterRepository(repositories.NUCLEI_REPOSITORY, postgres.NewNucleiRepository(db))
+	provider.RegisterRepository(repositories.HTTPX_REPOSITORY, postgres.NewHttpxRepository(db))
+	provider.RegisterRepository(repositories.FFUF_REPOSITORY, postgres.NewFfufRepository(db))
+	provider.RegisterRepository(repositories.WPSCAN_REPOSITORY, postgres.NewWPScanRepository(db))
+	provider.RegisterRepository(repositories.JOB_REPOSITORY, postgres.NewJobRepository(db))
+	provider.RegisterRepository(repositories.SAVED_SEARCH_REPOSITORY, postgres.NewSavedSearchRepository(db))
 	// TODO: See if we call it from init (as it's internal)
 	provider.RegisterRepository(repositories.DASHBOARD_REPOSITORY, postgres.NewDashboardRepository(db))
 
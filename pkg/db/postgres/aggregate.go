@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Column of the number of rows of each bucket, unlikely to be a group_by field
+const bucketCountColumn = "bucket_count"
+
+// Aggregate is a generic function counting the rows of a simple table matching AggregationParams, by group
+// With an interval, time fields are truncated (e.g. date_trunc('day', "first_seen")) and buckets sorted chronologically
+func Aggregate[T any](ctx context.Context, db *gorm.DB, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return AggregateRelated[T](ctx, db, params, nil)
+}
+
+// AggregateRelated is Aggregate, with specs and group_by fields of related tables (e.g. "scan_results.port")
+// Related rows of group_by fields are joined, and each bucket counts the distinct rows of T having them,
+// e.g. the hosts by open port: {"group_by": ["scan_results.port"], "search": [{"parameter": "scan_results.port_state", ...}]}
+func AggregateRelated[T any](ctx context.Context, db *gorm.DB, params *models.AggregationParams, relations []Relation[T]) (*models.AggregationResult, error) {
+	params.SetDefaults()
+
+	builder := NewSearchBuilder(db.WithContext(ctx), relations...)
+	query, counted, err := builder.aggregate(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Select(fmt.Sprintf("COUNT(%s)", counted)).Scan(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count records: %w", err)
+	}
+
+	var rows []map[string]any
+	if err := builder.buckets(query, params, counted).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate records: %w", err)
+	}
+
+	result := &models.AggregationResult{
+		Total:   uint64(total),
+		Buckets: make([]models.AggregationBucket, 0, len(rows)),
+	}
+	for _, row := range rows {
+		count, _ := row[bucketCountColumn].(int64)
+		delete(row, bucketCountColumn)
+		result.Buckets = append(result.Buckets, models.AggregationBucket{Key: row, Count: uint64(count)})
+	}
+
+	return result, nil
+}
+
+// aggregate returns the query of the rows to count, with the relations of the group_by fields joined,
+// and what is counted: * without joins, else the distinct primary keys of T
+func (sb *SearchBuilder[T]) aggregate(params *models.AggregationParams) (*gorm.DB, string, error) {
+	modelSchema, err := schema.Parse(new(T), schemaCache, sb.db.NamingStrategy)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse model: %w", err)
+	}
+
+	var joins []string
+	for _, field := range params.GroupBy {
+		relation, column, err := sb.relation(field)
+		if err != nil {
+			return nil, "", err
+		}
+		// Group names are pasted in the query
+		if relation == nil && sb.field(column) == nil || relation != nil && sb.related(relation).field(column) == nil {
+			return nil, "", fmt.Errorf("unknown group_by parameter: %s", field)
+		}
+		if relation == nil || sb.joined[relation.rows()] {
+			continue
+		}
+		if sb.joined == nil {
+			sb.joined = make(map[string]bool)
+		}
+		sb.joined[relation.rows()] = true
+		joins = append(joins, relation.join())
+	}
+
+	counted := "*"
+	if len(joins) > 0 {
+		// Columns of T are qualified, as joined tables share some (e.g. host_id)
+		sb.table = modelSchema.Table
+		if modelSchema.PrioritizedPrimaryField == nil {
+			return nil, "", fmt.Errorf("can't group %s by related fields", modelSchema.Table)
+		}
+		counted = "DISTINCT " + sb.column(modelSchema.PrioritizedPrimaryField.DBName)
+	}
+
+	query, err := sb.Filter(params.Search)
+	if err != nil {
+		return nil, "", err
+	}
+	query = query.Model(new(T))
+	for _, join := range joins {
+		query = query.Joins(join)
+	}
+	return query, counted, nil
+}
+
+// buckets returns the query counting the rows of each combination of the group_by fields, keyed by their names
+// With an interval, the latest buckets are kept, oldest first
+func (sb *SearchBuilder[T]) buckets(query *gorm.DB, params *models.AggregationParams, counted string) *gorm.DB {
+	groups := make([]string, 0, len(params.GroupBy))
+	selects := make([]string, 0, len(params.GroupBy)+1)
+	chronological := false
+	for _, field := range params.GroupBy {
+		builder, column := sb, field
+		// Checked by aggregate
+		if relation, related, _ := sb.relation(field); relation != nil {
+			builder, column = sb.related(relation), related
+		}
+
+		group := builder.column(column)
+		if params.Interval != "" && builder.dataType(column) == schema.Time {
+			group = fmt.Sprintf("date_trunc('%s', %s)", params.Interval, group)
+			chronological = true
+		}
+		groups = append(groups, group)
+		selects = append(selects, fmt.Sprintf("%s AS \"%s\"", group, field))
+	}
+	selects = append(selects, fmt.Sprintf("COUNT(%s) AS \"%s\"", counted, bucketCountColumn))
+
+	order := fmt.Sprintf("\"%s\" DESC, %s", bucketCountColumn, strings.Join(groups, ", "))
+	if chronological {
+		// The latest buckets are kept, then sorted back oldest first
+		order = strings.Join(groups, " DESC, ") + " DESC"
+	}
+
+	query = query.
+		Select(strings.Join(selects, ", ")).
+		Group(strings.Join(groups, ", ")).
+		Order(order).
+		Limit(int(params.Limit))
+	if !chronological {
+		return query
+	}
+
+	aliases := make([]string, 0, len(params.GroupBy))
+	for _, field := range params.GroupBy {
+		aliases = append(aliases, fmt.Sprintf("\"%s\"", field))
+	}
+	return query.Session(&gorm.Session{NewDB: true}).
+		Table("(?) AS buckets", query).
+		Order(strings.Join(aliases, ", "))
+}
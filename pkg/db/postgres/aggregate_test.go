@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// aggregateSQL returns the SQL and vars of the buckets of an aggregation of T
+func aggregateSQL[T any](t *testing.T, params *models.AggregationParams, relations ...Relation[T]) (string, []any, error) {
+	params.SetDefaults()
+	builder := NewSearchBuilder(dryRunDB(t), relations...)
+	query, counted, err := builder.aggregate(params)
+	if err != nil {
+		return "", nil, err
+	}
+	statement := builder.buckets(query, params, counted).Find(&[]map[string]any{}).Statement
+	return statement.SQL.String(), statement.Vars, nil
+}
+
+// Relations of hosts, as in the host repository
+var testHostRelations = []Relation[models.NmapHost]{
+	{Name: "scan_results", Model: &models.ScanResult{}, Table: "nmap_scan_results", From: "nmap_scan_results LEFT JOIN nmap_services ON nmap_services.service_id = nmap_scan_results.service_id", Where: "nmap_scan_results.host_id = nmap_hosts.host_id"},
+	{Name: "service", Model: &models.Service{}, Table: "nmap_services", From: "nmap_scan_results LEFT JOIN nmap_services ON nmap_services.service_id = nmap_scan_results.service_id", Where: "nmap_scan_results.host_id = nmap_hosts.host_id"},
+	{Name: "os_guesses", Model: &models.NmapOSGuess{}, Table: "nmap_os_guesses", From: "nmap_os_guesses", Where: "nmap_os_guesses.host_id = nmap_hosts.host_id"},
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name         string
+		params       models.AggregationParams
+		expectedSQL  string
+		expectedVars []any
+	}{
+		{
+			name: "Hosts per OS",
+			params: models.AggregationParams{
+				GroupBy: []string{"os_name"},
+				Search:  []models.SearchSpec{scalar("host_status", models.OpEq, "up")},
+			},
+			expectedSQL:  `SELECT "os_name" AS "os_name", COUNT(*) AS "bucket_count" FROM "nmap_hosts" WHERE "host_status" = $1 GROUP BY "os_name" ORDER BY "bucket_count" DESC, "os_name" LIMIT $2`,
+			expectedVars: []any{"up", 100},
+		},
+		{
+			name: "Top open ports",
+			params: models.AggregationParams{
+				GroupBy: []string{"scan_results.port"},
+				Search:  []models.SearchSpec{scalar("scan_results.port_state", models.OpEq, "open"), scalar("host_status", models.OpEq, "up")},
+				Limit:   20,
+			},
+			expectedSQL: `SELECT "nmap_scan_results"."port" AS "scan_results.port", COUNT(DISTINCT "nmap_hosts"."host_id") AS "bucket_count" FROM "nmap_hosts" ` +
+				`JOIN (nmap_scan_results LEFT JOIN nmap_services ON nmap_services.service_id = nmap_scan_results.service_id) ON nmap_scan_results.host_id = nmap_hosts.host_id ` +
+				`WHERE "nmap_scan_results"."port_state" = $1 AND "nmap_hosts"."host_status" = $2 GROUP BY "nmap_scan_results"."port" ORDER BY "bucket_count" DESC, "nmap_scan_results"."port" LIMIT $3`,
+			expectedVars: []any{"open", "up", 20},
+		},
+		{
+			name: "Fields of rows joined once",
+			params: models.AggregationParams{
+				GroupBy: []string{"service.service_product", "scan_results.port"},
+				Search:  []models.SearchSpec{group(models.OpOr, scalar("scan_results.port", models.OpEq, 80.0), scalar("os_guesses.family", models.OpEq, "Linux"))},
+			},
+			expectedSQL: `SELECT "nmap_services"."service_product" AS "service.service_product", "nmap_scan_results"."port" AS "scan_results.port", COUNT(DISTINCT "nmap_hosts"."host_id") AS "bucket_count" FROM "nmap_hosts" ` +
+				`JOIN (nmap_scan_results LEFT JOIN nmap_services ON nmap_services.service_id = nmap_scan_results.service_id) ON nmap_scan_results.host_id = nmap_hosts.host_id ` +
+				`WHERE ("nmap_scan_results"."port" = $1 OR EXISTS (SELECT 1 FROM nmap_os_guesses WHERE nmap_os_guesses.host_id = nmap_hosts.host_id AND "nmap_os_guesses"."family" = $2)) ` +
+				`GROUP BY "nmap_services"."service_product", "nmap_scan_results"."port" ORDER BY "bucket_count" DESC, "nmap_services"."service_product", "nmap_scan_results"."port" LIMIT $3`,
+			expectedVars: []any{80.0, "Linux", 100},
+		},
+		{
+			name:         "New hosts per day",
+			params:       models.AggregationParams{GroupBy: []string{"first_seen"}, Interval: models.IntervalDay},
+			expectedSQL:  `SELECT * FROM (SELECT date_trunc('day', "first_seen") AS "first_seen", COUNT(*) AS "bucket_count" FROM "nmap_hosts" GROUP BY date_trunc('day', "first_seen") ORDER BY date_trunc('day', "first_seen") DESC LIMIT $1) AS buckets ORDER BY "first_seen"`,
+			expectedVars: []any{100},
+		},
+		{
+			// More days than buckets: the latest ones are kept, not the oldest ones
+			name:         "New hosts by OS over the last days",
+			params:       models.AggregationParams{GroupBy: []string{"first_seen", "os_name"}, Interval: models.IntervalDay, Limit: 30},
+			expectedSQL:  `SELECT * FROM (SELECT date_trunc('day', "first_seen") AS "first_seen", "os_name" AS "os_name", COUNT(*) AS "bucket_count" FROM "nmap_hosts" GROUP BY date_trunc('day', "first_seen"), "os_name" ORDER BY date_trunc('day', "first_seen") DESC, "os_name" DESC LIMIT $1) AS buckets ORDER BY "first_seen", "os_name"`,
+			expectedVars: []any{30},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, vars, err := aggregateSQL(t, &tc.params, testHostRelations...)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedSQL, sql)
+				assert.Equal(t, tc.expectedVars, vars)
+			}
+		})
+	}
+}
+
+func TestAggregateColumns(t *testing.T) {
+	// Buckets are keyed by JSON names, of other columns
+	sql, _, err := aggregateSQL[models.NmapScriptResult](t, &models.AggregationParams{GroupBy: []string{"id"}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `SELECT "script_id" AS "id", COUNT(*) AS "bucket_count" FROM "nmap_nse_scripts" GROUP BY "script_id" ORDER BY "bucket_count" DESC, "script_id" LIMIT $1`, sql)
+	}
+
+	for _, groupBy := range []string{`os_name" FROM pg_user --`, "scan_results.unknown", "scripts.id"} {
+		_, _, err := aggregateSQL(t, &models.AggregationParams{GroupBy: []string{groupBy}}, testHostRelations...)
+		assert.Error(t, err, groupBy)
+	}
+}
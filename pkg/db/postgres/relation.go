@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"gorm.io/gorm/clause"
+)
+
+// Relation allows searching T by the fields of related rows, with dotted parameters (e.g. "hosts.host")
+// Related specs compile to EXISTS (SELECT 1 FROM <From> WHERE <Where> AND ...)
+type Relation[T any] struct {
+	// Prefix of the parameters, e.g. "hosts"
+	Name string
+	// Related model and its table in From, for the columns of the conditions
+	Model any
+	Table string
+	// Related rows of a searched row, e.g. "nmap_scan_results" and "nmap_scan_results.scan_id = nmap_scans.scan_id"
+	// Specs of relations with the same rows, ANDed together, are matched by the same row
+	From  string
+	Where string
+}
+
+func (r *Relation[T]) rows() string {
+	return r.From + " WHERE " + r.Where
+}
+
+// exists returns the condition of a related row matching all the conditions
+func (r *Relation[T]) exists(conditions []clause.Expr) clause.Expr {
+	sql := fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s", r.From, r.Where)
+	vars := make([]any, 0, len(conditions))
+	for _, condition := range conditions {
+		sql += " AND ?"
+		vars = append(vars, condition)
+	}
+	return clause.Expr{SQL: sql + ")", Vars: vars}
+}
+
+// join returns the JOIN of the related rows, for aggregations by their fields
+func (r *Relation[T]) join() string {
+	// Rows made of several tables are parenthesised, e.g. JOIN (a LEFT JOIN b ON ...) ON ...
+	if strings.Contains(r.From, " ") {
+		return fmt.Sprintf("JOIN (%s) ON %s", r.From, r.Where)
+	}
+	return fmt.Sprintf("JOIN %s ON %s", r.From, r.Where)
+}
+
+// relation returns the relation of a dotted parameter, and the parameter on its own columns
+// nil if the parameter isn't on a relation
+func (sb *SearchBuilder[T]) relation(parameter string) (*Relation[T], string, error) {
+	name, column, dotted := strings.Cut(parameter, ".")
+	if !dotted {
+		return nil, parameter, nil
+	}
+	for i := range sb.relations {
+		if sb.relations[i].Name == name {
+			return &sb.relations[i], column, nil
+		}
+	}
+	return nil, "", fmt.Errorf("unknown relation: %s", name)
+}
+
+// relatedSpec returns the relation of a dotted parameter, and the spec on its own columns
+// nil if the spec isn't on a relation
+func (sb *SearchBuilder[T]) relatedSpec(spec *models.SearchSpec) (*Relation[T], *models.SearchSpec, error) {
+	var parameter string
+	switch {
+	case spec.Scalar != nil:
+		parameter = spec.Scalar.Parameter
+	case spec.Vector != nil:
+		parameter = spec.Vector.Parameter
+	default:
+		return nil, nil, nil
+	}
+
+	relation, column, err := sb.relation(parameter)
+	if relation == nil || err != nil {
+		return nil, nil, err
+	}
+	related := &models.SearchSpec{}
+	if spec.Scalar != nil {
+		scalar := *spec.Scalar
+		scalar.Parameter = column
+		related.Scalar = &scalar
+	} else {
+		vector := *spec.Vector
+		vector.Parameter = column
+		related.Vector = &vector
+	}
+	return relation, related, nil
+}
+
+// related returns the builder of the conditions on the columns of a relation
+// Relations don't have relations themselves
+func (sb *SearchBuilder[T]) related(relation *Relation[T]) *SearchBuilder[T] {
+	return &SearchBuilder[T]{db: sb.db, model: relation.Model, table: relation.Table}
+}
+
+// andConditions returns the conditions of specs ANDed together
+// Specs of relations with the same rows are matched by the same row, e.g. the port and the product of the same scan result
+// Specs of joined relations are matched by the joined row
+func (sb *SearchBuilder[T]) andConditions(specs []models.SearchSpec) ([]clause.Expr, error) {
+	conditions := make([]clause.Expr, 0, len(specs))
+	var relations []*Relation[T]
+	related := make(map[string][]clause.Expr)
+
+	for i := range specs {
+		relation, spec, err := sb.relatedSpec(&specs[i])
+		if err != nil {
+			return nil, err
+		}
+		if relation == nil {
+			condition, err := sb.buildCondition(&specs[i])
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, condition)
+			continue
+		}
+
+		condition, err := sb.related(relation).buildCondition(spec)
+		if err != nil {
+			return nil, err
+		}
+		if sb.joined[relation.rows()] {
+			conditions = append(conditions, condition)
+			continue
+		}
+		if _, exists := related[relation.rows()]; !exists {
+			relations = append(relations, relation)
+		}
+		related[relation.rows()] = append(related[relation.rows()], condition)
+	}
+
+	for _, relation := range relations {
+		conditions = append(conditions, relation.exists(related[relation.rows()]))
+	}
+	return conditions, nil
+}
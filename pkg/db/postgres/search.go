@@ -2,16 +2,28 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 type SearchBuilder[T any] struct {
 	db *gorm.DB
+	// Model and table of the columns of the conditions: T, unqualified, or a relation
+	model     any
+	table     string
+	relations []Relation[T]
+	// Rows of the relations joined to T (see Relation.rows), whose specs are matched by the joined row instead of EXISTS
+	joined map[string]bool
 }
 
 type Preload[T any] struct {
@@ -19,74 +31,390 @@ type Preload[T any] struct {
 	Fn          func(*gorm.DB) *gorm.DB
 }
 
-func NewSearchBuilder[T any](db *gorm.DB) *SearchBuilder[T] {
-	return &SearchBuilder[T]{db: db}
+func NewSearchBuilder[T any](db *gorm.DB, relations ...Relation[T]) *SearchBuilder[T] {
+	return &SearchBuilder[T]{db: db, model: new(T), relations: relations}
 }
 
 func (sb *SearchBuilder[T]) Build(params *models.SearchParams) (*gorm.DB, error) {
-	query := sb.db
+	query, err := sb.Filter(params.Search)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, spec := range params.Search {
-		if spec.Scalar != nil {
-			var err error
-			query, err = sb.applyScalarFilter(query, spec.Scalar)
-			if err != nil {
-				return nil, err
+	for _, sort := range params.Sort {
+		order, err := sb.order(sort)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Order(order)
+	}
+
+	// Then by primary key, so that pages (and cursors) don't depend on the order of equal rows
+	keys := sb.cursorKeys(params)
+	for _, key := range keys {
+		if !slices.ContainsFunc(params.Sort, func(sort models.SortSpec) bool { return sb.column(sort.Parameter) == sb.column(key) }) {
+			query = query.Order(fmt.Sprintf("%s ASC", sb.column(key)))
+		}
+	}
+
+	// Only the listed columns, or their unique combinations with distinct
+	if len(params.Parameters) > 0 {
+		columns := make([]string, 0, len(params.Parameters))
+		for _, parameter := range params.Parameters {
+			if sb.field(parameter) == nil {
+				return nil, fmt.Errorf("unknown parameter: %s", parameter)
 			}
+			columns = append(columns, sb.column(parameter))
 		}
-		if spec.Vector != nil {
-			var err error
-			query, err = sb.applyVectorFilter(query, spec.Vector)
-			if err != nil {
-				return nil, err
+		// The next cursor is read from the last result
+		for _, key := range keys {
+			if !slices.Contains(columns, sb.column(key)) {
+				columns = append(columns, sb.column(key))
 			}
 		}
+		if params.Distinct {
+			query = query.Distinct(columns)
+		} else {
+			query = query.Select(columns)
+		}
 	}
 
-	for _, sort := range params.Sort {
-		query = query.Order(fmt.Sprintf("\"%s\" %s", sort.Parameter, sort.Direction))
+	return query, nil
+}
+
+// order returns the ORDER BY term of a sort, e.g. "script_id" desc for {"parameter": "id", "direction": "desc"}
+// Unknown parameters and directions are rejected, as they would be pasted in the query
+func (sb *SearchBuilder[T]) order(sort models.SortSpec) (string, error) {
+	if sb.field(sort.Parameter) == nil {
+		return "", fmt.Errorf("unknown sort parameter: %s", sort.Parameter)
+	}
+	direction := models.SortDirection(strings.ToLower(string(sort.Direction)))
+	if direction == "" {
+		direction = models.DirASC
+	}
+	if !direction.IsValid() {
+		return "", fmt.Errorf("invalid sort direction: %s", sort.Direction)
+	}
+	return fmt.Sprintf("%s %s", sb.column(sort.Parameter), direction), nil
+}
+
+// Filter returns the query of the rows matching the specs
+// Top-level specs are ANDed together
+func (sb *SearchBuilder[T]) Filter(search []models.SearchSpec) (*gorm.DB, error) {
+	conditions, err := sb.andConditions(search)
+	if err != nil {
+		return nil, err
 	}
 
+	query := sb.db
+	for _, condition := range conditions {
+		query = query.Where(condition)
+	}
 	return query, nil
 }
 
-func (sb *SearchBuilder[T]) applyScalarFilter(query *gorm.DB, spec *models.ScalarSearchSpec) (*gorm.DB, error) {
+// buildCondition returns the SQL condition of any spec
+func (sb *SearchBuilder[T]) buildCondition(spec *models.SearchSpec) (clause.Expr, error) {
+	relation, related, err := sb.relatedSpec(spec)
+	if err != nil {
+		return clause.Expr{}, err
+	}
+	if relation != nil {
+		condition, err := sb.related(relation).buildCondition(related)
+		if err != nil || sb.joined[relation.rows()] {
+			return condition, err
+		}
+		return relation.exists([]clause.Expr{condition}), nil
+	}
+
+	switch {
+	case spec.Scalar != nil:
+		return sb.applyScalarFilter(spec.Scalar)
+	case spec.Vector != nil:
+		return sb.applyVectorFilter(spec.Vector)
+	case spec.Group != nil:
+		return sb.applyGroupFilter(spec.Group)
+	default:
+		return clause.Expr{}, fmt.Errorf("empty search spec")
+	}
+}
+
+func (sb *SearchBuilder[T]) applyScalarFilter(spec *models.ScalarSearchSpec) (clause.Expr, error) {
 	switch spec.Operator {
 	case models.OpEq:
-		return query.Where(fmt.Sprintf("\"%s\" = ?", spec.Parameter), spec.Value), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s = ?", sb.column(spec.Parameter)), Vars: []any{spec.Value}}, nil
 	case models.OpNeq:
-		return query.Where(fmt.Sprintf("\"%s\" != ?", spec.Parameter), spec.Value), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s != ?", sb.column(spec.Parameter)), Vars: []any{spec.Value}}, nil
 	case models.OpGt:
-		return query.Where(fmt.Sprintf("\"%s\" > ?", spec.Parameter), spec.Value), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s > ?", sb.column(spec.Parameter)), Vars: []any{spec.Value}}, nil
 	case models.OpLt:
-		return query.Where(fmt.Sprintf("\"%s\" < ?", spec.Parameter), spec.Value), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s < ?", sb.column(spec.Parameter)), Vars: []any{spec.Value}}, nil
 	case models.OpLike:
 		escapedValue := strings.ReplaceAll(fmt.Sprint(spec.Value), "%", "\\%")
 		escapedValue = strings.ReplaceAll(escapedValue, "_", "\\_")
-		return query.Where(fmt.Sprintf("\"%s\" ILIKE ?", spec.Parameter), "%"+escapedValue+"%"), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s ILIKE ?", sb.textColumn(spec.Parameter)), Vars: []any{"%" + escapedValue + "%"}}, nil
 	case models.OpNotLike:
 		escapedValue := strings.ReplaceAll(fmt.Sprint(spec.Value), "%", "\\%")
 		escapedValue = strings.ReplaceAll(escapedValue, "_", "\\_")
-		return query.Where(fmt.Sprintf("\"%s\" NOT ILIKE ?", spec.Parameter), "%"+escapedValue+"%"), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s NOT ILIKE ?", sb.textColumn(spec.Parameter)), Vars: []any{"%" + escapedValue + "%"}}, nil
 	case models.OpRegex:
 		if _, err := regexp.Compile(fmt.Sprint(spec.Value)); err != nil {
-			return nil, fmt.Errorf("invalid regex: %w", err)
+			return clause.Expr{}, fmt.Errorf("invalid regex: %w", err)
 		}
-		return query.Where(fmt.Sprintf("\"%s\" ~ ?", spec.Parameter), spec.Value), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s ~ ?", sb.textColumn(spec.Parameter)), Vars: []any{spec.Value}}, nil
+	case models.OpInCIDR:
+		return clause.Expr{SQL: fmt.Sprintf("%s <<= CAST(? AS inet)", sb.column(spec.Parameter)), Vars: []any{spec.Value}}, nil
+	case models.OpNotInCIDR:
+		return clause.Expr{SQL: fmt.Sprintf("NOT (%s <<= CAST(? AS inet))", sb.column(spec.Parameter)), Vars: []any{spec.Value}}, nil
+	case models.OpIPRange:
+		start, end, err := models.ParseIPRange(fmt.Sprint(spec.Value))
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		return clause.Expr{
+			SQL:  fmt.Sprintf("%s BETWEEN CAST(? AS inet) AND CAST(? AS inet)", sb.column(spec.Parameter)),
+			Vars: []any{start.String(), end.String()},
+		}, nil
+	case models.OpContains:
+		return sb.arrayFilter(spec.Parameter, "@>", []any{spec.Value})
+	case models.OpAnyLike:
+		escapedValue := strings.ReplaceAll(fmt.Sprint(spec.Value), "%", "\\%")
+		escapedValue = strings.ReplaceAll(escapedValue, "_", "\\_")
+		return clause.Expr{
+			SQL:  fmt.Sprintf("EXISTS (SELECT 1 FROM unnest(%s) AS element WHERE element ILIKE ?)", sb.column(spec.Parameter)),
+			Vars: []any{"%" + escapedValue + "%"},
+		}, nil
 	default:
-		return nil, fmt.Errorf("unknown operator: %s", spec.Operator)
+		return clause.Expr{}, fmt.Errorf("unknown operator: %s", spec.Operator)
 	}
 }
 
-func (sb *SearchBuilder[T]) applyVectorFilter(query *gorm.DB, spec *models.VectorSearchSpec) (*gorm.DB, error) {
+func (sb *SearchBuilder[T]) applyVectorFilter(spec *models.VectorSearchSpec) (clause.Expr, error) {
 	switch spec.Operator {
 	case models.OpIn:
-		return query.Where(fmt.Sprintf("\"%s\" IN ?", spec.Parameter), spec.Values), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s IN ?", sb.column(spec.Parameter)), Vars: []any{spec.Values}}, nil
 	case models.OpNotIn:
-		return query.Where(fmt.Sprintf("\"%s\" NOT IN ?", spec.Parameter), spec.Values), nil
+		return clause.Expr{SQL: fmt.Sprintf("%s NOT IN ?", sb.column(spec.Parameter)), Vars: []any{spec.Values}}, nil
+	case models.OpContainsAny, models.OpContainsAll:
+		values, ok := spec.Values.([]any)
+		if !ok || len(values) == 0 {
+			return clause.Expr{}, fmt.Errorf("%s needs a list of values", spec.Operator)
+		}
+		if spec.Operator == models.OpContainsAny {
+			return sb.arrayFilter(spec.Parameter, "&&", values)
+		}
+		return sb.arrayFilter(spec.Parameter, "@>", values)
 	default:
-		return nil, fmt.Errorf("unknown operator: %s", spec.Operator)
+		return clause.Expr{}, fmt.Errorf("unknown operator: %s", spec.Operator)
+	}
+}
+
+// applyGroupFilter parenthesises the conditions of nested specs, e.g. ("port" = 80 OR ("port" = 8080 AND ...))
+func (sb *SearchBuilder[T]) applyGroupFilter(spec *models.GroupSearchSpec) (clause.Expr, error) {
+	var built []clause.Expr
+	if spec.Operator == models.OpAnd {
+		var err error
+		if built, err = sb.andConditions(spec.Specs); err != nil {
+			return clause.Expr{}, err
+		}
+	} else {
+		for i := range spec.Specs {
+			condition, err := sb.buildCondition(&spec.Specs[i])
+			if err != nil {
+				return clause.Expr{}, err
+			}
+			built = append(built, condition)
+		}
+	}
+
+	conditions := make([]string, 0, len(built))
+	vars := make([]any, 0, len(built))
+	for _, condition := range built {
+		// Built in place of the placeholder
+		conditions = append(conditions, "?")
+		vars = append(vars, condition)
+	}
+
+	switch spec.Operator {
+	case models.OpAnd:
+		return clause.Expr{SQL: "(" + strings.Join(conditions, " AND ") + ")", Vars: vars}, nil
+	case models.OpOr:
+		return clause.Expr{SQL: "(" + strings.Join(conditions, " OR ") + ")", Vars: vars}, nil
+	case models.OpNot:
+		if len(conditions) != 1 {
+			return clause.Expr{}, fmt.Errorf("not group takes a single spec")
+		}
+		return clause.Expr{SQL: "NOT (?)", Vars: vars}, nil
+	default:
+		return clause.Expr{}, fmt.Errorf("unknown operator: %s", spec.Operator)
+	}
+}
+
+// arrayFilter compares an array column to the given values, e.g. "ports" && CAST(ARRAY[?, ?] AS integer[])
+// The values are cast to the type of the column, so that its indexes can be used
+func (sb *SearchBuilder[T]) arrayFilter(parameter, operator string, values []any) (clause.Expr, error) {
+	dataType := sb.dataType(parameter)
+	if !strings.HasSuffix(string(dataType), "[]") {
+		return clause.Expr{}, fmt.Errorf("%s is not an array", parameter)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	return clause.Expr{
+		SQL:  fmt.Sprintf("%s %s CAST(ARRAY[%s] AS %s)", sb.column(parameter), operator, placeholders, dataType),
+		Vars: values,
+	}, nil
+}
+
+// textColumn returns the column to match patterns against
+// Addresses are matched without their mask, as shown in results (e.g. 10.0.0.1 and not 10.0.0.1/32)
+func (sb *SearchBuilder[T]) textColumn(parameter string) string {
+	if sb.dataType(parameter) == "inet" {
+		return fmt.Sprintf("host(%s)", sb.column(parameter))
+	}
+	return sb.column(parameter)
+}
+
+// column returns the quoted column of a parameter, qualified by its table for relations
+func (sb *SearchBuilder[T]) column(parameter string) string {
+	if field := sb.field(parameter); field != nil && field.DBName != "" {
+		parameter = field.DBName
 	}
+	if sb.table != "" {
+		return fmt.Sprintf("\"%s\".\"%s\"", sb.table, parameter)
+	}
+	return fmt.Sprintf("\"%s\"", parameter)
+}
+
+// dataType returns the database type of a column of the model, empty if unknown
+func (sb *SearchBuilder[T]) dataType(parameter string) schema.DataType {
+	field := sb.field(parameter)
+	if field == nil {
+		return ""
+	}
+	return field.DataType
+}
+
+// field returns the field of the model named by a parameter: its JSON name (e.g. "id" for "script_id") or column
+func (sb *SearchBuilder[T]) field(parameter string) *schema.Field {
+	modelSchema, err := schema.Parse(sb.model, schemaCache, sb.db.NamingStrategy)
+	if err != nil {
+		return nil
+	}
+	for _, field := range modelSchema.Fields {
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name == parameter && field.DBName != "" {
+			return field
+		}
+	}
+	return modelSchema.LookUpField(parameter)
+}
+
+// Parsed schemas of the searched models
+var schemaCache = &sync.Map{}
+
+// cursorKeys returns the sort parameters followed by the primary key of T, which identify the position of a result
+// Unique combinations (distinct) don't have a primary key: nil
+func (sb *SearchBuilder[T]) cursorKeys(params *models.SearchParams) []string {
+	if params.Distinct {
+		return nil
+	}
+	modelSchema, err := schema.Parse(new(T), schemaCache, sb.db.NamingStrategy)
+	if err != nil || len(modelSchema.PrimaryFields) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(params.Sort)+len(modelSchema.PrimaryFields))
+	for _, sort := range params.Sort {
+		keys = append(keys, sort.Parameter)
+	}
+	for _, field := range modelSchema.PrimaryFields {
+		if !slices.ContainsFunc(keys, func(key string) bool { return sb.column(key) == sb.column(field.DBName) }) {
+			keys = append(keys, field.DBName)
+		}
+	}
+	return keys
+}
+
+// afterCursor returns the condition of the results following the cursor, in the order of the search
+// e.g. ("scan_start" < ? OR ("scan_start" = ? AND "scan_id" > ?)) when sorted by scan_start desc
+// Nulls being last in ascending order, and first in descending order
+func (sb *SearchBuilder[T]) afterCursor(cursor *models.Cursor, keys []string, sorts []models.SortSpec) (clause.Expr, error) {
+	if !slices.Equal(cursor.Keys, keys) {
+		return clause.Expr{}, fmt.Errorf("cursor doesn't match the sort")
+	}
+
+	var disjuncts []string
+	var disjunctVars []any
+	var equals []string
+	var equalVars []any
+	for i, key := range keys {
+		value := cursor.Values[i]
+		descending := i < len(sorts) && strings.EqualFold(string(sorts[i].Direction), string(models.DirDESC))
+
+		// Equal on the previous keys, after on this one
+		column := sb.column(key)
+		var after clause.Expr
+		switch {
+		case value == nil && descending:
+			after = clause.Expr{SQL: fmt.Sprintf("%s IS NOT NULL", column)}
+		case value == nil:
+			// Nothing after nulls
+		case descending:
+			after = clause.Expr{SQL: fmt.Sprintf("%s < ?", column), Vars: []any{value}}
+		default:
+			after = clause.Expr{SQL: fmt.Sprintf("(%s > ? OR %s IS NULL)", column, column), Vars: []any{value}}
+		}
+		if after.SQL != "" {
+			disjuncts = append(disjuncts, "("+strings.Join(append(slices.Clone(equals), "?"), " AND ")+")")
+			disjunctVars = append(disjunctVars, append(slices.Clone(equalVars), after)...)
+		}
+
+		if value == nil {
+			equals = append(equals, fmt.Sprintf("%s IS NULL", column))
+		} else {
+			equals = append(equals, fmt.Sprintf("%s = ?", column))
+			equalVars = append(equalVars, value)
+		}
+	}
+
+	if len(disjuncts) == 0 {
+		return clause.Expr{SQL: "FALSE"}, nil
+	}
+	return clause.Expr{SQL: "(" + strings.Join(disjuncts, " OR ") + ")", Vars: disjunctVars}, nil
+}
+
+// nextCursor returns the cursor after a result
+func (sb *SearchBuilder[T]) nextCursor(ctx context.Context, keys []string, last *T) (string, error) {
+	cursor := &models.Cursor{Keys: keys, Values: make([]any, 0, len(keys))}
+	for _, key := range keys {
+		field := sb.field(key)
+		if field == nil {
+			return "", fmt.Errorf("unknown sort parameter: %s", key)
+		}
+		value, zero := field.ValueOf(ctx, reflect.ValueOf(last).Elem())
+		if zero && field.FieldType.Kind() == reflect.Pointer {
+			value = nil
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	return cursor.Encode()
+}
+
+// estimateCount returns the number of rows the query planner expects, from the table statistics
+func (sb *SearchBuilder[T]) estimateCount(query *gorm.DB) (int64, error) {
+	var plan string
+	if err := sb.db.Raw("EXPLAIN (FORMAT JSON) ?", query.Session(&gorm.Session{}).Model(new(T))).Row().Scan(&plan); err != nil {
+		return 0, err
+	}
+
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &explained); err != nil || len(explained) == 0 {
+		return 0, fmt.Errorf("unexpected query plan: %s", plan)
+	}
+	return int64(explained[0].Plan.Rows), nil
 }
 
 // Search is a generic function to query a simple table with SearchParams
@@ -96,38 +424,96 @@ func Search[T any](
 	db *gorm.DB,
 	params *models.SearchParams,
 	preloads ...Preload[T],
-) (uint64, []T, error) {
+) (*models.SearchResult[T], error) {
+	return SearchRelated(ctx, db, params, nil, preloads...)
+}
+
+// SearchRelated is Search, with specs on the fields of related tables (e.g. "hosts.host")
+func SearchRelated[T any](
+	ctx context.Context,
+	db *gorm.DB,
+	params *models.SearchParams,
+	relations []Relation[T],
+	preloads ...Preload[T],
+) (*models.SearchResult[T], error) {
 	var results []T
 
 	params.SetDefaults()
 
-	builder := NewSearchBuilder[T](db.WithContext(ctx))
+	builder := NewSearchBuilder(db.WithContext(ctx), relations...)
 	query, err := builder.Build(params)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
 	}
 
+	// Counting on a copy of the query, as it replaces the selected columns
 	var total int64
-	if err := query.Model(new(T)).Count(&total).Error; err != nil {
-		return 0, nil, fmt.Errorf("failed to count records: %w", err)
+	switch {
+	case params.Count == models.CountNone:
+	case params.Count == models.CountEstimated:
+		if total, err = builder.estimateCount(query); err != nil {
+			return nil, fmt.Errorf("failed to estimate records: %w", err)
+		}
+	case params.Distinct && len(params.Parameters) > 0:
+		// Count the unique combinations, not the rows
+		if err := db.WithContext(ctx).Table("(?) AS results", query.Model(new(T))).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count records: %w", err)
+		}
+	default:
+		if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count records: %w", err)
+		}
 	}
 
-	// Handle pagination - page 0 defaults to 1
-	page := params.Page
-	if page == 0 {
-		page = 1
+	keys := builder.cursorKeys(params)
+	if params.Cursor != "" {
+		// Keyset pagination: the results after the cursor, whatever the page
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("cursors aren't supported for this search")
+		}
+		cursor, err := models.DecodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		condition, err := builder.afterCursor(cursor, keys, params.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition)
+	} else {
+		// Handle pagination - page 0 defaults to 1
+		page := params.Page
+		if page == 0 {
+			page = 1
+		}
+		offset := (page - 1) * params.PerPage
+		query = query.Offset(int(offset))
 	}
-	offset := (page - 1) * params.PerPage
-	query = query.Offset(int(offset)).Limit(int(params.PerPage))
+	query = query.Limit(int(params.PerPage))
 
-	// Apply preloads if provided
-	for _, preload := range preloads {
-		query = query.Preload(preload.Association, preload.Fn)
+	// Apply preloads if provided, unless only some columns are selected
+	if len(params.Parameters) == 0 {
+		for _, preload := range preloads {
+			query = query.Preload(preload.Association, preload.Fn)
+		}
 	}
 
 	if err := query.Find(&results).Error; err != nil {
-		return 0, nil, fmt.Errorf("failed to search records: %w", err)
+		return nil, fmt.Errorf("failed to search records: %w", err)
+	}
+
+	result := &models.SearchResult[T]{
+		Total:   uint64(total),
+		Count:   params.Count,
+		Results: results,
+	}
+
+	// A full page may not be the last one
+	if len(keys) > 0 && len(results) > 0 && uint64(len(results)) == params.PerPage {
+		if result.NextCursor, err = builder.nextCursor(ctx, keys, &results[len(results)-1]); err != nil {
+			return nil, err
+		}
 	}
 
-	return uint64(total), results, nil
+	return result, nil
 }
@@ -0,0 +1,354 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	pg "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds the SQL of queries without running them, so that no database is needed
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(pg.New(pg.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// searchSQL returns the SQL and vars of a search of T
+func searchSQL[T any](t *testing.T, params *models.SearchParams, relations ...Relation[T]) (string, []any, error) {
+	query, err := NewSearchBuilder(dryRunDB(t), relations...).Build(params)
+	if err != nil {
+		return "", nil, err
+	}
+	statement := query.Find(&[]T{}).Statement
+	return statement.SQL.String(), statement.Vars, nil
+}
+
+func scalar(parameter string, operator models.ScalarOperator, value any) models.SearchSpec {
+	return models.SearchSpec{Scalar: &models.ScalarSearchSpec{Parameter: parameter, Operator: operator, Value: value}}
+}
+
+func vector(parameter string, operator models.VectorOperator, values ...any) models.SearchSpec {
+	return models.SearchSpec{Vector: &models.VectorSearchSpec{Parameter: parameter, Operator: operator, Values: values}}
+}
+
+func group(operator models.GroupOperator, specs ...models.SearchSpec) models.SearchSpec {
+	return models.SearchSpec{Group: &models.GroupSearchSpec{Operator: operator, Specs: specs}}
+}
+
+// Relations of scans, as in the nmap repository
+var testScanRelations = []Relation[models.NmapScan]{
+	{Name: "hosts", Model: &models.NmapHost{}, Table: "nmap_hosts", From: "nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id", Where: "nmap_scan_results.scan_id = nmap_scans.scan_id"},
+	{Name: "scan_results", Model: &models.ScanResult{}, Table: "nmap_scan_results", From: "nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id", Where: "nmap_scan_results.scan_id = nmap_scans.scan_id"},
+	{Name: "scripts", Model: &models.NmapScriptResult{}, Table: "nmap_nse_scripts", From: "nmap_nse_scripts", Where: "nmap_nse_scripts.scan_id = nmap_scans.scan_id"},
+}
+
+func TestSearchFilters(t *testing.T) {
+	tests := []struct {
+		name         string
+		search       []models.SearchSpec
+		expectedSQL  string
+		expectedVars []any
+	}{
+		{
+			name:         "Top-level specs are ANDed",
+			search:       []models.SearchSpec{scalar("host", models.OpEq, "10.0.0.1"), scalar("os_accuracy", models.OpGt, 90.0)},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE "host" = $1 AND "os_accuracy" > $2 ORDER BY "host_id" ASC`,
+			expectedVars: []any{"10.0.0.1", 90.0},
+		},
+		{
+			name: "Or inside and",
+			search: []models.SearchSpec{
+				group(models.OpOr, scalar("host", models.OpEq, "10.0.0.1"), scalar("host", models.OpEq, "10.0.0.2")),
+				scalar("host_status", models.OpEq, "up"),
+			},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE (("host" = $1 OR "host" = $2)) AND "host_status" = $3 ORDER BY "host_id" ASC`,
+			expectedVars: []any{"10.0.0.1", "10.0.0.2", "up"},
+		},
+		{
+			name: "And inside or",
+			search: []models.SearchSpec{group(models.OpOr,
+				group(models.OpAnd, scalar("host_status", models.OpEq, "up"), scalar("os_accuracy", models.OpGt, 90.0)),
+				scalar("os_name", models.OpLike, "windows"),
+			)},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE (("host_status" = $1 AND "os_accuracy" > $2) OR "os_name" ILIKE $3) ORDER BY "host_id" ASC`,
+			expectedVars: []any{"up", 90.0, "%windows%"},
+		},
+		{
+			name: "Not of a group",
+			search: []models.SearchSpec{group(models.OpNot,
+				group(models.OpOr, scalar("host_status", models.OpEq, "down"), vector("host", models.OpIn, "10.0.0.1", "10.0.0.2")),
+			)},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE NOT (("host_status" = $1 OR "host" IN ($2,$3))) ORDER BY "host_id" ASC`,
+			expectedVars: []any{"down", "10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:         "Escaped like",
+			search:       []models.SearchSpec{scalar("comment", models.OpNotLike, "100%_sure")},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE "comment" NOT ILIKE $1 ORDER BY "host_id" ASC`,
+			expectedVars: []any{`%100\%\_sure%`},
+		},
+		{
+			name: "Networks",
+			search: []models.SearchSpec{
+				scalar("ip", models.OpInCIDR, "10.0.0.0/8"),
+				scalar("ip", models.OpNotInCIDR, "10.1.0.0/16"),
+				scalar("ip", models.OpIPRange, "10.0.0.1 - 10.0.0.254"),
+			},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE "ip" <<= CAST($1 AS inet) AND NOT ("ip" <<= CAST($2 AS inet)) AND ("ip" BETWEEN CAST($3 AS inet) AND CAST($4 AS inet)) ORDER BY "host_id" ASC`,
+			expectedVars: []any{"10.0.0.0/8", "10.1.0.0/16", "10.0.0.1", "10.0.0.254"},
+		},
+		{
+			name:         "Addresses matched as text",
+			search:       []models.SearchSpec{scalar("ip", models.OpLike, "10.20.")},
+			expectedSQL:  `SELECT * FROM "nmap_hosts" WHERE host("ip") ILIKE $1 ORDER BY "host_id" ASC`,
+			expectedVars: []any{"%10.20.%"},
+		},
+		{
+			name: "Arrays",
+			search: []models.SearchSpec{
+				scalar("hostnames", models.OpContains, "dc01.corp.local"),
+				vector("addresses", models.OpContainsAny, "10.0.0.1", "10.0.0.2"),
+				vector("hostnames", models.OpContainsAll, "a", "b"),
+				scalar("hostnames", models.OpAnyLike, "corp"),
+			},
+			expectedSQL: `SELECT * FROM "nmap_hosts" WHERE "hostnames" @> CAST(ARRAY[$1] AS text[]) AND "addresses" && CAST(ARRAY[$2, $3] AS text[]) ` +
+				`AND "hostnames" @> CAST(ARRAY[$4, $5] AS text[]) AND EXISTS (SELECT 1 FROM unnest("hostnames") AS element WHERE element ILIKE $6) ORDER BY "host_id" ASC`,
+			expectedVars: []any{"dc01.corp.local", "10.0.0.1", "10.0.0.2", "a", "b", "%corp%"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, vars, err := searchSQL[models.NmapHost](t, &models.SearchParams{Search: tc.search})
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedSQL, sql)
+				assert.Equal(t, tc.expectedVars, vars)
+			}
+		})
+	}
+}
+
+func TestSearchFilterErrors(t *testing.T) {
+	for name, search := range map[string][]models.SearchSpec{
+		"Not of several specs": {group(models.OpNot, scalar("host", models.OpEq, "a"), scalar("host", models.OpEq, "b"))},
+		"Array operator":       {scalar("host", models.OpContains, "a")},
+		"Invalid regex":        {scalar("host", models.OpRegex, "(")},
+		"Invalid IP range":     {scalar("ip", models.OpIPRange, "10.0.0.9-10.0.0.1")},
+		"Empty spec":           {{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := searchSQL[models.NmapHost](t, &models.SearchParams{Search: search})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSearchRelations(t *testing.T) {
+	tests := []struct {
+		name         string
+		search       []models.SearchSpec
+		expectedSQL  string
+		expectedVars []any
+	}{
+		{
+			name:   "Same row",
+			search: []models.SearchSpec{scalar("scan_results.port", models.OpEq, 443.0), scalar("hosts.ip", models.OpInCIDR, "10.0.0.0/8")},
+			expectedSQL: `SELECT * FROM "nmap_scans" WHERE EXISTS (SELECT 1 FROM nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id ` +
+				`WHERE nmap_scan_results.scan_id = nmap_scans.scan_id AND "nmap_scan_results"."port" = $1 AND "nmap_hosts"."ip" <<= CAST($2 AS inet)) ORDER BY "scan_id" ASC`,
+			expectedVars: []any{443.0, "10.0.0.0/8"},
+		},
+		{
+			name:   "Other rows",
+			search: []models.SearchSpec{scalar("scripts.id", models.OpEq, "ssl-cert"), scalar("scanner", models.OpEq, "nmap")},
+			expectedSQL: `SELECT * FROM "nmap_scans" WHERE "scanner" = $1 AND (EXISTS (SELECT 1 FROM nmap_nse_scripts ` +
+				`WHERE nmap_nse_scripts.scan_id = nmap_scans.scan_id AND "nmap_nse_scripts"."script_id" = $2)) ORDER BY "scan_id" ASC`,
+			expectedVars: []any{"nmap", "ssl-cert"},
+		},
+		{
+			name:   "In groups",
+			search: []models.SearchSpec{group(models.OpNot, group(models.OpOr, scalar("scan_results.port", models.OpEq, 22.0), vector("hosts.host", models.OpIn, "a", "b")))},
+			expectedSQL: `SELECT * FROM "nmap_scans" WHERE NOT ((EXISTS (SELECT 1 FROM nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id ` +
+				`WHERE nmap_scan_results.scan_id = nmap_scans.scan_id AND "nmap_scan_results"."port" = $1) OR EXISTS (SELECT 1 FROM nmap_scan_results JOIN nmap_hosts ON nmap_hosts.host_id = nmap_scan_results.host_id ` +
+				`WHERE nmap_scan_results.scan_id = nmap_scans.scan_id AND "nmap_hosts"."host" IN ($2,$3)))) ORDER BY "scan_id" ASC`,
+			expectedVars: []any{22.0, "a", "b"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, vars, err := searchSQL(t, &models.SearchParams{Search: tc.search}, testScanRelations...)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedSQL, sql)
+				assert.Equal(t, tc.expectedVars, vars)
+			}
+		})
+	}
+
+	_, _, err := searchSQL(t, &models.SearchParams{Search: []models.SearchSpec{scalar("os_guesses.name", models.OpEq, "Linux")}}, testScanRelations...)
+	assert.Error(t, err, "Unknown relations should be rejected")
+}
+
+func TestSearchProjection(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      models.SearchParams
+		expectedSQL string
+	}{
+		{
+			name:        "Sorted",
+			params:      models.SearchParams{Sort: []models.SortSpec{{Parameter: "last_seen", Direction: models.DirDESC}}},
+			expectedSQL: `SELECT * FROM "nmap_hosts" ORDER BY "last_seen" desc,"host_id" ASC`,
+		},
+		{
+			name:        "Parameters",
+			params:      models.SearchParams{Parameters: []string{"host", "os_name"}},
+			expectedSQL: `SELECT "host","os_name","host_id" FROM "nmap_hosts" ORDER BY "host_id" ASC`,
+		},
+		{
+			name:        "Distinct",
+			params:      models.SearchParams{Parameters: []string{"os_name"}, Distinct: true, Sort: []models.SortSpec{{Parameter: "os_name", Direction: models.DirASC}}},
+			expectedSQL: `SELECT DISTINCT "os_name" FROM "nmap_hosts" ORDER BY "os_name" asc`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, _, err := searchSQL[models.NmapHost](t, &tc.params)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedSQL, sql)
+			}
+		})
+	}
+}
+
+func TestSearchAfterCursor(t *testing.T) {
+	builder := NewSearchBuilder[models.NmapHost](dryRunDB(t))
+	sorts := []models.SortSpec{{Parameter: "last_seen", Direction: models.DirDESC}, {Parameter: "os_accuracy", Direction: models.DirASC}}
+	keys := []string{"last_seen", "os_accuracy", "host_id"}
+
+	tests := []struct {
+		name         string
+		values       []any
+		expectedSQL  string
+		expectedVars []any
+	}{
+		{
+			name:   "Values",
+			values: []any{"2024-01-01T00:00:00Z", 90.0, "3f1c"},
+			expectedSQL: `SELECT * FROM "nmap_hosts" WHERE (("last_seen" < $1) OR ("last_seen" = $2 AND ("os_accuracy" > $3 OR "os_accuracy" IS NULL)) ` +
+				`OR ("last_seen" = $4 AND "os_accuracy" = $5 AND ("host_id" > $6 OR "host_id" IS NULL)))`,
+			expectedVars: []any{"2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", 90.0, "2024-01-01T00:00:00Z", 90.0, "3f1c"},
+		},
+		{
+			name:   "Nulls",
+			values: []any{nil, nil, "3f1c"},
+			expectedSQL: `SELECT * FROM "nmap_hosts" WHERE (("last_seen" IS NOT NULL) ` +
+				`OR ("last_seen" IS NULL AND "os_accuracy" IS NULL AND ("host_id" > $1 OR "host_id" IS NULL)))`,
+			expectedVars: []any{"3f1c"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			condition, err := builder.afterCursor(&models.Cursor{Keys: keys, Values: tc.values}, keys, sorts)
+			if !assert.NoError(t, err) {
+				return
+			}
+			statement := dryRunDB(t).Where(condition).Find(&[]models.NmapHost{}).Statement
+			assert.Equal(t, tc.expectedSQL, statement.SQL.String())
+			assert.Equal(t, tc.expectedVars, statement.Vars)
+		})
+	}
+
+	_, err := builder.afterCursor(&models.Cursor{Keys: []string{"host_id"}, Values: []any{"3f1c"}}, keys, sorts)
+	assert.Error(t, err, "Cursors of another sort should be rejected")
+}
+
+func TestSearchSort(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      models.SearchParams
+		expectedSQL string
+	}{
+		{
+			name:        "JSON name of another column",
+			params:      models.SearchParams{Sort: []models.SortSpec{{Parameter: "id", Direction: models.DirDESC}}},
+			expectedSQL: `SELECT * FROM "nmap_nse_scripts" ORDER BY "script_id" desc,"nmap_script_result_id" ASC`,
+		},
+		{
+			name:        "Upper case and default directions",
+			params:      models.SearchParams{Sort: []models.SortSpec{{Parameter: "id", Direction: "DESC"}, {Parameter: "output"}}},
+			expectedSQL: `SELECT * FROM "nmap_nse_scripts" ORDER BY "script_id" desc,"script_output" asc,"nmap_script_result_id" ASC`,
+		},
+		{
+			name:        "Primary key",
+			params:      models.SearchParams{Sort: []models.SortSpec{{Parameter: "nmap_script_result_id", Direction: models.DirDESC}}},
+			expectedSQL: `SELECT * FROM "nmap_nse_scripts" ORDER BY "nmap_script_result_id" desc`,
+		},
+		{
+			name:        "Parameters",
+			params:      models.SearchParams{Parameters: []string{"id", "output"}, Sort: []models.SortSpec{{Parameter: "id", Direction: models.DirASC}}},
+			expectedSQL: `SELECT "script_id","script_output","nmap_script_result_id" FROM "nmap_nse_scripts" ORDER BY "script_id" asc,"nmap_script_result_id" ASC`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, _, err := searchSQL[models.NmapScriptResult](t, &tc.params)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expectedSQL, sql)
+			}
+		})
+	}
+}
+
+func TestSearchSortErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params models.SearchParams
+	}{
+		{name: "Unknown parameter", params: models.SearchParams{Sort: []models.SortSpec{{Parameter: `id" desc; --`, Direction: models.DirASC}}}},
+		{name: "Relation", params: models.SearchParams{Sort: []models.SortSpec{{Parameter: "scan_results", Direction: models.DirASC}}}},
+		{name: "Invalid direction", params: models.SearchParams{Sort: []models.SortSpec{{Parameter: "host", Direction: "asc, (SELECT 1)"}}}},
+		{name: "Unknown selected parameter", params: models.SearchParams{Parameters: []string{"host", `"host_id" FROM pg_user --`}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := searchSQL[models.NmapHost](t, &tc.params)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSearchNextCursor(t *testing.T) {
+	builder := NewSearchBuilder[models.NmapScriptResult](dryRunDB(t))
+	params := &models.SearchParams{Sort: []models.SortSpec{{Parameter: "id", Direction: models.DirDESC}}}
+	keys := builder.cursorKeys(params)
+	assert.Equal(t, []string{"id", "nmap_script_result_id"}, keys)
+
+	last := &models.NmapScriptResult{NmapScriptResultID: uuid.MustParse("3f1c2a4e-0000-4000-8000-000000000001"), ScriptID: "ssl-cert"}
+	encoded, err := builder.nextCursor(context.Background(), keys, last)
+	if !assert.NoError(t, err) {
+		return
+	}
+	cursor, err := models.DecodeCursor(encoded)
+	if assert.NoError(t, err) {
+		assert.Equal(t, keys, cursor.Keys)
+		assert.Equal(t, "ssl-cert", cursor.Values[0])
+	}
+
+	// Conditions on the columns of the keys
+	condition, err := builder.afterCursor(cursor, keys, params.Sort)
+	if assert.NoError(t, err) {
+		statement := dryRunDB(t).Where(condition).Find(&[]models.NmapScriptResult{}).Statement
+		assert.Equal(t, `SELECT * FROM "nmap_nse_scripts" WHERE (("script_id" < $1) OR ("script_id" = $2 AND ("nmap_script_result_id" > $3 OR "nmap_script_result_id" IS NULL)))`, statement.SQL.String())
+	}
+}
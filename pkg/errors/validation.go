@@ -40,3 +40,12 @@ type NotFoundError struct {
 func (e NotFoundError) Error() string {
 	return fmt.Sprintf("%s with ID %s not found", e.Resource, e.ID)
 }
+
+type AlreadyExistsError struct {
+	Resource string
+	ID       string
+}
+
+func (e AlreadyExistsError) Error() string {
+	return fmt.Sprintf("%s with ID %s already exists", e.Resource, e.ID)
+}
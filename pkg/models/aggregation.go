@@ -0,0 +1,52 @@
+package models
+
+// TimeInterval enum, to bucket time fields
+type TimeInterval string
+
+const (
+	IntervalHour TimeInterval = "hour"
+	IntervalDay  TimeInterval = "day"
+	IntervalWeek TimeInterval = "week"
+)
+
+func (ti TimeInterval) IsValid() bool {
+	switch ti {
+	case IntervalHour, IntervalDay, IntervalWeek:
+		return true
+	default:
+		return false
+	}
+}
+
+// AggregationParams counts the results matching the search by group
+// e.g. {"group_by": ["scan_results.port"], "limit": 20} for the top 20 ports of hosts, {"group_by": ["os_name"]} for hosts per OS,
+// or {"group_by": ["first_seen"], "interval": "day"} for new hosts per day
+type AggregationParams struct {
+	Search  []SearchSpec `json:"search" validate:"dive,required"`
+	GroupBy []string     `json:"group_by" validate:"required,min=1"`
+	// Truncates the time fields of group_by
+	Interval TimeInterval `json:"interval,omitempty"`
+	// Number of buckets, the biggest first (or the latest ones with an interval, oldest first)
+	Limit uint64 `json:"limit"`
+}
+
+func (a *AggregationParams) SetDefaults() {
+	if a.Limit == 0 {
+		a.Limit = DEFAULT_RESULTS_PER_PAGE
+	}
+	if a.Limit > MAX_RESULTS_PER_PAGE {
+		a.Limit = MAX_RESULTS_PER_PAGE
+	}
+}
+
+// AggregationBucket is a combination of the group_by values, and the number of results having it
+type AggregationBucket struct {
+	Key   map[string]any `json:"key"`
+	Count uint64         `json:"count"`
+}
+
+type AggregationResult struct {
+	// Number of results matching the search, in any bucket
+	Total   uint64              `json:"total"`
+	Buckets []AggregationBucket `json:"buckets"`
+}
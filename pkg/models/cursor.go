@@ -0,0 +1,53 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Cursor is the position of a result in the order of a search, to read the results after it
+// Keys are the sort parameters followed by the primary key, so that positions are unique
+type Cursor struct {
+	Keys   []string `json:"k"`
+	Values []any    `json:"v"`
+}
+
+// Encode returns the cursor as an opaque string, safe in URLs
+func (c *Cursor) Encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor reads a cursor returned by Encode
+// Integers are kept as int64, so that big ones (e.g. counts) are compared exactly
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var cursor Cursor
+	if err := decoder.Decode(&cursor); err != nil || len(cursor.Keys) == 0 || len(cursor.Keys) != len(cursor.Values) {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	for i, value := range cursor.Values {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		if integer, err := number.Int64(); err == nil {
+			cursor.Values[i] = integer
+		} else if float, err := number.Float64(); err == nil {
+			cursor.Values[i] = float
+		}
+	}
+	return &cursor, nil
+}
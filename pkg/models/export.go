@@ -0,0 +1,33 @@
+package models
+
+// ExportFormat enum, of the files of exported search results
+type ExportFormat string
+
+const (
+	ExportCSV ExportFormat = "csv"
+	// One JSON object per line
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportXLSX   ExportFormat = "xlsx"
+)
+
+func (ef ExportFormat) IsValid() bool {
+	switch ef {
+	case ExportCSV, ExportNDJSON, ExportXLSX:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ef ExportFormat) ContentType() string {
+	switch ef {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
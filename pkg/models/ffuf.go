@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Adapted from: https://github.com/ffuf/ffuf/blob/master/pkg/output/file_json.go
+
+// FfufResult is a single hit of an ffuf run (`ffuf -of json`)
+// Linked to the nmap host and service of the fuzzed target, if any
+type FfufResult struct {
+	FfufResultID uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"ffuf_result_id"`
+	// Links to nmap data, null when the target was never scanned
+	HostID    *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	ServiceID *uuid.UUID `gorm:"type:uuid;index" json:"service_id,omitempty"`
+
+	// Target information
+	URL  string `gorm:"type:text" json:"url"`
+	Host string `gorm:"type:varchar(255);index" json:"host,omitempty"`
+	Port uint16 `json:"port,omitempty"`
+	// Keywords and the words they were replaced with (e.g. FUZZ: admin)
+	Input map[string]string `gorm:"type:jsonb;serializer:json" json:"input,omitempty"`
+
+	// Response information
+	Status           int    `gorm:"index" json:"status"`
+	Length           int    `json:"length"`
+	Words            int    `json:"words"`
+	Lines            int    `json:"lines"`
+	ContentType      string `gorm:"type:varchar(255)" json:"content_type,omitempty"`
+	RedirectLocation string `gorm:"type:text" json:"redirect_location,omitempty"`
+	// Response time, in milliseconds
+	Duration int64 `json:"duration,omitempty"`
+
+	// When ffuf ran
+	Timestamp time.Time `gorm:"index" json:"timestamp"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (FfufResult) TableName() string {
+	return "ffuf_results"
+}
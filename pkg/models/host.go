@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HostScanResult is a scan result of a host, with the scan and the service it was found with
+type HostScanResult struct {
+	ScanResult
+	ScanStart time.Time `json:"scan_start"`
+	Scanner   string    `json:"scanner,omitempty"`
+	Service   *Service  `gorm:"foreignKey:ServiceID;references:ServiceID" json:"service,omitempty"`
+}
+
+func (HostScanResult) TableName() string {
+	return "nmap_scan_results"
+}
+
+// HostDetail is a host as of its latest scans
+// OS guesses and host scripts are the ones of the latest scan that had some
+type HostDetail struct {
+	NmapHost
+	// Ports open after the latest scan of the host, with their service and scripts
+	OpenPorts []HostScanResult `json:"open_ports"`
+}
+
+// PortEvent is a change of a port of a host, between two scans
+type PortEvent string
+
+const (
+	PORT_EVENT_OPENED PortEvent = "opened"
+	// Reported as not open (closed, filtered...), or not reported by a scan of the host
+	PORT_EVENT_CLOSED PortEvent = "closed"
+	// Still open, with another service
+	PORT_EVENT_SERVICE_CHANGED PortEvent = "service_changed"
+)
+
+// HostTimeline is the history of the ports of a host, scan by scan, the oldest first
+type HostTimeline struct {
+	HostID uuid.UUID          `json:"host_id"`
+	Scans  []HostTimelineScan `json:"scans"`
+}
+
+// HostTimelineScan is a scan of a host, with the changes of its ports since the previous scans
+type HostTimelineScan struct {
+	ScanID    uuid.UUID `json:"scan_id"`
+	ScanStart time.Time `json:"scan_start"`
+	Scanner   string    `json:"scanner,omitempty"`
+	// Ports open after this scan, e.g. "22/tcp"
+	OpenPorts []string     `json:"open_ports"`
+	Changes   []PortChange `json:"changes"`
+}
+
+type PortChange struct {
+	Port     uint16    `json:"port"`
+	Protocol string    `json:"protocol,omitempty"`
+	Event    PortEvent `json:"event"`
+	// As reported by the scan, empty if it didn't report the port
+	State string `json:"state,omitempty"`
+	// Service of the port after the change, if reported
+	Service *Service `json:"service,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Adapted from: https://github.com/projectdiscovery/httpx/blob/main/runner/types.go
+
+// HttpxResult contains the HTTP metadata httpx found on a web port
+// Enriches the nmap host and service seen on the same IP/port, if any
+type HttpxResult struct {
+	HttpxResultID uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"httpx_result_id"`
+	// Links to nmap data, null when the target was never scanned
+	HostID    *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	ServiceID *uuid.UUID `gorm:"type:uuid;index" json:"service_id,omitempty"`
+
+	// Target information
+	URL    string `gorm:"type:text" json:"url"`
+	Input  string `gorm:"type:varchar(255)" json:"input,omitempty"`
+	Scheme string `gorm:"type:varchar(10)" json:"scheme,omitempty"`
+	// IP the target resolved to
+	Host IPAddress `gorm:"type:inet;index" json:"host,omitempty"`
+	Port uint16    `json:"port,omitempty"`
+	Path string    `gorm:"type:text" json:"path,omitempty"`
+
+	// Response information
+	StatusCode    int    `gorm:"index" json:"status_code,omitempty"`
+	Title         string `gorm:"type:text" json:"title,omitempty"`
+	WebServer     string `gorm:"type:varchar(255)" json:"web_server,omitempty"`
+	ContentType   string `gorm:"type:varchar(255)" json:"content_type,omitempty"`
+	ContentLength int    `json:"content_length,omitempty"`
+	Words         int    `json:"words,omitempty"`
+	Lines         int    `json:"lines,omitempty"`
+	// Comma separated (e.g. "Nginx:1.19,PHP"), so that it can be searched with like
+	Tech string `gorm:"type:text" json:"tech,omitempty"`
+
+	// Content hashes
+	FaviconHash string `gorm:"type:varchar(64);index" json:"favicon_hash,omitempty"`
+	BodyMD5     string `gorm:"column:body_md5;type:varchar(32)" json:"body_md5,omitempty"`
+	BodySHA256  string `gorm:"column:body_sha256;type:varchar(64)" json:"body_sha256,omitempty"`
+	BodyMMH3    string `gorm:"column:body_mmh3;type:varchar(64)" json:"body_mmh3,omitempty"`
+	HeaderMD5   string `gorm:"column:header_md5;type:varchar(32)" json:"header_md5,omitempty"`
+
+	// TLS certificate
+	TLSSubjectDN         string         `gorm:"column:tls_subject_dn;type:text" json:"tls_subject_dn,omitempty"`
+	TLSSubjectCN         string         `gorm:"column:tls_subject_cn;type:varchar(255)" json:"tls_subject_cn,omitempty"`
+	TLSSubjectAN         pq.StringArray `gorm:"column:tls_subject_an;type:text[]" json:"tls_subject_an,omitempty"`
+	TLSIssuerDN          string         `gorm:"column:tls_issuer_dn;type:text" json:"tls_issuer_dn,omitempty"`
+	TLSFingerprintSHA256 string         `gorm:"column:tls_fingerprint_sha256;type:varchar(64)" json:"tls_fingerprint_sha256,omitempty"`
+
+	// When httpx probed
+	Timestamp time.Time `gorm:"index" json:"timestamp"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (HttpxResult) TableName() string {
+	return "httpx_results"
+}
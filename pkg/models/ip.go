@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IPAddress is an IPv4 or IPv6 address stored in an inet column, so that it can be searched by network
+// (see OpInCIDR and OpIPRange). Empty addresses are stored as NULL
+type IPAddress string
+
+// NewIPAddress returns the address if it is an IP, empty otherwise (e.g. hostnames, MAC addresses)
+func NewIPAddress(address string) IPAddress {
+	// inet has no zones (e.g. fe80::1%eth0)
+	if addr, err := netip.ParseAddr(address); err != nil || addr.Zone() != "" {
+		return ""
+	}
+	return IPAddress(address)
+}
+
+func (IPAddress) GormDataType() string {
+	return "inet"
+}
+
+func (ip IPAddress) Value() (driver.Value, error) {
+	if ip == "" {
+		return nil, nil
+	}
+	return string(ip), nil
+}
+
+func (ip *IPAddress) Scan(value any) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*ip = ""
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into an IP address", value)
+	}
+
+	// Single addresses may be read with their mask (e.g. 10.0.0.1/32)
+	if prefix, err := netip.ParsePrefix(text); err == nil && prefix.IsSingleIP() {
+		text = prefix.Addr().String()
+	}
+	*ip = IPAddress(text)
+	return nil
+}
+
+// ParseIPRange parses an ip_range value, e.g. "10.0.0.1-10.0.0.254"
+// Both ends are included, and must be of the same IP version
+func ParseIPRange(value string) (netip.Addr, netip.Addr, error) {
+	first, last, found := strings.Cut(value, "-")
+	if !found {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid IP range %q: expected <first>-<last>", value)
+	}
+
+	start, err := netip.ParseAddr(strings.TrimSpace(first))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid IP range %q: %w", value, err)
+	}
+	end, err := netip.ParseAddr(strings.TrimSpace(last))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid IP range %q: %w", value, err)
+	}
+
+	if start.Is4() != end.Is4() {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid IP range %q: both ends must be of the same IP version", value)
+	}
+	if end.Less(start) {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("invalid IP range %q: first address is after the last one", value)
+	}
+	return start, end, nil
+}
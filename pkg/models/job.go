@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStatus is the state of an ingestion job
+type JobStatus string
+
+const (
+	JOB_STATUS_QUEUED  JobStatus = "queued"
+	JOB_STATUS_RUNNING JobStatus = "running"
+	JOB_STATUS_DONE    JobStatus = "done"
+	JOB_STATUS_FAILED  JobStatus = "failed"
+)
+
+const (
+	// How often the heartbeat of a running job is saved
+	JOB_HEARTBEAT_INTERVAL = 30 * time.Second
+	// A running job without heartbeat for this long lost its worker (e.g. crashed or restarted), and is claimed again
+	JOB_STALE_TIMEOUT = 5 * time.Minute
+	// A job claimed more often than this keeps stopping its worker, and is failed
+	MAX_JOB_ATTEMPTS = 3
+)
+
+func (s JobStatus) IsValid() bool {
+	switch s {
+	case JOB_STATUS_QUEUED, JOB_STATUS_RUNNING, JOB_STATUS_DONE, JOB_STATUS_FAILED:
+		return true
+	default:
+		return false
+	}
+}
+
+// IngestionJob is an upload waiting to be (or being) imported by a worker
+type IngestionJob struct {
+	JobID uuid.UUID `gorm:"type:uuid;primaryKey" json:"job_id"`
+	// Module importing the upload, e.g. "nmap"
+	Tool string `gorm:"type:varchar(50)" json:"tool"`
+	// Format of the uploaded files, for tools with several (e.g. masscan), empty to detect it
+	Format  string `gorm:"type:varchar(20)" json:"format,omitempty"`
+	Project string `gorm:"type:varchar(255)" json:"project,omitempty"`
+	// queued / running / done / failed
+	Status string `gorm:"type:varchar(20);index" json:"status"`
+
+	// Directory holding the uploaded files until the job is over
+	StoragePath string `gorm:"type:text" json:"-"`
+
+	// Progress, updated while the job runs
+	HostsProcessed int64 `json:"hosts_processed"`
+	PortsProcessed int64 `json:"ports_processed"`
+
+	// Outcome of every uploaded file, archives expanded
+	Files []JobFileResult `gorm:"type:jsonb;serializer:json" json:"files,omitempty"`
+	// Why the whole job failed, if it did
+	Error string `gorm:"type:text" json:"error,omitempty"`
+
+	// Number of times a worker claimed the job
+	Attempts int `json:"attempts"`
+
+	CreatedAt time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// Saved by the worker running the job, cf JOB_STALE_TIMEOUT
+	HeartbeatAt *time.Time `json:"heartbeat_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+func (IngestionJob) TableName() string {
+	return "ingestion_jobs"
+}
+
+// JobFileResult is the outcome of the import of a single uploaded file
+type JobFileResult struct {
+	File   string `json:"file"`
+	ScanID string `json:"scan_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -50,14 +50,20 @@ func (p Protocol) IsValid() bool {
 	}
 }
 
-// NmapScriptResult represents a single NSE script result for a scan result.
+// NmapScriptResult represents a single NSE script result for a scan result (port script),
+// or for a host (host script, without scan result).
 type NmapScriptResult struct {
 	NmapScriptResultID uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"nmap_script_result_id"`
-	// TODO: Parsing?
-	ScanResultID uuid.UUID `gorm:"type:uuid;index" json:"-"`
-	ScriptID     string    `gorm:"type:varchar(255)" json:"id"`
-	ScriptOutput string    `gorm:"type:text" json:"output"`
-	CreatedAt    time.Time `gorm:"autoCreateTime" json:"-"`
+	ScanID             uuid.UUID `gorm:"type:uuid;index" json:"scan_id"`
+	HostID             uuid.UUID `gorm:"type:uuid;index" json:"host_id"`
+	// null for host scripts
+	ScanResultID *uuid.UUID `gorm:"type:uuid;index" json:"-"`
+	ScriptID     string     `gorm:"type:varchar(255)" json:"id"`
+	ScriptOutput string     `gorm:"type:text" json:"output"`
+	// Structured output (<elem> and <table>), e.g. {"subject": {"commonName": "..."}} for ssl-cert
+	// Unkeyed entries are keyed by their position ("1", "2", ...), like NSE Lua tables
+	ScriptData map[string]any `gorm:"type:jsonb;serializer:json" json:"data,omitempty"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"-"`
 }
 
 func (NmapScriptResult) TableName() string {
@@ -89,7 +95,13 @@ type NmapScan struct {
 	ScanStart time.Time `gorm:"index:idx_scan_start" json:"scan_start"`
 	// command line args
 	ScanArgs string `gorm:"type:text" json:"scan_args,omitempty"`
-	// e.g. "7.94"
+	// Scope of the hosts of the scan, empty for none
+	Project string `gorm:"type:varchar(255);index;uniqueIndex:idx_scan_content_hash,priority:1" json:"project,omitempty"`
+	// SHA-256 of the imported file, a re-upload in the same project gives back this scan
+	ContentHash string `gorm:"type:varchar(64);uniqueIndex:idx_scan_content_hash,priority:2,where:content_hash <> ''" json:"content_hash,omitempty"`
+	// nmap / masscan
+	Scanner string `gorm:"type:varchar(50)" json:"scanner,omitempty"`
+	// e.g. "7.94" (or the masscan version)
 	NmapVersion string    `gorm:"type:varchar(50)" json:"nmap_version,omitempty"`
 	CreatedAt   time.Time `gorm:"autoCreateTime" json:"-"`
 
@@ -102,12 +114,25 @@ func (NmapScan) TableName() string {
 	return "nmap_scans"
 }
 
+// Namespace of host IDs, cf NewHostID
+var nmapHostNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/Robin-Van-de-Merghel/Shiryoku/nmap_hosts"))
+
+// NewHostID returns the stable ID of the host owning an address within a project (empty for none)
+// Importing the same address twice gives the same host, whatever the tool
+func NewHostID(address, project string) uuid.UUID {
+	return uuid.NewSHA1(nmapHostNamespace, []byte(project+"|"+address))
+}
+
 // NmapHost is dedicated to storing host info
-// A host can appear in multiple scans
+// A host can appear in multiple scans: it is identified by its address and project (see NewHostID),
+// and merged with the previous imports of the same address
 type NmapHost struct {
 	HostID uuid.UUID `gorm:"type:uuid;primaryKey" json:"host_id"`
-	// takes first address
-	Host      string         `gorm:"index:idx_host;type:varchar(255)" json:"host"`
+	// takes first IP address
+	Host    string `gorm:"index:idx_host;type:varchar(255)" json:"host"`
+	Project string `gorm:"type:varchar(255);index" json:"project,omitempty"`
+	// Same as Host, unless it isn't an IP (e.g. MAC address): searchable by network
+	IP        IPAddress      `gorm:"type:inet;index" json:"ip,omitempty"`
 	Addresses pq.StringArray `gorm:"type:text[]" json:"addresses,omitempty"`
 	// DNS names
 	Hostnames pq.StringArray `gorm:"type:text[]" json:"hostnames,omitempty"`
@@ -116,16 +141,43 @@ type NmapHost struct {
 	OSName     string `gorm:"type:varchar(255)" json:"os_name,omitempty"`
 	OSAccuracy int    `json:"os_accuracy,omitempty"`
 	// See nmap doc
-	Comment   string    `gorm:"type:text" json:"comment,omitempty"`
+	Comment string `gorm:"type:text" json:"comment,omitempty"`
+	// Oldest and latest scans of the host
+	FirstSeen time.Time `gorm:"index" json:"first_seen"`
+	LastSeen  time.Time `gorm:"index" json:"last_seen"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
 
-	ScanResults []ScanResult `gorm:"foreignKey:HostID" json:"scan_results,omitempty"`
+	ScanResults []ScanResult  `gorm:"foreignKey:HostID" json:"scan_results,omitempty"`
+	OSGuesses   []NmapOSGuess `gorm:"foreignKey:HostID" json:"os_guesses,omitempty"`
+	// Host scripts only (hostscript), port scripts are in ScanResults
+	HostScripts []NmapScriptResult `gorm:"foreignKey:HostID" json:"host_scripts,omitempty"`
 }
 
 func (NmapHost) TableName() string {
 	return "nmap_hosts"
 }
 
+// NmapOSGuess is an OS match of a host in a scan
+// OSName/OSAccuracy of the host only keep the latest best guess, this keeps all of them
+type NmapOSGuess struct {
+	OSGuessID uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"os_guess_id"`
+	HostID    uuid.UUID `gorm:"type:uuid;index" json:"host_id"`
+	ScanID    uuid.UUID `gorm:"type:uuid;index" json:"scan_id"`
+	// e.g. "Linux 5.0 - 5.14"
+	Name     string `gorm:"type:varchar(255)" json:"name"`
+	Accuracy int    `json:"accuracy"`
+	// From the first OS class, e.g. "Linux", "Linux", "5.X"
+	Vendor     string    `gorm:"type:varchar(255)" json:"vendor,omitempty"`
+	Family     string    `gorm:"type:varchar(255)" json:"family,omitempty"`
+	Generation string    `gorm:"type:varchar(50)" json:"generation,omitempty"`
+	SeenAt     time.Time `gorm:"index" json:"seen_at"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (NmapOSGuess) TableName() string {
+	return "nmap_os_guesses"
+}
+
 // ScanResult represents a discovery of a service in a scan on a specific host and port
 // Composite unique key: (ScanID, HostID, ServiceID, Port)
 // One ScanResult = one port + one scan + one host + one service
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Adapted from: https://github.com/projectdiscovery/nuclei/blob/dev/pkg/output/output.go
+
+// NucleiSeverity represents the severity of a nuclei template
+type NucleiSeverity string
+
+const (
+	NUCLEI_SEVERITY_INFO     NucleiSeverity = "info"
+	NUCLEI_SEVERITY_LOW      NucleiSeverity = "low"
+	NUCLEI_SEVERITY_MEDIUM   NucleiSeverity = "medium"
+	NUCLEI_SEVERITY_HIGH     NucleiSeverity = "high"
+	NUCLEI_SEVERITY_CRITICAL NucleiSeverity = "critical"
+	NUCLEI_SEVERITY_UNKNOWN  NucleiSeverity = "unknown"
+)
+
+func (ns NucleiSeverity) IsValid() bool {
+	switch ns {
+	case NUCLEI_SEVERITY_INFO, NUCLEI_SEVERITY_LOW, NUCLEI_SEVERITY_MEDIUM,
+		NUCLEI_SEVERITY_HIGH, NUCLEI_SEVERITY_CRITICAL, NUCLEI_SEVERITY_UNKNOWN:
+		return true
+	default:
+		return false
+	}
+}
+
+// NucleiFinding represents a single nuclei match (one line of `nuclei -jsonl`)
+// Linked to the nmap host and service seen on the same IP/port, if any
+type NucleiFinding struct {
+	FindingID uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"finding_id"`
+	// Links to nmap data, null when the target was never scanned
+	HostID    *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	ServiceID *uuid.UUID `gorm:"type:uuid;index" json:"service_id,omitempty"`
+
+	// Template information
+	TemplateID   string         `gorm:"type:varchar(255);index" json:"template_id"`
+	TemplateName string         `gorm:"type:varchar(255)" json:"template_name,omitempty"`
+	Severity     string         `gorm:"type:varchar(20);index" json:"severity"`
+	Tags         pq.StringArray `gorm:"type:text[]" json:"tags,omitempty"`
+	// http / dns / tcp / ...
+	Type string `gorm:"type:varchar(50)" json:"type,omitempty"`
+
+	// Target information
+	Host      string    `gorm:"type:varchar(255);index" json:"host"`
+	IP        IPAddress `gorm:"type:inet;index" json:"ip,omitempty"`
+	Port      uint16    `json:"port,omitempty"`
+	MatchedAt string    `gorm:"type:text" json:"matched_at,omitempty"`
+
+	// Match information
+	MatcherName      string         `gorm:"type:varchar(255)" json:"matcher_name,omitempty"`
+	ExtractedResults pq.StringArray `gorm:"type:text[]" json:"extracted_results,omitempty"`
+
+	// Classification
+	CVEIDs    pq.StringArray `gorm:"column:cve_ids;type:text[]" json:"cve_ids,omitempty"`
+	CWEIDs    pq.StringArray `gorm:"column:cwe_ids;type:text[]" json:"cwe_ids,omitempty"`
+	CVSSScore float64        `json:"cvss_score,omitempty"`
+
+	// When nuclei matched
+	Timestamp time.Time `gorm:"index" json:"timestamp"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (NucleiFinding) TableName() string {
+	return "nuclei_findings"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedSearch is a named search of a target (e.g. "nmap"), run by its ID
+// Its search and query may hold placeholders ({{since}}) of its variables, filled in at run time
+type SavedSearch struct {
+	SavedSearchID uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"saved_search_id"`
+	Name          string    `gorm:"type:varchar(255)" json:"name" validate:"required,max=255"`
+	Description   string    `gorm:"type:text" json:"description,omitempty"`
+	// Searched entity, e.g. "nmap" or "wpscan_components"
+	Target string `gorm:"type:varchar(50);index" json:"target" validate:"required"`
+
+	// As given to search, page and cursor being given at run time
+	Search SearchParams `gorm:"type:jsonb;serializer:json" json:"search"`
+	// As given in ?q=, e.g. "port:3389 first_seen:>={{since}}"
+	Query     string           `gorm:"type:text" json:"query,omitempty"`
+	Variables []SearchVariable `gorm:"type:jsonb;serializer:json" json:"variables,omitempty" validate:"dive"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (SavedSearch) TableName() string {
+	return "saved_searches"
+}
+
+// SearchVariable is a placeholder of a saved search, e.g. {{since}}
+type SearchVariable struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+	// Value when none is given at run time, the variable is required without it
+	Default *string `json:"default,omitempty"`
+}
+
+// SavedSearchRun is what may be given when running a saved search
+type SavedSearchRun struct {
+	// Values of the variables, e.g. {"since": "now-7d"}
+	Variables map[string]string `json:"variables,omitempty"`
+	Page      uint64            `json:"page"`
+	PerPage   uint64            `json:"per_page"`
+	Cursor    string            `json:"cursor,omitempty"`
+}
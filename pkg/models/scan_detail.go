@@ -0,0 +1,19 @@
+package models
+
+// NmapScanDetail is a scan with its hosts, each with the ports the scan found
+type NmapScanDetail struct {
+	NmapScan
+	Hosts []NmapScanDetailHost `json:"hosts"`
+}
+
+// NmapScanDetailHost is a host as seen by a scan: OS guesses, host scripts and ports are the ones of this scan only
+type NmapScanDetailHost struct {
+	NmapHost
+	Ports []NmapScanPort `json:"ports"`
+}
+
+// NmapScanPort is a scan result with its service
+type NmapScanPort struct {
+	ScanResult
+	Service *Service `json:"service,omitempty"`
+}
@@ -20,11 +20,42 @@ const (
 	OpLike    ScalarOperator = "like"
 	OpNotLike ScalarOperator = "not like"
 	OpRegex   ScalarOperator = "regex"
+	// Network operators, for IP addresses only
+	// e.g. "10.20.0.0/16" or "2001:db8::/32"
+	OpInCIDR    ScalarOperator = "in_cidr"
+	OpNotInCIDR ScalarOperator = "not_in_cidr"
+	// e.g. "10.0.0.1-10.0.0.254", see ParseIPRange
+	OpIPRange ScalarOperator = "ip_range"
+	// Array operators, for array fields only
+	// The array holds the value
+	OpContains ScalarOperator = "contains"
+	// An element of the array is like the value
+	OpAnyLike ScalarOperator = "any_like"
 )
 
 func (s ScalarOperator) IsValid() bool {
 	switch s {
-	case OpEq, OpNeq, OpGt, OpLt, OpLike, OpNotLike, OpRegex:
+	case OpEq, OpNeq, OpGt, OpLt, OpLike, OpNotLike, OpRegex, OpInCIDR, OpNotInCIDR, OpIPRange, OpContains, OpAnyLike:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsNetwork tells whether the operator only applies to IP addresses
+func (s ScalarOperator) IsNetwork() bool {
+	switch s {
+	case OpInCIDR, OpNotInCIDR, OpIPRange:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsArray tells whether the operator only applies to array fields
+func (s ScalarOperator) IsArray() bool {
+	switch s {
+	case OpContains, OpAnyLike:
 		return true
 	default:
 		return false
@@ -37,11 +68,44 @@ type VectorOperator string
 const (
 	OpIn    VectorOperator = "in"
 	OpNotIn VectorOperator = "not in"
+	// Array operators, for array fields only
+	// The array holds at least one of the values
+	OpContainsAny VectorOperator = "contains_any"
+	// The array holds all the values
+	OpContainsAll VectorOperator = "contains_all"
 )
 
 func (v VectorOperator) IsValid() bool {
 	switch v {
-	case OpIn, OpNotIn:
+	case OpIn, OpNotIn, OpContainsAny, OpContainsAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsArray tells whether the operator only applies to array fields
+func (v VectorOperator) IsArray() bool {
+	switch v {
+	case OpContainsAny, OpContainsAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// GroupOperator enum
+type GroupOperator string
+
+const (
+	OpAnd GroupOperator = "and"
+	OpOr  GroupOperator = "or"
+	OpNot GroupOperator = "not"
+)
+
+func (g GroupOperator) IsValid() bool {
+	switch g {
+	case OpAnd, OpOr, OpNot:
 		return true
 	default:
 		return false
@@ -84,10 +148,38 @@ type VectorSearchSpec struct {
 	Values    any            `json:"values" validate:"required"`
 }
 
-// SearchSpec either Vector, either Scalar
+// Nested specs, all (and) or any (or) of them matching, or a single one not matching (not)
+// e.g. {"or": [{...}, {"and": [{...}, {...}]}]} or {"not": {...}}
+type GroupSearchSpec struct {
+	Operator GroupOperator
+	Specs    []SearchSpec `validate:"dive"`
+}
+
+// SearchSpec either Vector, either Scalar, either a Group of specs
 type SearchSpec struct {
 	Scalar *ScalarSearchSpec
 	Vector *VectorSearchSpec
+	Group  *GroupSearchSpec
+}
+
+// CountMode enum, how the total of a search is counted
+type CountMode string
+
+const (
+	CountExact CountMode = "exact"
+	// From the query planner statistics, much faster on big tables
+	CountEstimated CountMode = "estimated"
+	// Not counted, e.g. when following cursors
+	CountNone CountMode = "none"
+)
+
+func (cm CountMode) IsValid() bool {
+	switch cm {
+	case CountExact, CountEstimated, CountNone:
+		return true
+	default:
+		return false
+	}
 }
 
 type SearchParams struct {
@@ -97,6 +189,9 @@ type SearchParams struct {
 	Distinct   bool         `json:"distinct"`
 	Page       uint64       `json:"page"`
 	PerPage    uint64       `json:"per_page"`
+	// NextCursor of the previous page, instead of page: deep pages stay as fast as the first one
+	Cursor string    `json:"cursor,omitempty"`
+	Count  CountMode `json:"count,omitempty"`
 }
 
 func (s *SearchParams) SetDefaults() {
@@ -104,6 +199,10 @@ func (s *SearchParams) SetDefaults() {
 		s.PerPage = DEFAULT_RESULTS_PER_PAGE
 	}
 
+	if s.Count == "" {
+		s.Count = CountExact
+	}
+
 	if s.Sort == nil {
 		s.Sort = []SortSpec{}
 	}
@@ -111,6 +210,25 @@ func (s *SearchParams) SetDefaults() {
 
 // Custom unmarshaler for SearchSpec
 func (s *SearchSpec) UnmarshalJSON(data []byte) error {
+	// Groups are keyed by their operator
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	for _, operator := range []GroupOperator{OpAnd, OpOr, OpNot} {
+		if raw, exists := keys[string(operator)]; exists {
+			if len(keys) != 1 {
+				return fmt.Errorf("%s group can't have other keys", operator)
+			}
+			group, err := unmarshalGroup(operator, raw)
+			if err != nil {
+				return err
+			}
+			s.Group = group
+			return nil
+		}
+	}
+
 	// First pass: read the operator to discriminate
 	var base struct {
 		Operator string `json:"operator"`
@@ -121,7 +239,7 @@ func (s *SearchSpec) UnmarshalJSON(data []byte) error {
 
 	// Second pass: unmarshal into correct type based on operator
 	switch VectorOperator(base.Operator) {
-	case OpIn, OpNotIn:
+	case OpIn, OpNotIn, OpContainsAny, OpContainsAll:
 		var vector VectorSearchSpec
 		if err := json.Unmarshal(data, &vector); err != nil {
 			return err
@@ -145,7 +263,49 @@ func (s *SearchSpec) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Custom marshaler for SearchSpec, the way UnmarshalJSON reads it (e.g. to save searches)
+func (s SearchSpec) MarshalJSON() ([]byte, error) {
+	switch {
+	case s.Scalar != nil:
+		return json.Marshal(s.Scalar)
+	case s.Vector != nil:
+		return json.Marshal(s.Vector)
+	case s.Group != nil && s.Group.Operator == OpNot && len(s.Group.Specs) == 1:
+		return json.Marshal(map[GroupOperator]SearchSpec{OpNot: s.Group.Specs[0]})
+	case s.Group != nil:
+		return json.Marshal(map[GroupOperator][]SearchSpec{s.Group.Operator: s.Group.Specs})
+	default:
+		return nil, fmt.Errorf("empty search spec")
+	}
+}
+
+// "and" and "or" take a non-empty list of specs, "not" a single spec
+func unmarshalGroup(operator GroupOperator, raw json.RawMessage) (*GroupSearchSpec, error) {
+	group := &GroupSearchSpec{Operator: operator}
+
+	if operator == OpNot {
+		var spec SearchSpec
+		if err := json.Unmarshal(raw, &spec); err != nil {
+			return nil, fmt.Errorf("invalid not group: %w", err)
+		}
+		group.Specs = []SearchSpec{spec}
+		return group, nil
+	}
+
+	if err := json.Unmarshal(raw, &group.Specs); err != nil {
+		return nil, fmt.Errorf("invalid %s group: %w", operator, err)
+	}
+	if len(group.Specs) == 0 {
+		return nil, fmt.Errorf("%s group can't be empty", operator)
+	}
+	return group, nil
+}
+
 type SearchResult[T any] struct {
-	Total   uint64 `json:"total"`
-	Results []T    `json:"results"`
+	Total uint64 `json:"total"`
+	// How total was counted, it is 0 with CountNone
+	Count   CountMode `json:"count"`
+	Results []T       `json:"results"`
+	// Cursor of the next page, empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ServiceDetail is a service signature, with when nmap scans found it and where it is exposed
+type ServiceDetail struct {
+	Service
+	// Oldest and latest scans that found the service, empty if no nmap scan did (e.g. only used by other tools)
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	// Ports open with this service as of the latest scan of their host
+	Exposures []ServiceExposure `gorm:"-" json:"exposures"`
+}
+
+// ServiceExposure is a port of a host currently exposing a service
+type ServiceExposure struct {
+	HostID    uuid.UUID      `json:"host_id"`
+	Host      string         `json:"host"`
+	Hostnames pq.StringArray `gorm:"type:text[]" json:"hostnames,omitempty"`
+	Port      uint16         `json:"port"`
+	// Latest scan that reported the port
+	ScanID    uuid.UUID `json:"scan_id"`
+	ScanStart time.Time `json:"scan_start"`
+}
+
+// ServiceVersion is a version of a product, across the service signatures that have it
+type ServiceVersion struct {
+	ServiceProduct string `json:"service_product"`
+	ServiceVersion string `json:"service_version"`
+	// Hosts currently exposing it
+	Hosts     int64     `json:"hosts"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
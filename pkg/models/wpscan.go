@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Adapted from: https://github.com/wpscanteam/wpscan/tree/master/app/views/json
+
+// WPScanComponentType is the kind of WordPress component
+type WPScanComponentType string
+
+const (
+	WPSCAN_COMPONENT_WORDPRESS WPScanComponentType = "wordpress"
+	WPSCAN_COMPONENT_PLUGIN    WPScanComponentType = "plugin"
+	WPSCAN_COMPONENT_THEME     WPScanComponentType = "theme"
+)
+
+func (ct WPScanComponentType) IsValid() bool {
+	switch ct {
+	case WPSCAN_COMPONENT_WORDPRESS, WPSCAN_COMPONENT_PLUGIN, WPSCAN_COMPONENT_THEME:
+		return true
+	default:
+		return false
+	}
+}
+
+// WPScanScan is a single `wpscan --format json` run against a WordPress site
+// Linked to the nmap host and service of the target, if any
+type WPScanScan struct {
+	ScanID uuid.UUID `gorm:"type:uuid;primaryKey" json:"scan_id"`
+	// Links to nmap data, null when the target was never scanned
+	HostID    *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	ServiceID *uuid.UUID `gorm:"type:uuid;index" json:"service_id,omitempty"`
+
+	// Target information
+	TargetURL    string `gorm:"type:text;index" json:"target_url"`
+	TargetIP     string `gorm:"type:varchar(64)" json:"target_ip,omitempty"`
+	EffectiveURL string `gorm:"type:text" json:"effective_url,omitempty"`
+	Port         uint16 `json:"port,omitempty"`
+
+	// WordPress core
+	WordPressVersion string `gorm:"column:wordpress_version;type:varchar(50);index" json:"wordpress_version,omitempty"`
+	// latest / outdated / insecure
+	WordPressStatus string `gorm:"column:wordpress_status;type:varchar(20)" json:"wordpress_status,omitempty"`
+	MainTheme       string `gorm:"type:varchar(255)" json:"main_theme,omitempty"`
+
+	ScanStart time.Time `gorm:"index" json:"scan_start"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+
+	// Relations
+	Components      []WPScanComponent     `gorm:"foreignKey:ScanID;constraint:OnDelete:CASCADE" json:"components,omitempty"`
+	Findings        []WPScanFinding       `gorm:"foreignKey:ScanID;constraint:OnDelete:CASCADE" json:"findings,omitempty"`
+	Vulnerabilities []WPScanVulnerability `gorm:"foreignKey:ScanID;constraint:OnDelete:CASCADE" json:"vulnerabilities,omitempty"`
+}
+
+func (WPScanScan) TableName() string {
+	return "wpscan_scans"
+}
+
+// WPScanComponent is an enumerated plugin or theme (or WordPress itself) with its version
+// Target information is repeated so that components can be searched on their own
+type WPScanComponent struct {
+	ComponentID uuid.UUID  `gorm:"type:uuid;primaryKey" json:"component_id"`
+	ScanID      uuid.UUID  `gorm:"type:uuid;index" json:"scan_id"`
+	HostID      *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	TargetURL   string     `gorm:"type:text" json:"target_url"`
+
+	// wordpress / plugin / theme
+	Type          string `gorm:"type:varchar(20);index:idx_wpscan_component" json:"type"`
+	Slug          string `gorm:"type:varchar(255);index:idx_wpscan_component" json:"slug"`
+	Version       string `gorm:"type:varchar(50);index:idx_wpscan_component" json:"version,omitempty"`
+	LatestVersion string `gorm:"type:varchar(50)" json:"latest_version,omitempty"`
+	Outdated      bool   `json:"outdated"`
+	Location      string `gorm:"type:text" json:"location,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (WPScanComponent) TableName() string {
+	return "wpscan_components"
+}
+
+// WPScanFinding is an "interesting finding" (headers, readme, xml-rpc, ...)
+type WPScanFinding struct {
+	FindingID uuid.UUID  `gorm:"type:uuid;primaryKey" json:"finding_id"`
+	ScanID    uuid.UUID  `gorm:"type:uuid;index" json:"scan_id"`
+	HostID    *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	TargetURL string     `gorm:"type:text" json:"target_url"`
+
+	URL                string              `gorm:"type:text" json:"url"`
+	Type               string              `gorm:"type:varchar(50)" json:"type,omitempty"`
+	Description        string              `gorm:"type:text" json:"description,omitempty"`
+	FoundBy            string              `gorm:"type:varchar(255)" json:"found_by,omitempty"`
+	Confidence         int                 `json:"confidence,omitempty"`
+	InterestingEntries pq.StringArray      `gorm:"type:text[]" json:"interesting_entries,omitempty"`
+	References         map[string][]string `gorm:"type:jsonb;serializer:json" json:"references,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (WPScanFinding) TableName() string {
+	return "wpscan_findings"
+}
+
+// WPScanVulnerability is a vulnerability listed for the WordPress version, a plugin or a theme
+// Component and target information is repeated to search vulnerable sites directly
+type WPScanVulnerability struct {
+	VulnerabilityID uuid.UUID  `gorm:"type:uuid;primaryKey" json:"vulnerability_id"`
+	ScanID          uuid.UUID  `gorm:"type:uuid;index" json:"scan_id"`
+	ComponentID     uuid.UUID  `gorm:"type:uuid;index" json:"component_id"`
+	HostID          *uuid.UUID `gorm:"type:uuid;index" json:"host_id,omitempty"`
+	TargetURL       string     `gorm:"type:text" json:"target_url"`
+
+	// Vulnerable component
+	ComponentType    string `gorm:"type:varchar(20);index:idx_wpscan_vulnerable_component" json:"component_type"`
+	ComponentSlug    string `gorm:"type:varchar(255);index:idx_wpscan_vulnerable_component" json:"component_slug"`
+	ComponentVersion string `gorm:"type:varchar(50)" json:"component_version,omitempty"`
+
+	Title   string `gorm:"type:text" json:"title"`
+	FixedIn string `gorm:"type:varchar(50)" json:"fixed_in,omitempty"`
+	// e.g. CVE-2020-35489
+	CVEIDs pq.StringArray `gorm:"column:cve_ids;type:text[]" json:"cve_ids,omitempty"`
+	// cve, url, wpvulndb, ... as listed by wpscan
+	References map[string][]string `gorm:"type:jsonb;serializer:json" json:"references,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (WPScanVulnerability) TableName() string {
+	return "wpscan_vulnerabilities"
+}
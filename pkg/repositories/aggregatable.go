@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+)
+
+// AggregatableRepository is a generic interface for any resource whose results can be counted by group
+// T is the searched model, as for SearchableRepository[T]
+type AggregatableRepository[T any] interface {
+	Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+}
+
+// AggregateFunc allows a repository to expose more than one aggregatable resource
+// (e.g. AggregateFunc[models.WPScanComponent](repo.AggregateComponents))
+type AggregateFunc[T any] func(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+
+func (f AggregateFunc[T]) Aggregate(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error) {
+	return f(ctx, params)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// FfufRepository defines database operations specific to ffuf results
+type FfufRepository interface {
+	SearchableRepository[models.FfufResult]
+	AggregatableRepository[models.FfufResult]
+
+	// InsertResults inserts ffuf hits
+	InsertResults(ctx context.Context, results []models.FfufResult) error
+
+	ReadyCheck() utils.Checker
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// HostRepository defines database operations on hosts, across the scans they appear in
+type HostRepository interface {
+	SearchableRepository[models.NmapHost]
+	AggregatableRepository[models.NmapHost]
+
+	// GetHost retrieves a host, with the OS guesses and host scripts of the latest scan that had some
+	// Returns a NotFoundError if there is none
+	GetHost(ctx context.Context, hostID string) (*models.NmapHost, error)
+
+	// GetHostScans retrieves the scans a host appears in (ports, OS guesses or host scripts), the oldest first
+	GetHostScans(ctx context.Context, hostID string) ([]models.NmapScan, error)
+
+	// GetHostHistory retrieves every scan result of a host, with its scan, service and scripts, the oldest scans first
+	GetHostHistory(ctx context.Context, hostID string) ([]models.HostScanResult, error)
+
+	ReadyCheck() utils.Checker
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// HttpxRepository defines database operations specific to httpx results
+type HttpxRepository interface {
+	SearchableRepository[models.HttpxResult]
+	AggregatableRepository[models.HttpxResult]
+
+	// InsertResults inserts httpx results
+	InsertResults(ctx context.Context, results []models.HttpxResult) error
+
+	ReadyCheck() utils.Checker
+}
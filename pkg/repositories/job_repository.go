@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// JobRepository defines database operations on ingestion jobs
+type JobRepository interface {
+	// CreateJob inserts a new job
+	CreateJob(ctx context.Context, job *models.IngestionJob) error
+
+	// GetJob retrieves a job
+	// Returns a NotFoundError if there is none
+	GetJob(ctx context.Context, jobID string) (*models.IngestionJob, error)
+
+	// ClaimJob marks the oldest queued job as running and returns it, so that no other worker runs it
+	// Running jobs without heartbeat for models.JOB_STALE_TIMEOUT are claimed again, from scratch
+	// Returns a NotFoundError if no job is queued
+	ClaimJob(ctx context.Context) (*models.IngestionJob, error)
+
+	// HeartbeatJob tells that the worker of a running job is still running it
+	HeartbeatJob(ctx context.Context, jobID string) error
+
+	// UpdateJobProgress sets the number of hosts and ports processed by a running job
+	UpdateJobProgress(ctx context.Context, jobID string, hosts, ports int64) error
+
+	// FinishJob saves the outcome of a job: status, file results, error and progress
+	FinishJob(ctx context.Context, job *models.IngestionJob) error
+
+	ReadyCheck() utils.Checker
+}
@@ -5,36 +5,72 @@ import (
 
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
 	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+	"github.com/google/uuid"
 )
 
 // NmapRepository defines database operations specific to nmap scan data
 type NmapRepository interface {
 	SearchableRepository[models.NmapScan]
+	AggregatableRepository[models.NmapScan]
 
 	// GetScan retrieves a single scan with all its scan results
+	// Returns a NotFoundError if there is none
 	GetScan(ctx context.Context, scanID string) (*models.NmapScan, error)
 
-	// GetHosts retrieves all unique hosts from a scan
+	// GetHosts retrieves the hosts of a scan, with or without ports (e.g. down hosts)
 	GetHosts(ctx context.Context, scanID string) ([]models.NmapHost, error)
 
 	// GetScanResults retrieves all scan results (ports discovered) for a specific scan and host
 	GetScanResults(ctx context.Context, scanID, hostID string) ([]models.ScanResult, error)
 
+	// GetScanServices retrieves the services of the scan results of a scan
+	GetScanServices(ctx context.Context, scanID string) ([]models.Service, error)
+
+	// DeleteScan deletes a scan with its scan results, scripts, OS guesses and dashboard rows
+	// Hosts and services left without data (from nmap or other tools) are deleted too
+	// Returns a NotFoundError if there is no such scan
+	DeleteScan(ctx context.Context, scanID string) error
+
+	// FindHostByAddress retrieves the most recent host of a project (empty for none) owning an address (IP or hostname)
+	// Returns a NotFoundError if no host matches
+	FindHostByAddress(ctx context.Context, project, address string) (*models.NmapHost, error)
+
+	// FindLatestScanResult retrieves the most recent scan result for a host and port
+	// Returns a NotFoundError if the port was never scanned on this host
+	FindLatestScanResult(ctx context.Context, hostID string, port uint16) (*models.ScanResult, error)
+
+	// FindScanByHash fetches the scan imported from a file with this hash in a project
+	// Returns a NotFoundError if there is none
+	FindScanByHash(ctx context.Context, project, contentHash string) (*models.NmapScan, error)
+
+	// WithTransaction runs fn with a repository bound to a single transaction
+	// Everything done through it is rolled back if fn returns an error
+	WithTransaction(ctx context.Context, fn func(repo NmapRepository) error) error
+
 	// GetOrCreateService retrieves or creates a service by its signature
 	// (ServiceName + Product + Version + ExtraInfo + Protocol + Tunnel)
 	GetOrCreateService(ctx context.Context, service *models.Service) (*models.Service, error)
 
 	// InsertScan inserts a new scan
+	// Returns an AlreadyExistsError when a scan was imported from the same file in the same project
 	InsertScan(ctx context.Context, scan *models.NmapScan) error
 
-	// InsertHosts inserts or updates hosts (upsert by host IP)
+	// InsertHosts inserts hosts, or merges them into the existing ones with the same ID
+	// Addresses and hostnames are merged, first/last seen dates widened, and the rest kept from the latest scan
 	InsertHosts(ctx context.Context, hosts []models.NmapHost) error
 
+	// InsertScanHosts links hosts to a scan that found them, ports or not
+	// Hosts already linked to the scan are skipped
+	InsertScanHosts(ctx context.Context, scanID string, hostIDs []uuid.UUID) error
+
 	// InsertScanResults inserts scan result records (ports discovered in a scan on a host with a service)
 	InsertScanResults(ctx context.Context, results []models.ScanResult) error
 
 	// InsertScripts inserts NSE script results
 	InsertScripts(ctx context.Context, scripts []models.NmapScriptResult) error
 
+	// InsertOSGuesses inserts the OS matches of hosts
+	InsertOSGuesses(ctx context.Context, guesses []models.NmapOSGuess) error
+
 	ReadyCheck() utils.Checker
 }
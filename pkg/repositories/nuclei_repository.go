@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// NucleiRepository defines database operations specific to nuclei findings
+type NucleiRepository interface {
+	SearchableRepository[models.NucleiFinding]
+	AggregatableRepository[models.NucleiFinding]
+
+	// InsertFindings inserts nuclei findings
+	InsertFindings(ctx context.Context, findings []models.NucleiFinding) error
+
+	ReadyCheck() utils.Checker
+}
@@ -5,8 +5,16 @@ import (
 )
 
 const (
-	NMAP_REPOSITORY      = "nmap"
-	DASHBOARD_REPOSITORY = "dashboard"
+	NMAP_REPOSITORY         = "nmap"
+	DASHBOARD_REPOSITORY    = "dashboard"
+	NUCLEI_REPOSITORY       = "nuclei"
+	HTTPX_REPOSITORY        = "httpx"
+	FFUF_REPOSITORY         = "ffuf"
+	WPSCAN_REPOSITORY       = "wpscan"
+	JOB_REPOSITORY          = "jobs"
+	SAVED_SEARCH_REPOSITORY = "saved_searches"
+	HOST_REPOSITORY         = "hosts"
+	SERVICE_REPOSITORY      = "services"
 )
 
 // RepositoryProvider allows access to repositories and custom extensions
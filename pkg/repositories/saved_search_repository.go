@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// SavedSearchRepository defines database operations on saved searches
+type SavedSearchRepository interface {
+	// CreateSavedSearch inserts a new saved search
+	CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error
+
+	// GetSavedSearch retrieves a saved search
+	// Returns a NotFoundError if there is none
+	GetSavedSearch(ctx context.Context, searchID string) (*models.SavedSearch, error)
+
+	// ListSavedSearches retrieves the saved searches of a target (all of them if empty), by name
+	ListSavedSearches(ctx context.Context, target string) ([]models.SavedSearch, error)
+
+	// UpdateSavedSearch replaces the name, description, target, search and variables of a saved search
+	// Returns a NotFoundError if there is none
+	UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) error
+
+	// DeleteSavedSearch removes a saved search
+	// Returns a NotFoundError if there is none
+	DeleteSavedSearch(ctx context.Context, searchID string) error
+
+	ReadyCheck() utils.Checker
+}
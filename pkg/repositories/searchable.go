@@ -8,5 +8,13 @@ import (
 
 // SearchableRepository is a generic interface for any resource that supports search and pagination
 type SearchableRepository[T any] interface {
-	Search(ctx context.Context, params *models.SearchParams) (uint64, []T, error)
+	Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[T], error)
+}
+
+// SearchFunc allows a repository to expose more than one searchable resource
+// (e.g. SearchFunc[models.WPScanComponent](repo.SearchComponents))
+type SearchFunc[T any] func(ctx context.Context, params *models.SearchParams) (*models.SearchResult[T], error)
+
+func (f SearchFunc[T]) Search(ctx context.Context, params *models.SearchParams) (*models.SearchResult[T], error) {
+	return f(ctx, params)
 }
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// ServiceRepository defines database operations on services, deduplicated across scans
+type ServiceRepository interface {
+	SearchableRepository[models.Service]
+	AggregatableRepository[models.Service]
+
+	// GetService retrieves a service with the dates of the oldest and latest scans that found it
+	// Returns a NotFoundError if there is none
+	GetService(ctx context.Context, serviceID string) (*models.ServiceDetail, error)
+
+	// GetServiceExposures retrieves the ports open with a service as of the latest scan of their host
+	GetServiceExposures(ctx context.Context, serviceID string) ([]models.ServiceExposure, error)
+
+	// GetServiceVersions retrieves the versions of the services of a product (of every product if empty),
+	// with the number of hosts currently exposing each
+	GetServiceVersions(ctx context.Context, product string) ([]models.ServiceVersion, error)
+
+	ReadyCheck() utils.Checker
+}
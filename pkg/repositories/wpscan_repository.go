@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/models"
+	"github.com/Robin-Van-de-Merghel/Shiryoku/pkg/utils"
+)
+
+// WPScanRepository defines database operations specific to wpscan data
+type WPScanRepository interface {
+	// Search retrieves wpscan scans with their components, findings and vulnerabilities
+	SearchableRepository[models.WPScanScan]
+	AggregatableRepository[models.WPScanScan]
+
+	// SearchComponents retrieves enumerated plugins, themes and WordPress versions
+	SearchComponents(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanComponent], error)
+
+	// SearchFindings retrieves interesting findings
+	SearchFindings(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanFinding], error)
+
+	// SearchVulnerabilities retrieves listed vulnerabilities
+	SearchVulnerabilities(ctx context.Context, params *models.SearchParams) (*models.SearchResult[models.WPScanVulnerability], error)
+
+	// AggregateComponents, AggregateFindings and AggregateVulnerabilities count them by group
+	AggregateComponents(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	AggregateFindings(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+	AggregateVulnerabilities(ctx context.Context, params *models.AggregationParams) (*models.AggregationResult, error)
+
+	// InsertScan inserts a scan with its components, findings and vulnerabilities
+	InsertScan(ctx context.Context, scan *models.WPScanScan) error
+
+	ReadyCheck() utils.Checker
+}